@@ -11,10 +11,11 @@ require (
 	github.com/eknkc/basex v1.0.0 // indirect
 	github.com/golang-migrate/migrate/v4 v4.4.0
 	github.com/golang/protobuf v1.3.2 // indirect
-	github.com/google/go-cmp v0.3.0
+	github.com/google/go-cmp v0.6.0
 	github.com/gotestyourself/gotestyourself v2.2.0+incompatible // indirect
 	github.com/hako/branca v0.0.0-20180808000428-10b799466ada
 	github.com/jackc/pgx v3.4.0+incompatible
+	github.com/jung-kurt/gofpdf v1.16.2
 	github.com/lib/pq v1.1.1
 	github.com/matoous/go-nanoid v0.0.0-20190515092250-e998f83de84d
 	github.com/matryer/vice v1.0.0
@@ -26,5 +27,7 @@ require (
 	github.com/opencontainers/runc v0.1.1 // indirect
 	github.com/ory/dockertest v3.3.4+incompatible
 	github.com/sirupsen/logrus v1.4.2
-	golang.org/x/crypto v0.0.0-20190701094942-4def268fd1a4
+	github.com/skip2/go-qrcode v0.0.0-20200617195104-da1b6568686e
+	golang.org/x/crypto v0.23.0
+	golang.org/x/image v0.18.0
 )
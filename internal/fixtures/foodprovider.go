@@ -18,8 +18,6 @@ const (
 	city     string = "Dhaka"
 	area     string = "Gulshan"
 	country  string = "Bangladesh"
-	oTime    string = "8AM"
-	cTime    string = "11PM"
 	referral string = ""
 )
 
@@ -39,7 +37,7 @@ func PopulateFoodProvider(s *service.Service) {
 
 	fmt.Println(&fp)
 
-	err = s.CreateRestaurant(ctx, title, about, phone, location, city, area, country, oTime, cTime)
+	err = s.CreateRestaurant(ctx, title, about, phone, location, city, area, country)
 	if err != nil {
 		fmt.Println(err)
 		return
@@ -0,0 +1,72 @@
+package handler
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+
+	"github.com/matryer/way"
+
+	"ovto/internal/service"
+)
+
+func respondComboErr(w http.ResponseWriter, err error) {
+	if err == service.ErrUnauthenticated {
+		http.Error(w, err.Error(), http.StatusUnauthorized)
+		return
+	}
+
+	if err == service.ErrRestaurantNotFound || err == service.ErrItemNotFound {
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return
+	}
+
+	if err == service.ErrEmptyValue || err == service.ErrInvalidQuantity || err == service.ErrInvalidCombo {
+		http.Error(w, err.Error(), http.StatusUnprocessableEntity)
+		return
+	}
+
+	respondErr(w, err)
+}
+
+func (h *handler) getComboSlots(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	rID := way.Param(ctx, "restaurant_id")
+	iID, err := strconv.ParseInt(way.Param(ctx, "item_id"), 10, 64)
+	if err != nil {
+		respondComboErr(w, service.ErrItemNotFound)
+		return
+	}
+
+	ss, err := h.GetComboSlots(ctx, rID, iID)
+	if err != nil {
+		respondComboErr(w, err)
+		return
+	}
+
+	respond(w, ss, http.StatusOK)
+}
+
+func (h *handler) setComboSlots(w http.ResponseWriter, r *http.Request) {
+	var in []service.ComboSlot
+	defer r.Body.Close()
+	if err := json.NewDecoder(r.Body).Decode(&in); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	ctx := r.Context()
+	rID := way.Param(ctx, "restaurant_id")
+	iID, err := strconv.ParseInt(way.Param(ctx, "item_id"), 10, 64)
+	if err != nil {
+		respondComboErr(w, service.ErrItemNotFound)
+		return
+	}
+
+	if err = h.SetComboSlots(ctx, rID, iID, in); err != nil {
+		respondComboErr(w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
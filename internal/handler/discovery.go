@@ -0,0 +1,103 @@
+package handler
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+
+	"github.com/matryer/way"
+
+	"ovto/internal/service"
+)
+
+type restaurantCuisinesInput struct {
+	Cuisines []string `json:"cuisines"`
+}
+
+// restaurantFilter reads the listing filters from the query string, e.g.
+// ?city=Dhaka&area=Banani&open_now=true&min_rating=4&cuisines=thai,chinese&sort=rating&first=20&after=....
+func restaurantFilter(r *http.Request) (service.RestaurantFilter, error) {
+	q := r.URL.Query()
+	f := service.RestaurantFilter{City: q.Get("city"), Area: q.Get("area"), Cuisines: queryList(q, "cuisines"),
+		Sort: q.Get("sort"), After: q.Get("after")}
+	var err error
+	if s := q.Get("min_rating"); s != "" {
+		if f.MinRating, err = strconv.ParseFloat(s, 64); err != nil {
+			return f, service.ErrInvalidRating
+		}
+	}
+
+	f.OpenNow, _ = strconv.ParseBool(q.Get("open_now"))
+	f.First, _ = strconv.Atoi(q.Get("first"))
+	return f, nil
+}
+
+func respondDiscoveryErr(w http.ResponseWriter, err error) {
+	if err == service.ErrUnauthenticated {
+		http.Error(w, err.Error(), http.StatusUnauthorized)
+		return
+	}
+
+	if err == service.ErrRestaurantNotFound {
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return
+	}
+
+	if err == service.ErrInvalidSort || err == service.ErrInvalidCursor || err == service.ErrInvalidRating ||
+		err == service.ErrUnknownTag {
+		http.Error(w, err.Error(), http.StatusUnprocessableEntity)
+		return
+	}
+
+	respondErr(w, err)
+}
+
+func (h *handler) getRestaurantListing(w http.ResponseWriter, r *http.Request) {
+	f, err := restaurantFilter(r)
+	if err != nil {
+		respondDiscoveryErr(w, err)
+		return
+	}
+
+	p, err := h.GetRestaurants(r.Context(), f)
+	if err != nil {
+		respondDiscoveryErr(w, err)
+		return
+	}
+
+	respond(w, p, http.StatusOK)
+}
+
+func (h *handler) getRestaurant(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	rID := way.Param(ctx, "restaurant_id")
+	p, err := h.GetRestaurantByID(ctx, rID)
+	if err != nil {
+		respondDiscoveryErr(w, err)
+		return
+	}
+
+	respond(w, p, http.StatusOK)
+}
+
+func (h *handler) getCuisines(w http.ResponseWriter, r *http.Request) {
+	respond(w, service.Cuisines, http.StatusOK)
+}
+
+func (h *handler) setRestaurantCuisines(w http.ResponseWriter, r *http.Request) {
+	var in restaurantCuisinesInput
+	defer r.Body.Close()
+	if err := json.NewDecoder(r.Body).Decode(&in); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	ctx := r.Context()
+	rID := way.Param(ctx, "restaurant_id")
+	if err := h.SetRestaurantCuisines(ctx, rID, in.Cuisines); err != nil {
+		respondDiscoveryErr(w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
@@ -0,0 +1,88 @@
+package handler
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+
+	"github.com/matryer/way"
+
+	"ovto/internal/service"
+)
+
+// nearbyQuery reads a nearby search from the query string, e.g.
+// ?lat=23.7937&lng=90.4066&radius=3&limit=20.
+func nearbyQuery(r *http.Request) (service.NearbyQuery, error) {
+	q := r.URL.Query()
+	var nearby service.NearbyQuery
+	var err error
+	if nearby.Latitude, err = strconv.ParseFloat(q.Get("lat"), 64); err != nil {
+		return nearby, service.ErrInvalidCoordinates
+	}
+
+	if nearby.Longitude, err = strconv.ParseFloat(q.Get("lng"), 64); err != nil {
+		return nearby, service.ErrInvalidCoordinates
+	}
+
+	if s := q.Get("radius"); s != "" {
+		if nearby.RadiusKm, err = strconv.ParseFloat(s, 64); err != nil {
+			return nearby, service.ErrInvalidRadius
+		}
+	}
+
+	nearby.Limit, _ = strconv.Atoi(q.Get("limit"))
+	return nearby, nil
+}
+
+func respondGeoErr(w http.ResponseWriter, err error) {
+	if err == service.ErrUnauthenticated {
+		http.Error(w, err.Error(), http.StatusUnauthorized)
+		return
+	}
+
+	if err == service.ErrRestaurantNotFound {
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return
+	}
+
+	if err == service.ErrInvalidCoordinates || err == service.ErrInvalidRadius {
+		http.Error(w, err.Error(), http.StatusUnprocessableEntity)
+		return
+	}
+
+	respondErr(w, err)
+}
+
+func (h *handler) getNearbyRestaurants(w http.ResponseWriter, r *http.Request) {
+	q, err := nearbyQuery(r)
+	if err != nil {
+		respondGeoErr(w, err)
+		return
+	}
+
+	rr, err := h.NearbyRestaurants(r.Context(), q)
+	if err != nil {
+		respondGeoErr(w, err)
+		return
+	}
+
+	respond(w, rr, http.StatusOK)
+}
+
+func (h *handler) setRestaurantCoordinates(w http.ResponseWriter, r *http.Request) {
+	var in service.Coordinates
+	defer r.Body.Close()
+	if err := json.NewDecoder(r.Body).Decode(&in); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	ctx := r.Context()
+	rID := way.Param(ctx, "restaurant_id")
+	if err := h.SetRestaurantCoordinates(ctx, rID, in); err != nil {
+		respondGeoErr(w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
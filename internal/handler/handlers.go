@@ -24,6 +24,14 @@ func New(s *service.Service) http.Handler {
 	userApi.HandleFunc("DELETE", "/users", h.deleteUser)
 	userApi.HandleFunc("PUT", "/auth_user/dp", h.updateDisplayPicture)
 	userApi.HandleFunc("POST", "/:restaurant_id/order", h.createUserOrder)
+	userApi.HandleFunc("GET", "/search", h.search)
+	userApi.HandleFunc("GET", "/dietary", h.getDietaryVocabulary)
+	userApi.HandleFunc("GET", "/cuisines", h.getCuisines)
+	userApi.HandleFunc("GET", "/:restaurant_id/reviews", h.getReviews)
+	userApi.HandleFunc("GET", "/:restaurant_id/hours", h.getRestaurantHours)
+	userApi.HandleFunc("POST", "/orders/:order_id/review", h.createReview)
+	userApi.HandleFunc("POST", "/reviews/:review_id/pictures", h.createReviewPicture)
+	userApi.HandleFunc("POST", "/reviews/:review_id/flag", h.flagReview)
 
 	foodProviderApi := way.NewRouter()
 	foodProviderApi.HandleFunc("POST", "/users", h.createFoodProvider)
@@ -37,6 +45,21 @@ func New(s *service.Service) http.Handler {
 	restaurantApi.HandleFunc("PUT", "/:restaurant_id", h.updateRestaurant)
 	restaurantApi.HandleFunc("PUT", "/:restaurant_id/dp", h.updateRestaurantDisplayPicture)
 	restaurantApi.HandleFunc("PUT", "/:restaurant_id/cover", h.updateRestaurantCoverPicture)
+	restaurantApi.HandleFunc("GET", "/:restaurant_id/translations", h.getTranslations)
+	restaurantApi.HandleFunc("PUT", "/:restaurant_id/cuisines", h.setRestaurantCuisines)
+	restaurantApi.HandleFunc("PUT", "/:restaurant_id/coordinates", h.setRestaurantCoordinates)
+	restaurantApi.HandleFunc("GET", "/:restaurant_id/hours", h.getRestaurantHours)
+	restaurantApi.HandleFunc("PUT", "/:restaurant_id/hours", h.setRestaurantHours)
+	restaurantApi.HandleFunc("PUT", "/:restaurant_id/hours/exceptions/:date", h.setHoursException)
+	restaurantApi.HandleFunc("DELETE", "/:restaurant_id/hours/exceptions/:date", h.deleteHoursException)
+	restaurantApi.HandleFunc("POST", "/:restaurant_id/pause", h.pauseOrdering)
+	restaurantApi.HandleFunc("DELETE", "/:restaurant_id/pause", h.resumeOrdering)
+	restaurantApi.HandleFunc("GET", "/:restaurant_id/qr", h.getMenuQR)
+	restaurantApi.HandleFunc("POST", "/:restaurant_id/tables", h.createTable)
+	restaurantApi.HandleFunc("GET", "/:restaurant_id/tables", h.getTables)
+	restaurantApi.HandleFunc("DELETE", "/:restaurant_id/tables/:table_id", h.archiveTable)
+	restaurantApi.HandleFunc("GET", "/:restaurant_id/tables/:table_id/qr", h.getMenuQR)
+	restaurantApi.HandleFunc("PUT", "/:restaurant_id/translations/:locale", h.setRestaurantTranslation)
 	restaurantApi.HandleFunc("POST", "/:restaurant_id/gallery", h.createRestaurantGalleryPicture)
 	restaurantApi.HandleFunc("GET", "/:restaurant_id/gallery", h.getRestaurantGallery)
 	restaurantApi.HandleFunc("DELETE", "/:restaurant_id/gallery/:image", h.deleteRestaurantGalleryPicture)
@@ -44,9 +67,80 @@ func New(s *service.Service) http.Handler {
 	restaurantApi.HandleFunc("DELETE", "/:restaurant_id/offers/:image", h.deleteRestaurantOffersPicture)
 	restaurantApi.HandleFunc("POST", "/:restaurant_id/category", h.createCategory)
 	restaurantApi.HandleFunc("GET", "/:restaurant_id/category", h.getCategoriesByRestaurant)
+	restaurantApi.HandleFunc("PUT", "/:restaurant_id/category/positions", h.reorderCategories)
+	restaurantApi.HandleFunc("PUT", "/:restaurant_id/category/:category_id", h.updateCategory)
+	restaurantApi.HandleFunc("PUT", "/:restaurant_id/category/:category_id/positions", h.reorderItems)
+	restaurantApi.HandleFunc("DELETE", "/:restaurant_id/category/:category_id", h.archiveCategory)
+	restaurantApi.HandleFunc("PUT", "/:restaurant_id/category/:category_id/availability", h.setCategoryItemsAvailability)
+	restaurantApi.HandleFunc("PUT", "/:restaurant_id/category/:category_id/translations/:locale", h.setCategoryTranslation)
+	restaurantApi.HandleFunc("GET", "/:restaurant_id/category/:category_id/schedule", h.getCategorySchedules)
+	restaurantApi.HandleFunc("PUT", "/:restaurant_id/category/:category_id/schedule", h.setCategorySchedules)
 	restaurantApi.HandleFunc("POST", "/:restaurant_id/menu", h.createItem)
-	restaurantApi.HandleFunc("GET", "/:restaurant_id/menu", h.getMenuForFp)
+	restaurantApi.HandleFunc("GET", "/:restaurant_id/menu/items", h.getMenuForFp)
+	restaurantApi.HandleFunc("POST", "/:restaurant_id/menu/import", h.importMenu)
+	restaurantApi.HandleFunc("GET", "/:restaurant_id/menu/export", h.exportMenu)
+	restaurantApi.HandleFunc("GET", "/:restaurant_id/menu/print", h.getPrintableMenu)
+	restaurantApi.HandleFunc("GET", "/:restaurant_id/menu/draft", h.getMenuDraft)
+	restaurantApi.HandleFunc("PUT", "/:restaurant_id/menu/draft", h.saveMenuDraft)
+	restaurantApi.HandleFunc("DELETE", "/:restaurant_id/menu/draft", h.discardMenuDraft)
+	restaurantApi.HandleFunc("GET", "/:restaurant_id/menu/draft/preview", h.previewMenuDraft)
+	restaurantApi.HandleFunc("POST", "/:restaurant_id/menu/draft/publish", h.publishMenuDraft)
+	restaurantApi.HandleFunc("GET", "/:restaurant_id/menu/versions", h.getMenuVersions)
+	restaurantApi.HandleFunc("GET", "/:restaurant_id/menu/versions/:version", h.getMenuVersion)
+	restaurantApi.HandleFunc("POST", "/:restaurant_id/menu/versions/:version/rollback", h.rollbackMenu)
+	restaurantApi.HandleFunc("POST", "/:restaurant_id/menu/copy", h.copyMenu)
+	restaurantApi.HandleFunc("GET", "/:restaurant_id/menu/link", h.getMenuLink)
+	restaurantApi.HandleFunc("PUT", "/:restaurant_id/menu/link", h.linkMenu)
+	restaurantApi.HandleFunc("DELETE", "/:restaurant_id/menu/link", h.unlinkMenu)
+	restaurantApi.HandleFunc("PUT", "/:restaurant_id/menu/link/overrides/:item_id", h.setMenuOverride)
+	restaurantApi.HandleFunc("PUT", "/:restaurant_id/menu/:item_id", h.updateItem)
+	restaurantApi.HandleFunc("DELETE", "/:restaurant_id/menu/:item_id", h.archiveItem)
+	restaurantApi.HandleFunc("PUT", "/:restaurant_id/menu/:item_id/dietary", h.setItemDietary)
+	restaurantApi.HandleFunc("GET", "/:restaurant_id/menu/:item_id/prices", h.getPriceHistory)
+	restaurantApi.HandleFunc("POST", "/:restaurant_id/menu/:item_id/prices", h.schedulePriceChange)
+	restaurantApi.HandleFunc("DELETE", "/:restaurant_id/menu/:item_id/prices/:change_id", h.cancelPriceChange)
+	restaurantApi.HandleFunc("PUT", "/:restaurant_id/menu/:item_id/translations/:locale", h.setItemTranslation)
+	restaurantApi.HandleFunc("GET", "/:restaurant_id/menu/:item_id/pictures", h.getItemPictures)
+	restaurantApi.HandleFunc("PUT", "/:restaurant_id/menu/:item_id/pictures/:slot", h.updateItemPicture)
+	restaurantApi.HandleFunc("DELETE", "/:restaurant_id/menu/:item_id/pictures/:slot", h.deleteItemPicture)
+	restaurantApi.HandleFunc("GET", "/:restaurant_id/menu/:item_id/options", h.getItemOptions)
+	restaurantApi.HandleFunc("POST", "/:restaurant_id/menu/:item_id/variants", h.createVariant)
+	restaurantApi.HandleFunc("DELETE", "/:restaurant_id/menu/:item_id/variants/:variant_id", h.archiveVariant)
+	restaurantApi.HandleFunc("POST", "/:restaurant_id/menu/:item_id/modifiers", h.createModifierGroup)
+	restaurantApi.HandleFunc("DELETE", "/:restaurant_id/menu/:item_id/modifiers/:group_id", h.archiveModifierGroup)
+	restaurantApi.HandleFunc("GET", "/:restaurant_id/menu/:item_id/combo", h.getComboSlots)
+	restaurantApi.HandleFunc("PUT", "/:restaurant_id/menu/:item_id/combo", h.setComboSlots)
+	restaurantApi.HandleFunc("GET", "/:restaurant_id/menu/:item_id/schedule", h.getItemSchedules)
+	restaurantApi.HandleFunc("PUT", "/:restaurant_id/menu/:item_id/schedule", h.setItemSchedules)
+	restaurantApi.HandleFunc("GET", "/:restaurant_id/menu/:item_id/recipe", h.getRecipe)
+	restaurantApi.HandleFunc("PUT", "/:restaurant_id/menu/:item_id/recipe", h.setRecipe)
+	restaurantApi.HandleFunc("GET", "/:restaurant_id/pricing", h.getPricingRules)
+	restaurantApi.HandleFunc("POST", "/:restaurant_id/pricing", h.createPricingRule)
+	restaurantApi.HandleFunc("DELETE", "/:restaurant_id/pricing/:rule_id", h.deletePricingRule)
+	restaurantApi.HandleFunc("POST", "/:restaurant_id/ingredients", h.createIngredient)
+	restaurantApi.HandleFunc("GET", "/:restaurant_id/ingredients", h.getIngredients)
+	restaurantApi.HandleFunc("GET", "/:restaurant_id/ingredients/alerts", h.getLowStockAlerts)
+	restaurantApi.HandleFunc("PUT", "/:restaurant_id/ingredients/:ingredient_id", h.updateIngredientStock)
 	restaurantApi.HandleFunc("GET", "/:restaurant_id/orders", h.getOrders)
+	restaurantApi.HandleFunc("GET", "/:restaurant_id/orders/search", h.searchOrders)
+	restaurantApi.HandleFunc("GET", "/:restaurant_id/orders/export", h.exportOrders)
+	restaurantApi.HandleFunc("PUT", "/:restaurant_id/orders/:order_id/status", h.updateOrderStatus)
+	restaurantApi.HandleFunc("GET", "/:restaurant_id/orders/:order_id/ticket", h.getKitchenTicket)
+	restaurantApi.HandleFunc("GET", "/:restaurant_id/reports/revenue", h.getRevenueReport)
+	restaurantApi.HandleFunc("GET", "/:restaurant_id/reports/orders", h.getStatusReport)
+	restaurantApi.HandleFunc("GET", "/:restaurant_id/reports/items", h.getTopItems)
+	restaurantApi.HandleFunc("GET", "/:restaurant_id/reports/categories", h.getTopCategories)
+	restaurantApi.HandleFunc("GET", "/:restaurant_id/reports/heatmap", h.getHeatmap)
+	restaurantApi.HandleFunc("POST", "/:restaurant_id/shifts", h.openShift)
+	restaurantApi.HandleFunc("GET", "/:restaurant_id/shifts/current", h.getCurrentShift)
+	restaurantApi.HandleFunc("GET", "/:restaurant_id/shifts/:shift_id", h.getZReport)
+	restaurantApi.HandleFunc("POST", "/:restaurant_id/shifts/:shift_id/entries", h.recordShiftEntry)
+	restaurantApi.HandleFunc("POST", "/:restaurant_id/shifts/:shift_id/close", h.closeShift)
+	restaurantApi.HandleFunc("POST", "/:restaurant_id/shifts/:shift_id/signoff", h.signOffShift)
+	restaurantApi.HandleFunc("GET", "/:restaurant_id/reviews", h.getReviewsForFp)
+	restaurantApi.HandleFunc("PUT", "/:restaurant_id/reviews/:review_id/reply", h.replyToReview)
+	restaurantApi.HandleFunc("POST", "/:restaurant_id/reviews/:review_id/flag", h.flagReview)
+	restaurantApi.HandleFunc("DELETE", "/:restaurant_id/reviews/:review_id/hidden", h.unhideReview)
 
 	fs := http.FileServer(&spaFileSystem{http.Dir("web/static")})
 	//if inLocalhost {
@@ -55,9 +149,13 @@ func New(s *service.Service) http.Handler {
 
 	r := way.NewRouter()
 	r.Handle("*", "/api/fp...", http.StripPrefix("/api/fp", h.withFpAuth(foodProviderApi)))
+	r.Handle("GET", "/api/restaurants", h.withAuth(http.HandlerFunc(h.getRestaurantListing)))
+	r.Handle("GET", "/api/restaurants/nearby", h.withAuth(http.HandlerFunc(h.getNearbyRestaurants)))
+	r.Handle("GET", "/api/restaurants/:restaurant_id", h.withAuth(http.HandlerFunc(h.getRestaurant)))
+	r.Handle("GET", "/api/restaurants/:restaurant_id/menu", h.withAuth(http.HandlerFunc(h.getMenu)))
 	r.Handle("*", "/api/restaurants...", http.StripPrefix("/api/restaurants", h.withFpAuth(restaurantApi)))
 	r.Handle("*", "/api...", http.StripPrefix("/api", h.withAuth(userApi)))
 	r.Handle("GET", "/...", fs)
 
-	return r
+	return withLocale(r)
 }
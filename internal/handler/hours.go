@@ -0,0 +1,130 @@
+package handler
+
+import (
+	"encoding/json"
+	"net/http"
+	"time"
+
+	"github.com/matryer/way"
+
+	"ovto/internal/service"
+)
+
+type restaurantHoursInput struct {
+	TimeZone string                 `json:"timezone"`
+	Weekly   []service.OpeningHours `json:"weekly"`
+}
+
+type pauseOrderingInput struct {
+	Minutes int64 `json:"minutes"`
+}
+
+func respondHoursErr(w http.ResponseWriter, err error) {
+	if err == service.ErrUnauthenticated {
+		http.Error(w, err.Error(), http.StatusUnauthorized)
+		return
+	}
+
+	if err == service.ErrRestaurantNotFound || err == service.ErrHoursExceptionNotFound {
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return
+	}
+
+	if err == service.ErrInvalidHours || err == service.ErrInvalidTimeZone || err == service.ErrInvalidPause {
+		http.Error(w, err.Error(), http.StatusUnprocessableEntity)
+		return
+	}
+
+	respondErr(w, err)
+}
+
+func (h *handler) getRestaurantHours(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	rID := way.Param(ctx, "restaurant_id")
+	hh, err := h.GetRestaurantHours(ctx, rID)
+	if err != nil {
+		respondHoursErr(w, err)
+		return
+	}
+
+	respond(w, hh, http.StatusOK)
+}
+
+func (h *handler) setRestaurantHours(w http.ResponseWriter, r *http.Request) {
+	var in restaurantHoursInput
+	defer r.Body.Close()
+	if err := json.NewDecoder(r.Body).Decode(&in); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	ctx := r.Context()
+	rID := way.Param(ctx, "restaurant_id")
+	if err := h.SetRestaurantHours(ctx, rID, in.TimeZone, in.Weekly); err != nil {
+		respondHoursErr(w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (h *handler) setHoursException(w http.ResponseWriter, r *http.Request) {
+	var in service.HoursException
+	defer r.Body.Close()
+	if err := json.NewDecoder(r.Body).Decode(&in); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	ctx := r.Context()
+	rID := way.Param(ctx, "restaurant_id")
+	in.Date = way.Param(ctx, "date")
+	if err := h.SetHoursException(ctx, rID, in); err != nil {
+		respondHoursErr(w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (h *handler) deleteHoursException(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	rID := way.Param(ctx, "restaurant_id")
+	if err := h.DeleteHoursException(ctx, rID, way.Param(ctx, "date")); err != nil {
+		respondHoursErr(w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// pauseOrdering stops taking orders for the given minutes, or until resumed
+// when they are left out.
+func (h *handler) pauseOrdering(w http.ResponseWriter, r *http.Request) {
+	var in pauseOrderingInput
+	defer r.Body.Close()
+	if err := json.NewDecoder(r.Body).Decode(&in); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	ctx := r.Context()
+	rID := way.Param(ctx, "restaurant_id")
+	if err := h.PauseOrdering(ctx, rID, time.Duration(in.Minutes)*time.Minute); err != nil {
+		respondHoursErr(w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (h *handler) resumeOrdering(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	rID := way.Param(ctx, "restaurant_id")
+	if err := h.ResumeOrdering(ctx, rID); err != nil {
+		respondHoursErr(w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
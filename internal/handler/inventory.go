@@ -0,0 +1,157 @@
+package handler
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+
+	"github.com/matryer/way"
+
+	"ovto/internal/service"
+)
+
+type ingredientInput struct {
+	Name     string  `json:"name"`
+	Unit     string  `json:"unit"`
+	Stock    float64 `json:"stock"`
+	LowStock float64 `json:"low_stock"`
+}
+
+func respondInventoryErr(w http.ResponseWriter, err error) {
+	if err == service.ErrUnauthenticated {
+		http.Error(w, err.Error(), http.StatusUnauthorized)
+		return
+	}
+
+	if err == service.ErrRestaurantNotFound || err == service.ErrInvalidRestaurantId ||
+		err == service.ErrIngredientNotFound || err == service.ErrItemNotFound {
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return
+	}
+
+	if err == service.ErrEmptyValue || err == service.ErrInvalidQuantity {
+		http.Error(w, err.Error(), http.StatusUnprocessableEntity)
+		return
+	}
+
+	if err == service.ErrIngredientExists {
+		http.Error(w, err.Error(), http.StatusConflict)
+		return
+	}
+
+	respondErr(w, err)
+}
+
+func (h *handler) createIngredient(w http.ResponseWriter, r *http.Request) {
+	var in ingredientInput
+	defer r.Body.Close()
+	if err := json.NewDecoder(r.Body).Decode(&in); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	ctx := r.Context()
+	rID := way.Param(ctx, "restaurant_id")
+
+	id, err := h.CreateIngredient(ctx, rID, in.Name, in.Unit, in.Stock, in.LowStock)
+	if err != nil {
+		respondInventoryErr(w, err)
+		return
+	}
+
+	respond(w, map[string]int64{"id": id}, http.StatusCreated)
+}
+
+func (h *handler) getIngredients(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	rID := way.Param(ctx, "restaurant_id")
+
+	ii, err := h.GetIngredients(ctx, rID, false)
+	if err != nil {
+		respondInventoryErr(w, err)
+		return
+	}
+
+	respond(w, ii, http.StatusOK)
+}
+
+func (h *handler) getLowStockAlerts(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	rID := way.Param(ctx, "restaurant_id")
+
+	ii, err := h.GetIngredients(ctx, rID, true)
+	if err != nil {
+		respondInventoryErr(w, err)
+		return
+	}
+
+	respond(w, ii, http.StatusOK)
+}
+
+func (h *handler) updateIngredientStock(w http.ResponseWriter, r *http.Request) {
+	var in ingredientInput
+	defer r.Body.Close()
+	if err := json.NewDecoder(r.Body).Decode(&in); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	ctx := r.Context()
+	rID := way.Param(ctx, "restaurant_id")
+	id, err := strconv.ParseInt(way.Param(ctx, "ingredient_id"), 10, 64)
+	if err != nil {
+		respondInventoryErr(w, service.ErrIngredientNotFound)
+		return
+	}
+
+	err = h.UpdateIngredientStock(ctx, rID, id, in.Stock, in.LowStock)
+	if err != nil {
+		respondInventoryErr(w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (h *handler) getRecipe(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	rID := way.Param(ctx, "restaurant_id")
+	iID, err := strconv.ParseInt(way.Param(ctx, "item_id"), 10, 64)
+	if err != nil {
+		respondInventoryErr(w, service.ErrItemNotFound)
+		return
+	}
+
+	rr, err := h.GetRecipe(ctx, rID, iID)
+	if err != nil {
+		respondInventoryErr(w, err)
+		return
+	}
+
+	respond(w, rr, http.StatusOK)
+}
+
+func (h *handler) setRecipe(w http.ResponseWriter, r *http.Request) {
+	var in []service.RecipeLine
+	defer r.Body.Close()
+	if err := json.NewDecoder(r.Body).Decode(&in); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	ctx := r.Context()
+	rID := way.Param(ctx, "restaurant_id")
+	iID, err := strconv.ParseInt(way.Param(ctx, "item_id"), 10, 64)
+	if err != nil {
+		respondInventoryErr(w, service.ErrItemNotFound)
+		return
+	}
+
+	err = h.SetRecipe(ctx, rID, iID, in)
+	if err != nil {
+		respondInventoryErr(w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
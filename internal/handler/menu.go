@@ -4,6 +4,9 @@ import (
 	"encoding/json"
 	"fmt"
 	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
 
 	"github.com/matryer/way"
 
@@ -23,6 +26,35 @@ type ItemInput struct {
 	Availability bool    `json:"availability"`
 }
 
+type availabilityInput struct {
+	Availability bool `json:"availability"`
+}
+
+func respondMenuErr(w http.ResponseWriter, err error) {
+	if err == service.ErrUnauthenticated {
+		http.Error(w, err.Error(), http.StatusUnauthorized)
+		return
+	}
+
+	if err == service.ErrRestaurantNotFound || err == service.ErrCategoryNotFound || err == service.ErrItemNotFound {
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return
+	}
+
+	if err == service.ErrEmptyValue || err == service.ErrInvalidPrice || err == service.ErrInvalidPositions ||
+		err == service.ErrUnknownTag || err == service.ErrInvalidSpiceLevel {
+		http.Error(w, err.Error(), http.StatusUnprocessableEntity)
+		return
+	}
+
+	if err == service.ErrTitleTaken || err == service.ErrItemAlreadyExists || err == service.ErrMenuLinked {
+		http.Error(w, err.Error(), http.StatusConflict)
+		return
+	}
+
+	respondErr(w, err)
+}
+
 func (h *handler) createCategory(w http.ResponseWriter, r *http.Request) {
 	var in CategoryInput
 	defer r.Body.Close()
@@ -40,7 +72,7 @@ func (h *handler) createCategory(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	if err == service.ErrTitleTaken {
+	if err == service.ErrTitleTaken || err == service.ErrMenuLinked {
 		http.Error(w, err.Error(), http.StatusConflict)
 		return
 	}
@@ -109,7 +141,7 @@ func (h *handler) createItem(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	if err == service.ErrItemAlreadyExists {
+	if err == service.ErrItemAlreadyExists || err == service.ErrMenuLinked {
 		http.Error(w, err.Error(), http.StatusConflict)
 		return
 	}
@@ -144,3 +176,326 @@ func (h *handler) getMenuForFp(w http.ResponseWriter, r *http.Request) {
 
 	respond(w, restaurants, http.StatusOK)
 }
+
+// queryList reads the values of a query string key given as comma separated
+// lists, repeated or not.
+func queryList(q url.Values, key string) []string {
+	ss := make([]string, 0)
+	for _, v := range q[key] {
+		for _, s := range strings.Split(v, ",") {
+			if s = strings.TrimSpace(s); s != "" {
+				ss = append(ss, s)
+			}
+		}
+	}
+	return ss
+}
+
+// menuFilter reads the dietary filters of the public menu, given as comma
+// separated lists, e.g. ?tags=halal,vegetarian&exclude_allergens=nuts.
+func menuFilter(r *http.Request) service.MenuFilter {
+	q := r.URL.Query()
+	f := service.MenuFilter{Tags: queryList(q, "tags"), ExcludeAllergens: queryList(q, "exclude_allergens")}
+	if spice, err := strconv.Atoi(q.Get("max_spice")); err == nil {
+		f.MaxSpice = &spice
+	}
+
+	return f
+}
+
+func (h *handler) getMenu(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	rID := way.Param(ctx, "restaurant_id")
+
+	m, err := h.GetMenu(ctx, rID, menuFilter(r))
+	if err == service.ErrRestaurantNotFound {
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return
+	}
+
+	if err == service.ErrUnknownTag || err == service.ErrInvalidSpiceLevel {
+		http.Error(w, err.Error(), http.StatusUnprocessableEntity)
+		return
+	}
+
+	if err != nil {
+		respondErr(w, err)
+		return
+	}
+
+	respond(w, m, http.StatusOK)
+}
+
+func (h *handler) updateCategory(w http.ResponseWriter, r *http.Request) {
+	var in CategoryInput
+	defer r.Body.Close()
+	if err := json.NewDecoder(r.Body).Decode(&in); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	ctx := r.Context()
+	rID := way.Param(ctx, "restaurant_id")
+	cID, err := strconv.ParseInt(way.Param(ctx, "category_id"), 10, 64)
+	if err != nil {
+		respondMenuErr(w, service.ErrCategoryNotFound)
+		return
+	}
+
+	if err = h.UpdateCategory(ctx, rID, cID, in.Label, in.Availability); err != nil {
+		respondMenuErr(w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (h *handler) setCategoryItemsAvailability(w http.ResponseWriter, r *http.Request) {
+	var in availabilityInput
+	defer r.Body.Close()
+	if err := json.NewDecoder(r.Body).Decode(&in); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	ctx := r.Context()
+	rID := way.Param(ctx, "restaurant_id")
+	cID, err := strconv.ParseInt(way.Param(ctx, "category_id"), 10, 64)
+	if err != nil {
+		respondMenuErr(w, service.ErrCategoryNotFound)
+		return
+	}
+
+	if err = h.SetCategoryItemsAvailability(ctx, rID, cID, in.Availability); err != nil {
+		respondMenuErr(w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (h *handler) archiveCategory(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	rID := way.Param(ctx, "restaurant_id")
+	cID, err := strconv.ParseInt(way.Param(ctx, "category_id"), 10, 64)
+	if err != nil {
+		respondMenuErr(w, service.ErrCategoryNotFound)
+		return
+	}
+
+	if err = h.ArchiveCategory(ctx, rID, cID); err != nil {
+		respondMenuErr(w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (h *handler) updateItem(w http.ResponseWriter, r *http.Request) {
+	var in ItemInput
+	defer r.Body.Close()
+	if err := json.NewDecoder(r.Body).Decode(&in); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	ctx := r.Context()
+	rID := way.Param(ctx, "restaurant_id")
+	iID, err := strconv.ParseInt(way.Param(ctx, "item_id"), 10, 64)
+	if err != nil {
+		respondMenuErr(w, service.ErrItemNotFound)
+		return
+	}
+
+	err = h.UpdateItem(ctx, rID, iID, in.Category, in.Name, in.Description, in.Price, in.Availability)
+	if err != nil {
+		respondMenuErr(w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (h *handler) archiveItem(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	rID := way.Param(ctx, "restaurant_id")
+	iID, err := strconv.ParseInt(way.Param(ctx, "item_id"), 10, 64)
+	if err != nil {
+		respondMenuErr(w, service.ErrItemNotFound)
+		return
+	}
+
+	if err = h.ArchiveItem(ctx, rID, iID); err != nil {
+		respondMenuErr(w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (h *handler) reorderCategories(w http.ResponseWriter, r *http.Request) {
+	var in []int64
+	defer r.Body.Close()
+	if err := json.NewDecoder(r.Body).Decode(&in); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	ctx := r.Context()
+	rID := way.Param(ctx, "restaurant_id")
+
+	if err := h.ReorderCategories(ctx, rID, in); err != nil {
+		respondMenuErr(w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (h *handler) reorderItems(w http.ResponseWriter, r *http.Request) {
+	var in []int64
+	defer r.Body.Close()
+	if err := json.NewDecoder(r.Body).Decode(&in); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	ctx := r.Context()
+	rID := way.Param(ctx, "restaurant_id")
+	cID, err := strconv.ParseInt(way.Param(ctx, "category_id"), 10, 64)
+	if err != nil {
+		respondMenuErr(w, service.ErrCategoryNotFound)
+		return
+	}
+
+	if err = h.ReorderItems(ctx, rID, cID, in); err != nil {
+		respondMenuErr(w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func respondItemPictureErr(w http.ResponseWriter, err error) {
+	if err == service.ErrUnauthenticated {
+		http.Error(w, err.Error(), http.StatusUnauthorized)
+		return
+	}
+
+	if err == service.ErrInvalidRestaurantId || err == service.ErrRestaurantNotFound ||
+		err == service.ErrItemNotFound || err == service.ErrItemPictureNotFound || err == service.ErrInvalidPictureSlot {
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return
+	}
+
+	if err == service.ErrUnsupportedImageFormat {
+		http.Error(w, err.Error(), http.StatusUnsupportedMediaType)
+		return
+	}
+
+	if err == service.ErrMenuLinked {
+		http.Error(w, err.Error(), http.StatusConflict)
+		return
+	}
+
+	respondErr(w, err)
+}
+
+func (h *handler) updateItemPicture(w http.ResponseWriter, r *http.Request) {
+	r.Body = http.MaxBytesReader(w, r.Body, service.MaxImageBytes)
+	defer r.Body.Close()
+
+	ctx := r.Context()
+	rID := way.Param(ctx, "restaurant_id")
+	iID, err := strconv.ParseInt(way.Param(ctx, "item_id"), 10, 64)
+	if err != nil {
+		respondItemPictureErr(w, service.ErrItemNotFound)
+		return
+	}
+
+	slot, err := strconv.Atoi(way.Param(ctx, "slot"))
+	if err != nil {
+		respondItemPictureErr(w, service.ErrInvalidPictureSlot)
+		return
+	}
+
+	pictureURL, err := h.UpdateItemPicture(ctx, r.Body, rID, iID, slot)
+	if err != nil {
+		respondItemPictureErr(w, err)
+		return
+	}
+
+	fmt.Fprint(w, pictureURL)
+}
+
+func (h *handler) deleteItemPicture(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	rID := way.Param(ctx, "restaurant_id")
+	iID, err := strconv.ParseInt(way.Param(ctx, "item_id"), 10, 64)
+	if err != nil {
+		respondItemPictureErr(w, service.ErrItemNotFound)
+		return
+	}
+
+	slot, err := strconv.Atoi(way.Param(ctx, "slot"))
+	if err != nil {
+		respondItemPictureErr(w, service.ErrInvalidPictureSlot)
+		return
+	}
+
+	if err = h.DeleteItemPicture(ctx, rID, iID, slot); err != nil {
+		respondItemPictureErr(w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (h *handler) getItemPictures(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	rID := way.Param(ctx, "restaurant_id")
+	iID, err := strconv.ParseInt(way.Param(ctx, "item_id"), 10, 64)
+	if err != nil {
+		respondItemPictureErr(w, service.ErrItemNotFound)
+		return
+	}
+
+	pp, err := h.GetItemPictures(ctx, rID, iID)
+	if err != nil {
+		respondItemPictureErr(w, err)
+		return
+	}
+
+	respond(w, pp, http.StatusOK)
+}
+
+func (h *handler) getDietaryVocabulary(w http.ResponseWriter, r *http.Request) {
+	respond(w, map[string][]string{
+		"tags":      service.DietaryTags,
+		"allergens": service.Allergens,
+	}, http.StatusOK)
+}
+
+func (h *handler) setItemDietary(w http.ResponseWriter, r *http.Request) {
+	var in service.Dietary
+	defer r.Body.Close()
+	if err := json.NewDecoder(r.Body).Decode(&in); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	ctx := r.Context()
+	rID := way.Param(ctx, "restaurant_id")
+	iID, err := strconv.ParseInt(way.Param(ctx, "item_id"), 10, 64)
+	if err != nil {
+		respondMenuErr(w, service.ErrItemNotFound)
+		return
+	}
+
+	if err = h.SetItemDietary(ctx, rID, iID, in); err != nil {
+		respondMenuErr(w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
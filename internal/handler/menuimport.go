@@ -0,0 +1,80 @@
+package handler
+
+import (
+	"encoding/json"
+	"mime"
+	"net/http"
+
+	"github.com/matryer/way"
+
+	"ovto/internal/service"
+)
+
+const maxImportBytes = 2 << 20 // 2MB
+
+func (h *handler) importMenu(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	rID := way.Param(ctx, "restaurant_id")
+	dryRun := r.URL.Query().Get("dry_run") == "true"
+
+	body := http.MaxBytesReader(w, r.Body, maxImportBytes)
+	defer body.Close()
+
+	var report service.ImportReport
+	var err error
+	if ct, _, _ := mime.ParseMediaType(r.Header.Get("Content-Type")); ct == "text/csv" {
+		report, err = h.ImportMenuCSV(ctx, rID, body, dryRun)
+	} else {
+		var in []service.MenuRow
+		if err := json.NewDecoder(body).Decode(&in); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		report, err = h.ImportMenu(ctx, rID, in, dryRun)
+	}
+
+	if err == service.ErrInvalidImport {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	if err != nil {
+		respondMenuErr(w, err)
+		return
+	}
+
+	if len(report.Problems) != 0 {
+		respond(w, report, http.StatusUnprocessableEntity)
+		return
+	}
+
+	if report.Applied {
+		respond(w, report, http.StatusCreated)
+		return
+	}
+
+	respond(w, report, http.StatusOK)
+}
+
+func (h *handler) exportMenu(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	rID := way.Param(ctx, "restaurant_id")
+
+	mm, err := h.ExportMenu(ctx, rID)
+	if err != nil {
+		respondMenuErr(w, err)
+		return
+	}
+
+	if wantsCSV(r) {
+		records := [][]string{service.MenuColumns}
+		for _, m := range mm {
+			records = append(records, m.Records())
+		}
+		respondCSV(w, "menu.csv", records)
+		return
+	}
+
+	respond(w, mm, http.StatusOK)
+}
@@ -0,0 +1,127 @@
+package handler
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+
+	"github.com/matryer/way"
+
+	"ovto/internal/service"
+)
+
+type menuSourceInput struct {
+	RestaurantId string `json:"restaurant_id"`
+}
+
+func respondMenuLinkErr(w http.ResponseWriter, err error) {
+	if err == service.ErrUnauthenticated {
+		http.Error(w, err.Error(), http.StatusUnauthorized)
+		return
+	}
+
+	if err == service.ErrRestaurantNotFound || err == service.ErrMenuLinkNotFound || err == service.ErrItemNotFound ||
+		err == service.ErrCategoryNotFound {
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return
+	}
+
+	if err == service.ErrInvalidPrice || err == service.ErrInvalidMenuDraft {
+		http.Error(w, err.Error(), http.StatusUnprocessableEntity)
+		return
+	}
+
+	if err == service.ErrInvalidMenuLink || err == service.ErrTitleTaken || err == service.ErrItemAlreadyExists ||
+		err == service.ErrMenuLinked {
+		http.Error(w, err.Error(), http.StatusConflict)
+		return
+	}
+
+	respondErr(w, err)
+}
+
+// copyMenu replaces the restaurant's menu with a copy of another's.
+func (h *handler) copyMenu(w http.ResponseWriter, r *http.Request) {
+	var in menuSourceInput
+	defer r.Body.Close()
+	if err := json.NewDecoder(r.Body).Decode(&in); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	ctx := r.Context()
+	rID := way.Param(ctx, "restaurant_id")
+	n, err := h.CopyMenu(ctx, rID, in.RestaurantId)
+	if err != nil {
+		respondMenuLinkErr(w, err)
+		return
+	}
+
+	respond(w, map[string]int{"version": n}, http.StatusCreated)
+}
+
+func (h *handler) getMenuLink(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	rID := way.Param(ctx, "restaurant_id")
+	l, err := h.GetMenuLink(ctx, rID)
+	if err != nil {
+		respondMenuLinkErr(w, err)
+		return
+	}
+
+	respond(w, l, http.StatusOK)
+}
+
+// linkMenu makes the restaurant a branch following the given master menu.
+func (h *handler) linkMenu(w http.ResponseWriter, r *http.Request) {
+	var in menuSourceInput
+	defer r.Body.Close()
+	if err := json.NewDecoder(r.Body).Decode(&in); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	ctx := r.Context()
+	rID := way.Param(ctx, "restaurant_id")
+	if err := h.LinkMenu(ctx, rID, in.RestaurantId); err != nil {
+		respondMenuLinkErr(w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (h *handler) unlinkMenu(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	rID := way.Param(ctx, "restaurant_id")
+	if err := h.UnlinkMenu(ctx, rID); err != nil {
+		respondMenuLinkErr(w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (h *handler) setMenuOverride(w http.ResponseWriter, r *http.Request) {
+	var in service.MenuOverride
+	defer r.Body.Close()
+	if err := json.NewDecoder(r.Body).Decode(&in); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	ctx := r.Context()
+	rID := way.Param(ctx, "restaurant_id")
+	iID, err := strconv.ParseInt(way.Param(ctx, "item_id"), 10, 64)
+	if err != nil {
+		respondMenuLinkErr(w, service.ErrItemNotFound)
+		return
+	}
+
+	if err = h.SetMenuOverride(ctx, rID, iID, in); err != nil {
+		respondMenuLinkErr(w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
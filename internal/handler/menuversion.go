@@ -0,0 +1,150 @@
+package handler
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+
+	"github.com/matryer/way"
+
+	"ovto/internal/service"
+)
+
+func respondMenuVersionErr(w http.ResponseWriter, err error) {
+	if err == service.ErrUnauthenticated {
+		http.Error(w, err.Error(), http.StatusUnauthorized)
+		return
+	}
+
+	if err == service.ErrRestaurantNotFound || err == service.ErrMenuDraftNotFound ||
+		err == service.ErrMenuVersionNotFound || err == service.ErrCategoryNotFound || err == service.ErrItemNotFound {
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return
+	}
+
+	if err == service.ErrInvalidMenuDraft {
+		http.Error(w, err.Error(), http.StatusUnprocessableEntity)
+		return
+	}
+
+	if err == service.ErrTitleTaken || err == service.ErrItemAlreadyExists || err == service.ErrMenuDraftOutdated ||
+		err == service.ErrMenuLinked {
+		http.Error(w, err.Error(), http.StatusConflict)
+		return
+	}
+
+	respondErr(w, err)
+}
+
+func (h *handler) getMenuDraft(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	rID := way.Param(ctx, "restaurant_id")
+	d, err := h.GetMenuDraft(ctx, rID)
+	if err != nil {
+		respondMenuVersionErr(w, err)
+		return
+	}
+
+	respond(w, d, http.StatusOK)
+}
+
+func (h *handler) saveMenuDraft(w http.ResponseWriter, r *http.Request) {
+	var in service.DraftMenu
+	defer r.Body.Close()
+	if err := json.NewDecoder(r.Body).Decode(&in); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	ctx := r.Context()
+	rID := way.Param(ctx, "restaurant_id")
+	if err := h.SaveMenuDraft(ctx, rID, in); err != nil {
+		respondMenuVersionErr(w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (h *handler) discardMenuDraft(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	rID := way.Param(ctx, "restaurant_id")
+	if err := h.DiscardMenuDraft(ctx, rID); err != nil {
+		respondMenuVersionErr(w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (h *handler) previewMenuDraft(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	rID := way.Param(ctx, "restaurant_id")
+	b, err := h.PreviewMenuDraft(ctx, rID)
+	if err != nil {
+		respondMenuVersionErr(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	_, _ = w.Write(b)
+}
+
+func (h *handler) publishMenuDraft(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	rID := way.Param(ctx, "restaurant_id")
+	n, err := h.PublishMenuDraft(ctx, rID)
+	if err != nil {
+		respondMenuVersionErr(w, err)
+		return
+	}
+
+	respond(w, map[string]int{"version": n}, http.StatusCreated)
+}
+
+func (h *handler) getMenuVersions(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	rID := way.Param(ctx, "restaurant_id")
+	vv, err := h.GetMenuVersions(ctx, rID)
+	if err != nil {
+		respondMenuVersionErr(w, err)
+		return
+	}
+
+	respond(w, vv, http.StatusOK)
+}
+
+func (h *handler) getMenuVersion(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	rID := way.Param(ctx, "restaurant_id")
+	n, err := strconv.Atoi(way.Param(ctx, "version"))
+	if err != nil {
+		respondMenuVersionErr(w, service.ErrMenuVersionNotFound)
+		return
+	}
+
+	v, err := h.GetMenuVersion(ctx, rID, n)
+	if err != nil {
+		respondMenuVersionErr(w, err)
+		return
+	}
+
+	respond(w, v, http.StatusOK)
+}
+
+func (h *handler) rollbackMenu(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	rID := way.Param(ctx, "restaurant_id")
+	n, err := strconv.Atoi(way.Param(ctx, "version"))
+	if err != nil {
+		respondMenuVersionErr(w, service.ErrMenuVersionNotFound)
+		return
+	}
+
+	if n, err = h.RollbackMenu(ctx, rID, n); err != nil {
+		respondMenuVersionErr(w, err)
+		return
+	}
+
+	respond(w, map[string]int{"version": n}, http.StatusCreated)
+}
@@ -0,0 +1,156 @@
+package handler
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+
+	"github.com/matryer/way"
+
+	"ovto/internal/service"
+)
+
+type variantInput struct {
+	Name  string  `json:"name"`
+	Price float64 `json:"price"`
+}
+
+func respondModifierErr(w http.ResponseWriter, err error) {
+	if err == service.ErrUnauthenticated {
+		http.Error(w, err.Error(), http.StatusUnauthorized)
+		return
+	}
+
+	if err == service.ErrRestaurantNotFound || err == service.ErrItemNotFound ||
+		err == service.ErrVariantNotFound || err == service.ErrModifierGroupNotFound {
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return
+	}
+
+	if err == service.ErrEmptyValue || err == service.ErrInvalidPrice || err == service.ErrInvalidModifierGroup ||
+		err == service.ErrUnknownTag {
+		http.Error(w, err.Error(), http.StatusUnprocessableEntity)
+		return
+	}
+
+	if err == service.ErrItemAlreadyExists || err == service.ErrTitleTaken {
+		http.Error(w, err.Error(), http.StatusConflict)
+		return
+	}
+
+	respondErr(w, err)
+}
+
+func (h *handler) getItemOptions(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	rID := way.Param(ctx, "restaurant_id")
+	iID, err := strconv.ParseInt(way.Param(ctx, "item_id"), 10, 64)
+	if err != nil {
+		respondModifierErr(w, service.ErrItemNotFound)
+		return
+	}
+
+	o, err := h.GetItemOptions(ctx, rID, iID)
+	if err != nil {
+		respondModifierErr(w, err)
+		return
+	}
+
+	respond(w, o, http.StatusOK)
+}
+
+func (h *handler) createVariant(w http.ResponseWriter, r *http.Request) {
+	var in variantInput
+	defer r.Body.Close()
+	if err := json.NewDecoder(r.Body).Decode(&in); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	ctx := r.Context()
+	rID := way.Param(ctx, "restaurant_id")
+	iID, err := strconv.ParseInt(way.Param(ctx, "item_id"), 10, 64)
+	if err != nil {
+		respondModifierErr(w, service.ErrItemNotFound)
+		return
+	}
+
+	id, err := h.CreateVariant(ctx, rID, iID, in.Name, in.Price)
+	if err != nil {
+		respondModifierErr(w, err)
+		return
+	}
+
+	respond(w, map[string]int64{"id": id}, http.StatusCreated)
+}
+
+func (h *handler) archiveVariant(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	rID := way.Param(ctx, "restaurant_id")
+	iID, err := strconv.ParseInt(way.Param(ctx, "item_id"), 10, 64)
+	if err != nil {
+		respondModifierErr(w, service.ErrItemNotFound)
+		return
+	}
+
+	vID, err := strconv.ParseInt(way.Param(ctx, "variant_id"), 10, 64)
+	if err != nil {
+		respondModifierErr(w, service.ErrVariantNotFound)
+		return
+	}
+
+	if err = h.ArchiveVariant(ctx, rID, iID, vID); err != nil {
+		respondModifierErr(w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (h *handler) createModifierGroup(w http.ResponseWriter, r *http.Request) {
+	var in service.ModifierGroup
+	defer r.Body.Close()
+	if err := json.NewDecoder(r.Body).Decode(&in); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	ctx := r.Context()
+	rID := way.Param(ctx, "restaurant_id")
+	iID, err := strconv.ParseInt(way.Param(ctx, "item_id"), 10, 64)
+	if err != nil {
+		respondModifierErr(w, service.ErrItemNotFound)
+		return
+	}
+
+	id, err := h.CreateModifierGroup(ctx, rID, iID, in)
+	if err != nil {
+		respondModifierErr(w, err)
+		return
+	}
+
+	respond(w, map[string]int64{"id": id}, http.StatusCreated)
+}
+
+func (h *handler) archiveModifierGroup(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	rID := way.Param(ctx, "restaurant_id")
+	iID, err := strconv.ParseInt(way.Param(ctx, "item_id"), 10, 64)
+	if err != nil {
+		respondModifierErr(w, service.ErrItemNotFound)
+		return
+	}
+
+	gID, err := strconv.ParseInt(way.Param(ctx, "group_id"), 10, 64)
+	if err != nil {
+		respondModifierErr(w, service.ErrModifierGroupNotFound)
+		return
+	}
+
+	if err = h.ArchiveModifierGroup(ctx, rID, iID, gID); err != nil {
+		respondModifierErr(w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
@@ -6,12 +6,16 @@ import (
 	"mime"
 	"net/http"
 	"ovto/internal/service"
+	"strconv"
 )
 
 type OrderInput struct {
 	CId    int64
 	Status int64
 	Items  map[string]int64
+	Lines  []service.OrderLineInput
+	Table  int64
+	Sig    string
 }
 
 func (h *handler) ordersStream(w http.ResponseWriter, r *http.Request) {
@@ -58,7 +62,7 @@ func (h *handler) createOrder(w http.ResponseWriter, r *http.Request) {
 	ctx := r.Context()
 	rID := way.Param(ctx, "restaurant_id")
 
-	err := h.CreateOrder(ctx, rID, in.CId, in.Status, in.Items)
+	err := h.CreateOrder(ctx, rID, in.CId, in.Status, in.Items, in.Lines)
 	if err == service.ErrUnauthenticated {
 		http.Error(w, err.Error(), http.StatusUnauthorized)
 		return
@@ -69,6 +73,12 @@ func (h *handler) createOrder(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	if err == service.ErrEmptyOrder || err == service.ErrItemNotFound || err == service.ErrInvalidQuantity ||
+		err == service.ErrInvalidVariant || err == service.ErrInvalidModifiers || err == service.ErrInvalidComboChoice {
+		http.Error(w, err.Error(), http.StatusUnprocessableEntity)
+		return
+	}
+
 	if err != nil {
 		respondErr(w, err)
 		return
@@ -88,7 +98,7 @@ func (h *handler) createUserOrder(w http.ResponseWriter, r *http.Request) {
 	ctx := r.Context()
 	rID := way.Param(ctx, "restaurant_id")
 
-	err := h.CreateUserOrder(ctx, rID, in.Status, in.Items)
+	err := h.CreateUserOrder(ctx, rID, in.Status, in.Items, in.Lines, service.TableLink{Table: in.Table, Sig: in.Sig})
 	if err == service.ErrUnauthenticated {
 		http.Error(w, err.Error(), http.StatusUnauthorized)
 		return
@@ -99,6 +109,18 @@ func (h *handler) createUserOrder(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	if err == service.ErrEmptyOrder || err == service.ErrItemNotFound || err == service.ErrInvalidQuantity ||
+		err == service.ErrInvalidVariant || err == service.ErrInvalidModifiers || err == service.ErrInvalidComboChoice ||
+		err == service.ErrTableNotFound || err == service.ErrInvalidTableLink {
+		http.Error(w, err.Error(), http.StatusUnprocessableEntity)
+		return
+	}
+
+	if err == service.ErrRestaurantClosed {
+		http.Error(w, err.Error(), http.StatusConflict)
+		return
+	}
+
 	if err != nil {
 		respondErr(w, err)
 		return
@@ -133,4 +155,77 @@ func (h *handler) getOrders(w http.ResponseWriter, r *http.Request) {
 	}
 
 	respond(w, o, http.StatusOK)
-}
\ No newline at end of file
+}
+
+func (h *handler) updateOrderStatus(w http.ResponseWriter, r *http.Request) {
+	var in OrderInput
+	defer r.Body.Close()
+	if err := json.NewDecoder(r.Body).Decode(&in); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	ctx := r.Context()
+	rID := way.Param(ctx, "restaurant_id")
+	oID, err := strconv.ParseInt(way.Param(ctx, "order_id"), 10, 64)
+	if err != nil {
+		http.Error(w, service.ErrOrderNotFound.Error(), http.StatusNotFound)
+		return
+	}
+
+	err = h.UpdateOrderStatus(ctx, rID, oID, in.Status)
+	if err == service.ErrUnauthenticated {
+		http.Error(w, err.Error(), http.StatusUnauthorized)
+		return
+	}
+
+	if err == service.ErrRestaurantNotFound || err == service.ErrInvalidRestaurantId || err == service.ErrOrderNotFound {
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return
+	}
+
+	if err == service.ErrInvalidOrderStatus {
+		http.Error(w, err.Error(), http.StatusUnprocessableEntity)
+		return
+	}
+
+	if err == service.ErrOutOfStock {
+		http.Error(w, err.Error(), http.StatusConflict)
+		return
+	}
+
+	if err != nil {
+		respondErr(w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (h *handler) getKitchenTicket(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	rID := way.Param(ctx, "restaurant_id")
+	oID, err := strconv.ParseInt(way.Param(ctx, "order_id"), 10, 64)
+	if err != nil {
+		http.Error(w, service.ErrOrderNotFound.Error(), http.StatusNotFound)
+		return
+	}
+
+	t, err := h.GetKitchenTicket(ctx, rID, oID)
+	if err == service.ErrUnauthenticated {
+		http.Error(w, err.Error(), http.StatusUnauthorized)
+		return
+	}
+
+	if err == service.ErrRestaurantNotFound || err == service.ErrInvalidRestaurantId || err == service.ErrOrderNotFound {
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return
+	}
+
+	if err != nil {
+		respondErr(w, err)
+		return
+	}
+
+	respond(w, t, http.StatusOK)
+}
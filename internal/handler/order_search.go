@@ -0,0 +1,211 @@
+package handler
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/matryer/way"
+
+	"ovto/internal/service"
+)
+
+var orderExportHeader = []string{"order_id", "created_at", "status", "customer_id", "customer", "phone",
+	"total", "item_id", "item", "quantity", "price"}
+
+// orderSearch reads the order search filters from the query string. Dates
+// use the report layout and to is inclusive.
+func orderSearch(r *http.Request) (service.OrderSearch, error) {
+	var search service.OrderSearch
+	var err error
+	q := r.URL.Query()
+
+	if s := q.Get("id"); s != "" {
+		if search.Id, err = strconv.ParseInt(s, 10, 64); err != nil {
+			return search, service.ErrOrderNotFound
+		}
+	}
+
+	search.Phone = q.Get("phone")
+	search.Name = q.Get("name")
+
+	if s := q.Get("from"); s != "" {
+		if search.From, err = time.Parse(reportDateLayout, s); err != nil {
+			return search, service.ErrInvalidDateRange
+		}
+	}
+
+	if s := q.Get("to"); s != "" {
+		if search.To, err = time.Parse(reportDateLayout, s); err != nil {
+			return search, service.ErrInvalidDateRange
+		}
+		search.To = search.To.Add(24 * time.Hour)
+	}
+
+	if s := q.Get("min"); s != "" {
+		if search.MinAmount, err = strconv.ParseFloat(s, 64); err != nil {
+			return search, service.ErrInvalidAmount
+		}
+	}
+
+	if s := q.Get("max"); s != "" {
+		if search.MaxAmount, err = strconv.ParseFloat(s, 64); err != nil {
+			return search, service.ErrInvalidAmount
+		}
+	}
+
+	if s := q.Get("status"); s != "" {
+		if search.Status, err = strconv.ParseInt(s, 10, 64); err != nil {
+			return search, service.ErrInvalidOrderStatus
+		}
+	}
+
+	search.Last, _ = strconv.ParseInt(q.Get("last"), 10, 64)
+	search.First, _ = strconv.Atoi(q.Get("first"))
+
+	return search, nil
+}
+
+func respondOrderSearchErr(w http.ResponseWriter, err error) {
+	if err == service.ErrUnauthenticated {
+		http.Error(w, err.Error(), http.StatusUnauthorized)
+		return
+	}
+
+	if err == service.ErrRestaurantNotFound || err == service.ErrInvalidRestaurantId || err == service.ErrOrderNotFound {
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return
+	}
+
+	if err == service.ErrInvalidDateRange || err == service.ErrInvalidAmount || err == service.ErrInvalidOrderStatus {
+		http.Error(w, err.Error(), http.StatusUnprocessableEntity)
+		return
+	}
+
+	respondErr(w, err)
+}
+
+func (h *handler) searchOrders(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	rID := way.Param(ctx, "restaurant_id")
+	search, err := orderSearch(r)
+	if err != nil {
+		respondOrderSearchErr(w, err)
+		return
+	}
+
+	oo, err := h.SearchOrders(ctx, rID, search)
+	if err != nil {
+		respondOrderSearchErr(w, err)
+		return
+	}
+
+	respond(w, oo, http.StatusOK)
+}
+
+// exportOrders streams the matching orders as CSV, one row per order item,
+// or as newline delimited JSON, one order per line.
+func (h *handler) exportOrders(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	rID := way.Param(ctx, "restaurant_id")
+	search, err := orderSearch(r)
+	if err != nil {
+		respondOrderSearchErr(w, err)
+		return
+	}
+
+	f, _ := w.(http.Flusher)
+	flush := func() {
+		if f != nil {
+			f.Flush()
+		}
+	}
+
+	// Headers are only written with the first order, so errors before it
+	// can still be reported with a proper status code.
+	started := false
+	var write func(service.OrderSummary) error
+	if wantsCSV(r) {
+		cw := csv.NewWriter(w)
+		write = func(o service.OrderSummary) error {
+			if !started {
+				w.Header().Set("Content-Type", "text/csv; charset=utf-8")
+				w.Header().Set("Content-Disposition", `attachment; filename="orders.csv"`)
+				started = true
+				if err := cw.Write(orderExportHeader); err != nil {
+					return err
+				}
+			}
+
+			order := []string{
+				strconv.FormatInt(o.Id, 10),
+				o.CreatedAt.Format(time.RFC3339),
+				o.Status,
+				strconv.FormatInt(o.CustomerId, 10),
+				o.Customer,
+				o.Phone,
+				strconv.FormatFloat(o.Total, 'f', 2, 64),
+			}
+			if len(o.Items) == 0 {
+				if err := cw.Write(append(order, "", "", "", "")); err != nil {
+					return err
+				}
+			}
+			for _, i := range o.Items {
+				err := cw.Write(append(order[:len(order):len(order)],
+					strconv.FormatInt(i.ItemId, 10),
+					i.Name,
+					strconv.FormatInt(i.Quantity, 10),
+					strconv.FormatFloat(i.Price, 'f', 2, 64),
+				))
+				if err != nil {
+					return err
+				}
+			}
+
+			cw.Flush()
+			flush()
+			return cw.Error()
+		}
+	} else {
+		enc := json.NewEncoder(w)
+		write = func(o service.OrderSummary) error {
+			if !started {
+				w.Header().Set("Content-Type", "application/x-ndjson; charset=utf-8")
+				w.Header().Set("Content-Disposition", `attachment; filename="orders.ndjson"`)
+				started = true
+			}
+
+			if err := enc.Encode(o); err != nil {
+				return err
+			}
+
+			flush()
+			return nil
+		}
+	}
+
+	err = h.ExportOrders(ctx, rID, search, write)
+	if err != nil && !started {
+		respondOrderSearchErr(w, err)
+		return
+	}
+
+	if err != nil {
+		h.Logger().WithError(err).WithField("restaurant", rID).Error("could not export orders")
+		return
+	}
+
+	if !started {
+		// Nothing matched; still answer with an empty export.
+		if wantsCSV(r) {
+			respondCSV(w, "orders.csv", [][]string{orderExportHeader})
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/x-ndjson; charset=utf-8")
+		w.WriteHeader(http.StatusOK)
+	}
+}
@@ -0,0 +1,107 @@
+package handler
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/matryer/way"
+
+	"ovto/internal/service"
+)
+
+type priceChangeInput struct {
+	Price       float64   `json:"price"`
+	EffectiveAt time.Time `json:"effective_at"`
+}
+
+func respondPriceErr(w http.ResponseWriter, err error) {
+	if err == service.ErrUnauthenticated {
+		http.Error(w, err.Error(), http.StatusUnauthorized)
+		return
+	}
+
+	if err == service.ErrRestaurantNotFound || err == service.ErrItemNotFound || err == service.ErrPriceChangeNotFound {
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return
+	}
+
+	if err == service.ErrInvalidPrice || err == service.ErrInvalidPriceChange {
+		http.Error(w, err.Error(), http.StatusUnprocessableEntity)
+		return
+	}
+
+	if err == service.ErrMenuLinked {
+		http.Error(w, err.Error(), http.StatusConflict)
+		return
+	}
+
+	respondErr(w, err)
+}
+
+func (h *handler) getPriceHistory(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	rID := way.Param(ctx, "restaurant_id")
+	iID, err := strconv.ParseInt(way.Param(ctx, "item_id"), 10, 64)
+	if err != nil {
+		respondPriceErr(w, service.ErrItemNotFound)
+		return
+	}
+
+	pp, err := h.GetPriceHistory(ctx, rID, iID)
+	if err != nil {
+		respondPriceErr(w, err)
+		return
+	}
+
+	respond(w, pp, http.StatusOK)
+}
+
+func (h *handler) schedulePriceChange(w http.ResponseWriter, r *http.Request) {
+	var in priceChangeInput
+	defer r.Body.Close()
+	if err := json.NewDecoder(r.Body).Decode(&in); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	ctx := r.Context()
+	rID := way.Param(ctx, "restaurant_id")
+	iID, err := strconv.ParseInt(way.Param(ctx, "item_id"), 10, 64)
+	if err != nil {
+		respondPriceErr(w, service.ErrItemNotFound)
+		return
+	}
+
+	id, err := h.SchedulePriceChange(ctx, rID, iID, in.Price, in.EffectiveAt)
+	if err != nil {
+		respondPriceErr(w, err)
+		return
+	}
+
+	respond(w, map[string]int64{"id": id}, http.StatusCreated)
+}
+
+func (h *handler) cancelPriceChange(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	rID := way.Param(ctx, "restaurant_id")
+	iID, err := strconv.ParseInt(way.Param(ctx, "item_id"), 10, 64)
+	if err != nil {
+		respondPriceErr(w, service.ErrItemNotFound)
+		return
+	}
+
+	id, err := strconv.ParseInt(way.Param(ctx, "change_id"), 10, 64)
+	if err != nil {
+		respondPriceErr(w, service.ErrPriceChangeNotFound)
+		return
+	}
+
+	if err = h.CancelPriceChange(ctx, rID, iID, id); err != nil {
+		respondPriceErr(w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
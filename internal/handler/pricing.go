@@ -0,0 +1,79 @@
+package handler
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+
+	"github.com/matryer/way"
+
+	"ovto/internal/service"
+)
+
+func respondPricingErr(w http.ResponseWriter, err error) {
+	if err == service.ErrUnauthenticated {
+		http.Error(w, err.Error(), http.StatusUnauthorized)
+		return
+	}
+
+	if err == service.ErrRestaurantNotFound || err == service.ErrCategoryNotFound || err == service.ErrItemNotFound ||
+		err == service.ErrPricingRuleNotFound {
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return
+	}
+
+	if err == service.ErrInvalidPricingRule {
+		http.Error(w, err.Error(), http.StatusUnprocessableEntity)
+		return
+	}
+
+	respondErr(w, err)
+}
+
+func (h *handler) getPricingRules(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	rID := way.Param(ctx, "restaurant_id")
+	rr, err := h.GetPricingRules(ctx, rID)
+	if err != nil {
+		respondPricingErr(w, err)
+		return
+	}
+
+	respond(w, rr, http.StatusOK)
+}
+
+func (h *handler) createPricingRule(w http.ResponseWriter, r *http.Request) {
+	var in service.PricingRule
+	defer r.Body.Close()
+	if err := json.NewDecoder(r.Body).Decode(&in); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	ctx := r.Context()
+	rID := way.Param(ctx, "restaurant_id")
+	id, err := h.CreatePricingRule(ctx, rID, in)
+	if err != nil {
+		respondPricingErr(w, err)
+		return
+	}
+
+	respond(w, map[string]int64{"id": id}, http.StatusCreated)
+}
+
+func (h *handler) deletePricingRule(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	rID := way.Param(ctx, "restaurant_id")
+	id, err := strconv.ParseInt(way.Param(ctx, "rule_id"), 10, 64)
+	if err != nil {
+		respondPricingErr(w, service.ErrPricingRuleNotFound)
+		return
+	}
+
+	if err = h.DeletePricingRule(ctx, rID, id); err != nil {
+		respondPricingErr(w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
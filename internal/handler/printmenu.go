@@ -0,0 +1,63 @@
+package handler
+
+import (
+	"net/http"
+
+	"github.com/matryer/way"
+
+	"ovto/internal/service"
+)
+
+var printContentTypes = map[string]string{
+	service.PrintFormatHTML: "text/html; charset=utf-8",
+	service.PrintFormatPDF:  "application/pdf",
+}
+
+func respondPrintErr(w http.ResponseWriter, err error) {
+	if err == service.ErrUnauthenticated {
+		http.Error(w, err.Error(), http.StatusUnauthorized)
+		return
+	}
+
+	if err == service.ErrRestaurantNotFound {
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return
+	}
+
+	if err == service.ErrInvalidPrintFormat || err == service.ErrUnsupportedPDFLocale {
+		http.Error(w, err.Error(), http.StatusUnprocessableEntity)
+		return
+	}
+
+	respondErr(w, err)
+}
+
+// getPrintableMenu responds with the restaurant's menu as a printable
+// ?format=html (the default) or pdf download. Its ETag is the menu version,
+// so clients holding the latest copy get a 304. Bangla menus only print as
+// html, since the pdf can't shape the script; asking for a pdf gets a 422.
+func (h *handler) getPrintableMenu(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	rID := way.Param(ctx, "restaurant_id")
+	format := r.URL.Query().Get("format")
+	if format == "" {
+		format = service.PrintFormatHTML
+	}
+
+	b, version, err := h.PrintableMenu(ctx, rID, format)
+	if err != nil {
+		respondPrintErr(w, err)
+		return
+	}
+
+	etag := `"` + version + `"`
+	w.Header().Set("ETag", etag)
+	if r.Header.Get("If-None-Match") == etag {
+		w.WriteHeader(http.StatusNotModified)
+		return
+	}
+
+	w.Header().Set("Content-Type", printContentTypes[format])
+	w.Header().Set("Content-Disposition", "attachment; filename=menu."+format)
+	_, _ = w.Write(b)
+}
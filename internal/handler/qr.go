@@ -0,0 +1,123 @@
+package handler
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+
+	"github.com/matryer/way"
+
+	"ovto/internal/service"
+)
+
+type tableInput struct {
+	Label string `json:"label"`
+}
+
+var qrContentTypes = map[string]string{
+	service.QRFormatPNG: "image/png",
+	service.QRFormatSVG: "image/svg+xml",
+}
+
+func respondTableErr(w http.ResponseWriter, err error) {
+	if err == service.ErrUnauthenticated {
+		http.Error(w, err.Error(), http.StatusUnauthorized)
+		return
+	}
+
+	if err == service.ErrRestaurantNotFound || err == service.ErrTableNotFound {
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return
+	}
+
+	if err == service.ErrEmptyValue || err == service.ErrInvalidQRFormat || err == service.ErrInvalidQRSize {
+		http.Error(w, err.Error(), http.StatusUnprocessableEntity)
+		return
+	}
+
+	if err == service.ErrTitleTaken {
+		http.Error(w, err.Error(), http.StatusConflict)
+		return
+	}
+
+	respondErr(w, err)
+}
+
+func (h *handler) createTable(w http.ResponseWriter, r *http.Request) {
+	var in tableInput
+	defer r.Body.Close()
+	if err := json.NewDecoder(r.Body).Decode(&in); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	ctx := r.Context()
+	rID := way.Param(ctx, "restaurant_id")
+	id, err := h.CreateTable(ctx, rID, in.Label)
+	if err != nil {
+		respondTableErr(w, err)
+		return
+	}
+
+	respond(w, map[string]int64{"id": id}, http.StatusCreated)
+}
+
+func (h *handler) getTables(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	rID := way.Param(ctx, "restaurant_id")
+	tt, err := h.GetTables(ctx, rID)
+	if err != nil {
+		respondTableErr(w, err)
+		return
+	}
+
+	respond(w, tt, http.StatusOK)
+}
+
+func (h *handler) archiveTable(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	rID := way.Param(ctx, "restaurant_id")
+	tID, err := strconv.ParseInt(way.Param(ctx, "table_id"), 10, 64)
+	if err != nil {
+		respondTableErr(w, service.ErrTableNotFound)
+		return
+	}
+
+	if err = h.ArchiveTable(ctx, rID, tID); err != nil {
+		respondTableErr(w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// getMenuQR responds with the QR code of the restaurant's menu link, or of
+// a table's, as ?format=png (the default) or svg, ?size pixels wide.
+func (h *handler) getMenuQR(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	rID := way.Param(ctx, "restaurant_id")
+	var tID int64
+	if p := way.Param(ctx, "table_id"); p != "" {
+		var err error
+		if tID, err = strconv.ParseInt(p, 10, 64); err != nil {
+			respondTableErr(w, service.ErrTableNotFound)
+			return
+		}
+	}
+
+	format := r.URL.Query().Get("format")
+	if format == "" {
+		format = service.QRFormatPNG
+	}
+
+	size, _ := strconv.Atoi(r.URL.Query().Get("size"))
+	b, err := h.MenuQR(ctx, rID, tID, format, size)
+	if err != nil {
+		respondTableErr(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", qrContentTypes[format])
+	w.Header().Set("Content-Disposition", "inline; filename=menu."+format)
+	_, _ = w.Write(b)
+}
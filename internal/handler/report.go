@@ -0,0 +1,176 @@
+package handler
+
+import (
+	"context"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/matryer/way"
+
+	"ovto/internal/service"
+)
+
+const reportDateLayout = "2006-01-02"
+
+// reportRange reads the from and to dates of a report, both inclusive, in
+// the restaurant's time zone.
+func (h *handler) reportRange(r *http.Request) (time.Time, time.Time, error) {
+	ctx := r.Context()
+	q := r.URL.Query()
+	return h.ReportRange(ctx, way.Param(ctx, "restaurant_id"), q.Get("from"), q.Get("to"))
+}
+
+func respondReportErr(w http.ResponseWriter, err error) {
+	if err == service.ErrUnauthenticated {
+		http.Error(w, err.Error(), http.StatusUnauthorized)
+		return
+	}
+
+	if err == service.ErrRestaurantNotFound || err == service.ErrInvalidRestaurantId {
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return
+	}
+
+	if err == service.ErrInvalidPeriod || err == service.ErrInvalidDateRange {
+		http.Error(w, err.Error(), http.StatusUnprocessableEntity)
+		return
+	}
+
+	respondErr(w, err)
+}
+
+func (h *handler) getRevenueReport(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	rID := way.Param(ctx, "restaurant_id")
+	from, to, err := h.reportRange(r)
+	if err != nil {
+		respondReportErr(w, err)
+		return
+	}
+
+	rr, err := h.GetRevenueReport(ctx, rID, r.URL.Query().Get("period"), from, to)
+	if err != nil {
+		respondReportErr(w, err)
+		return
+	}
+
+	if wantsCSV(r) {
+		records := [][]string{{"period", "orders", "revenue", "average_order_value"}}
+		for _, row := range rr {
+			records = append(records, []string{
+				row.Period.Format(reportDateLayout),
+				strconv.FormatInt(row.Orders, 10),
+				strconv.FormatFloat(row.Revenue, 'f', 2, 64),
+				strconv.FormatFloat(row.AverageOrderValue, 'f', 2, 64),
+			})
+		}
+		respondCSV(w, "revenue.csv", records)
+		return
+	}
+
+	respond(w, rr, http.StatusOK)
+}
+
+func (h *handler) getStatusReport(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	rID := way.Param(ctx, "restaurant_id")
+	from, to, err := h.reportRange(r)
+	if err != nil {
+		respondReportErr(w, err)
+		return
+	}
+
+	rr, err := h.GetStatusReport(ctx, rID, from, to)
+	if err != nil {
+		respondReportErr(w, err)
+		return
+	}
+
+	if wantsCSV(r) {
+		records := [][]string{{"status", "orders"}}
+		for _, row := range rr {
+			records = append(records, []string{row.Status, strconv.FormatInt(row.Orders, 10)})
+		}
+		respondCSV(w, "orders.csv", records)
+		return
+	}
+
+	respond(w, rr, http.StatusOK)
+}
+
+func (h *handler) getTopItems(w http.ResponseWriter, r *http.Request) {
+	h.getSalesReport(w, r, "items.csv", h.GetTopItems)
+}
+
+func (h *handler) getTopCategories(w http.ResponseWriter, r *http.Request) {
+	h.getSalesReport(w, r, "categories.csv", h.GetTopCategories)
+}
+
+func (h *handler) getSalesReport(w http.ResponseWriter, r *http.Request, filename string,
+	report func(ctx context.Context, rid string, from, to time.Time, limit int) ([]service.SalesReport, error)) {
+	ctx := r.Context()
+	rID := way.Param(ctx, "restaurant_id")
+	from, to, err := h.reportRange(r)
+	if err != nil {
+		respondReportErr(w, err)
+		return
+	}
+
+	limit, _ := strconv.Atoi(r.URL.Query().Get("limit"))
+	rr, err := report(ctx, rID, from, to, limit)
+	if err != nil {
+		respondReportErr(w, err)
+		return
+	}
+
+	if wantsCSV(r) {
+		records := [][]string{{"id", "name", "category", "quantity", "revenue"}}
+		for _, row := range rr {
+			records = append(records, []string{
+				strconv.FormatInt(row.Id, 10),
+				row.Name,
+				row.Category,
+				strconv.FormatInt(row.Quantity, 10),
+				strconv.FormatFloat(row.Revenue, 'f', 2, 64),
+			})
+		}
+		respondCSV(w, filename, records)
+		return
+	}
+
+	respond(w, rr, http.StatusOK)
+}
+
+func (h *handler) getHeatmap(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	rID := way.Param(ctx, "restaurant_id")
+	from, to, err := h.reportRange(r)
+	if err != nil {
+		respondReportErr(w, err)
+		return
+	}
+
+	m, err := h.GetHeatmap(ctx, rID, from, to)
+	if err != nil {
+		respondReportErr(w, err)
+		return
+	}
+
+	if wantsCSV(r) {
+		records := [][]string{{"weekday", "hour", "orders"}}
+		for day, hours := range m.Orders {
+			for hour, count := range hours {
+				records = append(records, []string{
+					time.Weekday(day).String(),
+					strconv.Itoa(hour),
+					strconv.FormatInt(count, 10),
+				})
+			}
+		}
+		respondCSV(w, "heatmap.csv", records)
+		return
+	}
+
+	respond(w, m, http.StatusOK)
+}
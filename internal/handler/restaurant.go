@@ -9,16 +9,14 @@ import (
 )
 
 type createRestaurantInput struct {
-	Title       string `json:"title"`
-	About       string `json:"about,omitempty"`
-	Phone       string `json:"phone"`
-	Location    string `json:"location"`
-	City        string `json:"city"`
-	Area        string `json:"area"`
-	Country     string `json:"country"`
-	OpeningTime string `json:"opening_time"`
-	ClosingTime string `json:"closing_time"`
-	Referral    string `json:"referral,omitempty"`
+	Title    string `json:"title"`
+	About    string `json:"about,omitempty"`
+	Phone    string `json:"phone"`
+	Location string `json:"location"`
+	City     string `json:"city"`
+	Area     string `json:"area"`
+	Country  string `json:"country"`
+	Referral string `json:"referral,omitempty"`
 }
 
 type updateRestaurantInput struct {
@@ -39,7 +37,7 @@ func (h *handler) createRestaurant(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	err := h.CreateRestaurant(r.Context(), in.Title, in.About, in.Phone, in.Location, in.City, in.Area, in.Country, in.OpeningTime, in.ClosingTime)
+	err := h.CreateRestaurant(r.Context(), in.Title, in.About, in.Phone, in.Location, in.City, in.Area, in.Country)
 	if err == service.ErrUnauthenticated {
 		http.Error(w, err.Error(), http.StatusUnauthorized)
 		return
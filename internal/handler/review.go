@@ -0,0 +1,274 @@
+package handler
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"github.com/matryer/way"
+
+	"ovto/internal/service"
+)
+
+type createReviewInput struct {
+	Stars int    `json:"stars"`
+	Body  string `json:"body"`
+}
+
+type replyReviewInput struct {
+	Reply string `json:"reply"`
+}
+
+type flagReviewInput struct {
+	Reason string `json:"reason"`
+}
+
+func (h *handler) createReview(w http.ResponseWriter, r *http.Request) {
+	var in createReviewInput
+	defer r.Body.Close()
+	if err := json.NewDecoder(r.Body).Decode(&in); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	ctx := r.Context()
+	oID, err := strconv.ParseInt(way.Param(ctx, "order_id"), 10, 64)
+	if err != nil {
+		http.Error(w, service.ErrOrderNotFound.Error(), http.StatusNotFound)
+		return
+	}
+
+	id, err := h.CreateReview(ctx, oID, in.Stars, in.Body)
+	if err == service.ErrUnauthenticated {
+		http.Error(w, err.Error(), http.StatusUnauthorized)
+		return
+	}
+
+	if err == service.ErrOrderNotFound {
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return
+	}
+
+	if err == service.ErrInvalidStars || err == service.ErrInvalidReview || err == service.ErrOrderNotCompleted {
+		http.Error(w, err.Error(), http.StatusUnprocessableEntity)
+		return
+	}
+
+	if err == service.ErrReviewExists {
+		http.Error(w, err.Error(), http.StatusConflict)
+		return
+	}
+
+	if err != nil {
+		respondErr(w, err)
+		return
+	}
+
+	respond(w, map[string]int64{"id": id}, http.StatusCreated)
+}
+
+func (h *handler) createReviewPicture(w http.ResponseWriter, r *http.Request) {
+	r.Body = http.MaxBytesReader(w, r.Body, service.MaxImageBytes)
+	defer r.Body.Close()
+
+	ctx := r.Context()
+	id, err := strconv.ParseInt(way.Param(ctx, "review_id"), 10, 64)
+	if err != nil {
+		http.Error(w, service.ErrReviewNotFound.Error(), http.StatusNotFound)
+		return
+	}
+
+	imageURL, err := h.CreateReviewPicture(ctx, r.Body, id)
+	if err == service.ErrUnauthenticated {
+		http.Error(w, err.Error(), http.StatusUnauthorized)
+		return
+	}
+
+	if err == service.ErrReviewNotFound {
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return
+	}
+
+	if err == service.ErrTooManyPictures {
+		http.Error(w, err.Error(), http.StatusUnprocessableEntity)
+		return
+	}
+
+	if err == service.ErrUnsupportedImageFormat {
+		http.Error(w, err.Error(), http.StatusUnsupportedMediaType)
+		return
+	}
+
+	if err != nil {
+		respondErr(w, err)
+		return
+	}
+
+	fmt.Fprint(w, imageURL)
+}
+
+func (h *handler) getReviews(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	rID := way.Param(ctx, "restaurant_id")
+	q := r.URL.Query()
+	last, _ := strconv.ParseInt(q.Get("last"), 10, 64)
+	first, _ := strconv.Atoi(q.Get("first"))
+
+	rr, err := h.GetReviews(ctx, rID, last, first)
+	if err == service.ErrInvalidRestaurantId {
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return
+	}
+
+	if err != nil {
+		respondErr(w, err)
+		return
+	}
+
+	respond(w, rr, http.StatusOK)
+}
+
+func (h *handler) getReviewsForFp(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	rID := way.Param(ctx, "restaurant_id")
+	q := r.URL.Query()
+	last, _ := strconv.ParseInt(q.Get("last"), 10, 64)
+	first, _ := strconv.Atoi(q.Get("first"))
+
+	rr, err := h.GetReviewsForFp(ctx, rID, last, first)
+	if err == service.ErrUnauthenticated {
+		http.Error(w, err.Error(), http.StatusUnauthorized)
+		return
+	}
+
+	if err == service.ErrRestaurantNotFound || err == service.ErrInvalidRestaurantId {
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return
+	}
+
+	if err != nil {
+		respondErr(w, err)
+		return
+	}
+
+	respond(w, rr, http.StatusOK)
+}
+
+func (h *handler) replyToReview(w http.ResponseWriter, r *http.Request) {
+	var in replyReviewInput
+	defer r.Body.Close()
+	if err := json.NewDecoder(r.Body).Decode(&in); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	ctx := r.Context()
+	rID := way.Param(ctx, "restaurant_id")
+	id, err := strconv.ParseInt(way.Param(ctx, "review_id"), 10, 64)
+	if err != nil {
+		http.Error(w, service.ErrReviewNotFound.Error(), http.StatusNotFound)
+		return
+	}
+
+	err = h.ReplyToReview(ctx, rID, id, in.Reply)
+	if err == service.ErrUnauthenticated {
+		http.Error(w, err.Error(), http.StatusUnauthorized)
+		return
+	}
+
+	if err == service.ErrRestaurantNotFound || err == service.ErrInvalidRestaurantId || err == service.ErrReviewNotFound {
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return
+	}
+
+	if err == service.ErrEmptyValue || err == service.ErrInvalidReview {
+		http.Error(w, err.Error(), http.StatusUnprocessableEntity)
+		return
+	}
+
+	if err != nil {
+		respondErr(w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (h *handler) flagReview(w http.ResponseWriter, r *http.Request) {
+	var in flagReviewInput
+	defer r.Body.Close()
+	if err := json.NewDecoder(r.Body).Decode(&in); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	ctx := r.Context()
+	rID := way.Param(ctx, "restaurant_id")
+	id, err := strconv.ParseInt(way.Param(ctx, "review_id"), 10, 64)
+	if err != nil {
+		http.Error(w, service.ErrReviewNotFound.Error(), http.StatusNotFound)
+		return
+	}
+
+	err = h.FlagReview(ctx, rID, id, in.Reason)
+	if err == service.ErrUnauthenticated {
+		http.Error(w, err.Error(), http.StatusUnauthorized)
+		return
+	}
+
+	if err == service.ErrRestaurantNotFound || err == service.ErrInvalidRestaurantId || err == service.ErrReviewNotFound {
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return
+	}
+
+	if err == service.ErrEmptyValue || err == service.ErrInvalidReview {
+		http.Error(w, err.Error(), http.StatusUnprocessableEntity)
+		return
+	}
+
+	if err == service.ErrNotCustomer {
+		http.Error(w, err.Error(), http.StatusForbidden)
+		return
+	}
+
+	if err == service.ErrAlreadyFlagged {
+		http.Error(w, err.Error(), http.StatusConflict)
+		return
+	}
+
+	if err != nil {
+		respondErr(w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (h *handler) unhideReview(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	rID := way.Param(ctx, "restaurant_id")
+	id, err := strconv.ParseInt(way.Param(ctx, "review_id"), 10, 64)
+	if err != nil {
+		http.Error(w, service.ErrReviewNotFound.Error(), http.StatusNotFound)
+		return
+	}
+
+	err = h.UnhideReview(ctx, rID, id)
+	if err == service.ErrUnauthenticated {
+		http.Error(w, err.Error(), http.StatusUnauthorized)
+		return
+	}
+
+	if err == service.ErrRestaurantNotFound || err == service.ErrInvalidRestaurantId || err == service.ErrReviewNotFound {
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return
+	}
+
+	if err != nil {
+		respondErr(w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
@@ -0,0 +1,95 @@
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"strconv"
+
+	"github.com/matryer/way"
+
+	"ovto/internal/service"
+)
+
+func respondScheduleErr(w http.ResponseWriter, err error) {
+	if err == service.ErrUnauthenticated {
+		http.Error(w, err.Error(), http.StatusUnauthorized)
+		return
+	}
+
+	if err == service.ErrRestaurantNotFound || err == service.ErrCategoryNotFound || err == service.ErrItemNotFound {
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return
+	}
+
+	if err == service.ErrInvalidSchedule {
+		http.Error(w, err.Error(), http.StatusUnprocessableEntity)
+		return
+	}
+
+	respondErr(w, err)
+}
+
+// getSchedules responds with the schedules of the category or item named by
+// the param.
+func (h *handler) getSchedules(w http.ResponseWriter, r *http.Request, param string, notFound error,
+	get func(context.Context, string, int64) ([]service.Schedule, error)) {
+	ctx := r.Context()
+	rID := way.Param(ctx, "restaurant_id")
+	id, err := strconv.ParseInt(way.Param(ctx, param), 10, 64)
+	if err != nil {
+		respondScheduleErr(w, notFound)
+		return
+	}
+
+	ss, err := get(ctx, rID, id)
+	if err != nil {
+		respondScheduleErr(w, err)
+		return
+	}
+
+	respond(w, ss, http.StatusOK)
+}
+
+// setSchedules replaces the schedules of the category or item named by the
+// param.
+func (h *handler) setSchedules(w http.ResponseWriter, r *http.Request, param string, notFound error,
+	set func(context.Context, string, int64, []service.Schedule) error) {
+	var in []service.Schedule
+	defer r.Body.Close()
+	if err := json.NewDecoder(r.Body).Decode(&in); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	ctx := r.Context()
+	rID := way.Param(ctx, "restaurant_id")
+	id, err := strconv.ParseInt(way.Param(ctx, param), 10, 64)
+	if err != nil {
+		respondScheduleErr(w, notFound)
+		return
+	}
+
+	if err = set(ctx, rID, id, in); err != nil {
+		respondScheduleErr(w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (h *handler) getCategorySchedules(w http.ResponseWriter, r *http.Request) {
+	h.getSchedules(w, r, "category_id", service.ErrCategoryNotFound, h.GetCategorySchedules)
+}
+
+func (h *handler) setCategorySchedules(w http.ResponseWriter, r *http.Request) {
+	h.setSchedules(w, r, "category_id", service.ErrCategoryNotFound, h.SetCategorySchedules)
+}
+
+func (h *handler) getItemSchedules(w http.ResponseWriter, r *http.Request) {
+	h.getSchedules(w, r, "item_id", service.ErrItemNotFound, h.GetItemSchedules)
+}
+
+func (h *handler) setItemSchedules(w http.ResponseWriter, r *http.Request) {
+	h.setSchedules(w, r, "item_id", service.ErrItemNotFound, h.SetItemSchedules)
+}
@@ -0,0 +1,55 @@
+package handler
+
+import (
+	"net/http"
+	"strconv"
+
+	"ovto/internal/service"
+)
+
+// searchQuery reads a search from the query string, e.g.
+// ?q=kacchi&city=Dhaka&area=Dhanmondi&min_price=200&max_price=500.
+func searchQuery(r *http.Request) (service.SearchQuery, error) {
+	q := r.URL.Query()
+	search := service.SearchQuery{Text: q.Get("q"), City: q.Get("city"), Area: q.Get("area")}
+	var err error
+	if s := q.Get("min_price"); s != "" {
+		if search.MinPrice, err = strconv.ParseFloat(s, 64); err != nil {
+			return search, service.ErrInvalidPrice
+		}
+	}
+
+	if s := q.Get("max_price"); s != "" {
+		if search.MaxPrice, err = strconv.ParseFloat(s, 64); err != nil {
+			return search, service.ErrInvalidPrice
+		}
+	}
+
+	search.Limit, _ = strconv.Atoi(q.Get("limit"))
+	return search, nil
+}
+
+func respondSearchErr(w http.ResponseWriter, err error) {
+	if err == service.ErrEmptyValue || err == service.ErrInvalidPrice {
+		http.Error(w, err.Error(), http.StatusUnprocessableEntity)
+		return
+	}
+
+	respondErr(w, err)
+}
+
+func (h *handler) search(w http.ResponseWriter, r *http.Request) {
+	q, err := searchQuery(r)
+	if err != nil {
+		respondSearchErr(w, err)
+		return
+	}
+
+	rr, err := h.Search(r.Context(), q)
+	if err != nil {
+		respondSearchErr(w, err)
+		return
+	}
+
+	respond(w, rr, http.StatusOK)
+}
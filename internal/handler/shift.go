@@ -0,0 +1,173 @@
+package handler
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+
+	"github.com/matryer/way"
+
+	"ovto/internal/service"
+)
+
+type openShiftInput struct {
+	Float float64 `json:"float"`
+}
+
+type shiftEntryInput struct {
+	Kind    string  `json:"kind"`
+	Method  string  `json:"method"`
+	Amount  float64 `json:"amount"`
+	VAT     float64 `json:"vat"`
+	OrderId int64   `json:"order_id"`
+	Note    string  `json:"note"`
+}
+
+type closeShiftInput struct {
+	CountedCash float64 `json:"counted_cash"`
+}
+
+func respondShiftErr(w http.ResponseWriter, err error) {
+	if err == service.ErrUnauthenticated {
+		http.Error(w, err.Error(), http.StatusUnauthorized)
+		return
+	}
+
+	if err == service.ErrRestaurantNotFound || err == service.ErrInvalidRestaurantId ||
+		err == service.ErrShiftNotFound || err == service.ErrOrderNotFound {
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return
+	}
+
+	if err == service.ErrInvalidAmount || err == service.ErrInvalidEntry {
+		http.Error(w, err.Error(), http.StatusUnprocessableEntity)
+		return
+	}
+
+	if err == service.ErrShiftAlreadyOpen || err == service.ErrShiftClosed ||
+		err == service.ErrShiftNotClosed || err == service.ErrShiftSignedOff {
+		http.Error(w, err.Error(), http.StatusConflict)
+		return
+	}
+
+	respondErr(w, err)
+}
+
+func (h *handler) openShift(w http.ResponseWriter, r *http.Request) {
+	var in openShiftInput
+	defer r.Body.Close()
+	if err := json.NewDecoder(r.Body).Decode(&in); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	ctx := r.Context()
+	rID := way.Param(ctx, "restaurant_id")
+
+	z, err := h.OpenShift(ctx, rID, in.Float)
+	if err != nil {
+		respondShiftErr(w, err)
+		return
+	}
+
+	respond(w, z, http.StatusCreated)
+}
+
+func (h *handler) getCurrentShift(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	rID := way.Param(ctx, "restaurant_id")
+
+	z, err := h.GetCurrentShift(ctx, rID)
+	if err != nil {
+		respondShiftErr(w, err)
+		return
+	}
+
+	respond(w, z, http.StatusOK)
+}
+
+func (h *handler) getZReport(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	rID := way.Param(ctx, "restaurant_id")
+	sID, err := strconv.ParseInt(way.Param(ctx, "shift_id"), 10, 64)
+	if err != nil {
+		respondShiftErr(w, service.ErrShiftNotFound)
+		return
+	}
+
+	z, err := h.GetZReport(ctx, rID, sID)
+	if err != nil {
+		respondShiftErr(w, err)
+		return
+	}
+
+	respond(w, z, http.StatusOK)
+}
+
+func (h *handler) recordShiftEntry(w http.ResponseWriter, r *http.Request) {
+	var in shiftEntryInput
+	defer r.Body.Close()
+	if err := json.NewDecoder(r.Body).Decode(&in); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	ctx := r.Context()
+	rID := way.Param(ctx, "restaurant_id")
+	sID, err := strconv.ParseInt(way.Param(ctx, "shift_id"), 10, 64)
+	if err != nil {
+		respondShiftErr(w, service.ErrShiftNotFound)
+		return
+	}
+
+	err = h.RecordShiftEntry(ctx, rID, sID, in.Kind, in.Method, in.Amount, in.VAT, in.OrderId, in.Note)
+	if err != nil {
+		respondShiftErr(w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (h *handler) closeShift(w http.ResponseWriter, r *http.Request) {
+	var in closeShiftInput
+	defer r.Body.Close()
+	if err := json.NewDecoder(r.Body).Decode(&in); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	ctx := r.Context()
+	rID := way.Param(ctx, "restaurant_id")
+	sID, err := strconv.ParseInt(way.Param(ctx, "shift_id"), 10, 64)
+	if err != nil {
+		respondShiftErr(w, service.ErrShiftNotFound)
+		return
+	}
+
+	z, err := h.CloseShift(ctx, rID, sID, in.CountedCash)
+	if err != nil {
+		respondShiftErr(w, err)
+		return
+	}
+
+	respond(w, z, http.StatusOK)
+}
+
+func (h *handler) signOffShift(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	rID := way.Param(ctx, "restaurant_id")
+	sID, err := strconv.ParseInt(way.Param(ctx, "shift_id"), 10, 64)
+	if err != nil {
+		respondShiftErr(w, service.ErrShiftNotFound)
+		return
+	}
+
+	z, err := h.SignOffShift(ctx, rID, sID)
+	if err != nil {
+		respondShiftErr(w, err)
+		return
+	}
+
+	respond(w, z, http.StatusOK)
+}
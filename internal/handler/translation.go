@@ -0,0 +1,132 @@
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"strconv"
+
+	"github.com/matryer/way"
+
+	"ovto/internal/service"
+)
+
+type categoryTranslationInput struct {
+	Label string `json:"label"`
+}
+
+type restaurantTranslationInput struct {
+	About string `json:"about"`
+}
+
+// withLocale puts the locale the Accept-Language header prefers in the
+// context and tells caches responses vary by it.
+func withLocale(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		locale := service.ParseAcceptLanguage(r.Header.Get("Accept-Language"))
+		w.Header().Add("Vary", "Accept-Language")
+		w.Header().Set("Content-Language", locale)
+		ctx := context.WithValue(r.Context(), service.KeyLocale, locale)
+		next.ServeHTTP(w, r.WithContext(ctx))
+	})
+}
+
+func respondTranslationErr(w http.ResponseWriter, err error) {
+	if err == service.ErrUnauthenticated {
+		http.Error(w, err.Error(), http.StatusUnauthorized)
+		return
+	}
+
+	if err == service.ErrRestaurantNotFound || err == service.ErrCategoryNotFound || err == service.ErrItemNotFound {
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return
+	}
+
+	if err == service.ErrUnsupportedLocale || err == service.ErrInvalidTranslation {
+		http.Error(w, err.Error(), http.StatusUnprocessableEntity)
+		return
+	}
+
+	respondErr(w, err)
+}
+
+func (h *handler) getTranslations(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	rID := way.Param(ctx, "restaurant_id")
+	t, err := h.GetTranslations(ctx, rID)
+	if err != nil {
+		respondTranslationErr(w, err)
+		return
+	}
+
+	respond(w, t, http.StatusOK)
+}
+
+func (h *handler) setRestaurantTranslation(w http.ResponseWriter, r *http.Request) {
+	var in restaurantTranslationInput
+	defer r.Body.Close()
+	if err := json.NewDecoder(r.Body).Decode(&in); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	ctx := r.Context()
+	rID := way.Param(ctx, "restaurant_id")
+	locale := way.Param(ctx, "locale")
+	if err := h.SetRestaurantTranslation(ctx, rID, locale, in.About); err != nil {
+		respondTranslationErr(w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (h *handler) setCategoryTranslation(w http.ResponseWriter, r *http.Request) {
+	var in categoryTranslationInput
+	defer r.Body.Close()
+	if err := json.NewDecoder(r.Body).Decode(&in); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	ctx := r.Context()
+	rID := way.Param(ctx, "restaurant_id")
+	locale := way.Param(ctx, "locale")
+	cID, err := strconv.ParseInt(way.Param(ctx, "category_id"), 10, 64)
+	if err != nil {
+		respondTranslationErr(w, service.ErrCategoryNotFound)
+		return
+	}
+
+	if err = h.SetCategoryTranslation(ctx, rID, cID, locale, in.Label); err != nil {
+		respondTranslationErr(w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (h *handler) setItemTranslation(w http.ResponseWriter, r *http.Request) {
+	var in service.ItemTranslation
+	defer r.Body.Close()
+	if err := json.NewDecoder(r.Body).Decode(&in); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	ctx := r.Context()
+	rID := way.Param(ctx, "restaurant_id")
+	locale := way.Param(ctx, "locale")
+	iID, err := strconv.ParseInt(way.Param(ctx, "item_id"), 10, 64)
+	if err != nil {
+		respondTranslationErr(w, service.ErrItemNotFound)
+		return
+	}
+
+	if err = h.SetItemTranslation(ctx, rID, iID, locale, in); err != nil {
+		respondTranslationErr(w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
@@ -1,11 +1,13 @@
 package handler
 
 import (
+	"encoding/csv"
 	"encoding/json"
 	"errors"
 	"fmt"
 	"io"
 	"log"
+	"mime"
 	"net/http"
 )
 
@@ -37,4 +39,23 @@ func writeSSE(w io.Writer, v interface{}) {
 	}
 
 	fmt.Fprintf(w, "data: %s\n\n", b)
-}
\ No newline at end of file
+}
+
+// wantsCSV reports whether the client asked for a CSV export either through
+// the format query parameter or the Accept header.
+func wantsCSV(r *http.Request) bool {
+	if r.URL.Query().Get("format") == "csv" {
+		return true
+	}
+
+	a, _, err := mime.ParseMediaType(r.Header.Get("Accept"))
+	return err == nil && a == "text/csv"
+}
+
+func respondCSV(w http.ResponseWriter, filename string, records [][]string) {
+	w.Header().Set("Content-Type", "text/csv; charset=utf-8")
+	w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=%q", filename))
+	if err := csv.NewWriter(w).WriteAll(records); err != nil {
+		log.Printf("could not write csv: %v\n", err)
+	}
+}
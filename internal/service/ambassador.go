@@ -41,7 +41,7 @@ func (s *Service) CreateAmbassador(ctx context.Context, email, fullname, phone,
 		return ErrInvalidFullname
 	}
 
-	phone = strings.TrimSpace(phone)
+	phone = normalizePhone(phone)
 	if !rxPhone.MatchString(phone) {
 		return ErrInvalidPhone
 	}
@@ -195,7 +195,7 @@ func (s *Service) UserLogin(ctx context.Context, phone string, password string)
 	var out LoginOutput
 
 	password = strings.TrimSpace(password)
-	phone = strings.TrimSpace(phone)
+	phone = normalizePhone(phone)
 	if !rxPhone.MatchString(phone) {
 		return out, ErrInvalidPhone
 	}
@@ -238,7 +238,7 @@ func (s *Service) FoodProviderLogin(ctx context.Context, phone string, password
 	var out FPLoginOutput
 
 	password = strings.TrimSpace(password)
-	phone = strings.TrimSpace(phone)
+	phone = normalizePhone(phone)
 	if !rxPhone.MatchString(phone) {
 		return out, ErrInvalidPhone
 	}
@@ -307,7 +307,7 @@ func (s *Service) AmbassadorLogin(ctx context.Context, phone string, password st
 	var out LoginOutput
 
 	password = strings.TrimSpace(password)
-	phone = strings.TrimSpace(phone)
+	phone = normalizePhone(phone)
 	if !rxPhone.MatchString(phone) {
 		return out, ErrInvalidPhone
 	}
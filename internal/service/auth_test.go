@@ -50,11 +50,11 @@ func TestUserLogin(t *testing.T) {
 		log.Fatalf("failed to validate schema: %v\n", err)
 	}
 
-	s := New(db,nil, codec, fpCodec, nil, url.URL{})
+	s := New(db, codec, fpCodec, nil, url.URL{})
 
 	for _, test := range tt {
 		t.Run(test.Label, func(t *testing.T) {
-			_ = s.CreateUser(ctx, test.Email, "Test User", test.Password)
+			_ = s.CreateUser(ctx, test.Email, "01867584576", "Test User", test.Password)
 			user, got := s.UserLogin(ctx, test.LookupEmail, test.Password)
 			if test.Condition == "success" {
 				if user.Token == "" {
@@ -106,7 +106,7 @@ func TestLoginFoodProvider(t *testing.T) {
 		log.Fatalf("failed to validate schema: %v\n", err)
 	}
 
-	s := New(db, nil, codec, fpCodec, nil, url.URL{})
+	s := New(db, codec, fpCodec, nil, url.URL{})
 
 	_ = s.CreateFoodProvider(ctx, "johndoe@gmail.com", "Taufiq Rahman", "01767586798", "coolpass")
 
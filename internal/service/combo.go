@@ -0,0 +1,333 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+var (
+	// ErrInvalidCombo denotes combo slots without choices or with items that can't be part of a combo.
+	ErrInvalidCombo = errors.New("invalid combo")
+	// ErrInvalidComboChoice denotes a missing, unknown or unavailable component chosen for a combo.
+	ErrInvalidComboChoice = errors.New("invalid combo choice")
+)
+
+// ComboSlot is a part of a combo, e.g. "pick one drink". Slots with a single
+// choice are fixed components.
+type ComboSlot struct {
+	Id       int64         `json:"id"`
+	Name     string        `json:"name"`
+	Quantity int64         `json:"quantity"`
+	Choices  []ComboChoice `json:"choices"`
+}
+
+// ComboChoice is an item that can fill a combo slot.
+type ComboChoice struct {
+	ItemId     int64  `json:"item_id"`
+	Name       string `json:"name,omitempty"`
+	Available  bool   `json:"available"`
+	categoryId int64
+}
+
+// comboComponent is an item chosen for a combo slot, at the slot's position
+// in the combo.
+type comboComponent struct {
+	Position int
+	Slot     string
+	ItemId   int64
+	Quantity int64
+}
+
+// chooseComponents resolves the component of every slot of a combo from the
+// chosen items, keyed by slot id. Fixed slots don't need a choice.
+func chooseComponents(slots []ComboSlot, choices map[int64]int64) ([]comboComponent, error) {
+	cc := make([]comboComponent, 0, len(slots))
+	used := 0
+	for pos, s := range slots {
+		iid, chosen := choices[s.Id]
+		if chosen {
+			used++
+		} else if len(s.Choices) == 1 {
+			iid = s.Choices[0].ItemId
+		} else {
+			return nil, ErrInvalidComboChoice
+		}
+
+		found := false
+		for _, c := range s.Choices {
+			if c.ItemId == iid {
+				if !c.Available {
+					return nil, ErrInvalidComboChoice
+				}
+				found = true
+			}
+		}
+
+		if !found {
+			return nil, ErrInvalidComboChoice
+		}
+
+		cc = append(cc, comboComponent{Position: pos, Slot: s.Name, ItemId: iid, Quantity: s.Quantity})
+	}
+
+	// Choices for slots the combo doesn't have.
+	if used != len(choices) {
+		return nil, ErrInvalidComboChoice
+	}
+
+	return cc, nil
+}
+
+// comboSlots loads the slots of the restaurant's combos. When iid is not zero
+// only that combo is loaded.
+func comboSlots(ctx context.Context, q queryer, rid string, iid int64) (map[int64][]ComboSlot, error) {
+	query := `
+		SELECT s.item_id, s.id, s.name, s.quantity, i.id, i.name, i.category_id,
+			i.availability AND NOT i.sold_out AND NOT i.archived
+				AND EXISTS (SELECT 1 FROM category c WHERE c.id = i.category_id AND c.availability = true)
+		FROM combo_slot s
+			INNER JOIN combo_choice ch ON ch.slot_id = s.id
+			INNER JOIN item i ON ch.item_id = i.id
+		WHERE i.restaurant_id = $1 AND ($2 = 0 OR s.item_id = $2)
+		ORDER BY s.item_id, s.id, i.name`
+	rows, err := q.QueryContext(ctx, query, rid, iid)
+	if err != nil {
+		return nil, fmt.Errorf("could not query combo slots: %v", err)
+	}
+
+	defer rows.Close()
+	combos := make(map[int64][]ComboSlot)
+	for rows.Next() {
+		var iid int64
+		var s ComboSlot
+		var c ComboChoice
+		if err = rows.Scan(&iid, &s.Id, &s.Name, &s.Quantity, &c.ItemId, &c.Name, &c.categoryId, &c.Available); err != nil {
+			return nil, fmt.Errorf("could not scan combo slot: %v", err)
+		}
+
+		ss := combos[iid]
+		if len(ss) == 0 || ss[len(ss)-1].Id != s.Id {
+			s.Choices = make([]ComboChoice, 0)
+			ss = append(ss, s)
+		}
+
+		ss[len(ss)-1].Choices = append(ss[len(ss)-1].Choices, c)
+		combos[iid] = ss
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("could not iterate combo slots: %v", err)
+	}
+
+	return combos, nil
+}
+
+// scheduleChoices marks the combo choices that are off schedule as not
+// available.
+func scheduleChoices(slots []ComboSlot, offCategories, offItems map[int64]bool) {
+	for _, s := range slots {
+		for i, c := range s.Choices {
+			if offCategories[c.categoryId] || offItems[c.ItemId] {
+				s.Choices[i].Available = false
+			}
+		}
+	}
+}
+
+// comboAvailable tells whether every slot of the combo has a choice that can
+// be ordered, fixed components included. Plain items have no slots.
+func comboAvailable(slots []ComboSlot) bool {
+	for _, s := range slots {
+		available := false
+		for _, c := range s.Choices {
+			available = available || c.Available
+		}
+
+		if !available {
+			return false
+		}
+	}
+
+	return true
+}
+
+// GetComboSlots of a menu item. Items that aren't combos have none.
+func (s *Service) GetComboSlots(ctx context.Context, rid string, iid int64) ([]ComboSlot, error) {
+	uid, auth := ctx.Value(KeyAuthFoodProviderID).(int64)
+	if !auth {
+		return nil, ErrUnauthenticated
+	}
+
+	if !rxUUID.MatchString(rid) {
+		return nil, ErrRestaurantNotFound
+	}
+
+	if _, err := s.checkPermission(ctx, Waiter, uid, rid); err != nil {
+		return nil, err
+	}
+
+	combos, err := comboSlots(ctx, s.db, rid, iid)
+	if err != nil {
+		return nil, err
+	}
+
+	if ss, ok := combos[iid]; ok {
+		return ss, nil
+	}
+
+	return make([]ComboSlot, 0), nil
+}
+
+// SetComboSlots turns a menu item into a combo of other items of the
+// restaurant, replacing its current slots. The combo keeps its own price.
+// Without slots the item is a plain item again.
+func (s *Service) SetComboSlots(ctx context.Context, rid string, iid int64, slots []ComboSlot) error {
+	uid, auth := ctx.Value(KeyAuthFoodProviderID).(int64)
+	if !auth {
+		return ErrUnauthenticated
+	}
+
+	if !rxUUID.MatchString(rid) {
+		return ErrRestaurantNotFound
+	}
+
+	for i, sl := range slots {
+		slots[i].Name = strings.TrimSpace(sl.Name)
+		if slots[i].Name == "" {
+			return ErrEmptyValue
+		}
+
+		if sl.Quantity == 0 {
+			slots[i].Quantity = 1
+		}
+
+		if slots[i].Quantity < 1 {
+			return ErrInvalidQuantity
+		}
+
+		if len(sl.Choices) == 0 {
+			return ErrInvalidCombo
+		}
+
+		for _, c := range sl.Choices {
+			if c.ItemId == iid {
+				return ErrInvalidCombo
+			}
+		}
+	}
+
+	if _, err := s.checkPermission(ctx, Manager, uid, rid); err != nil {
+		return err
+	}
+
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("could not begin tx: %v", err)
+	}
+	defer func() { _ = tx.Rollback() }()
+
+	var exists bool
+	query := "SELECT EXISTS (SELECT 1 FROM item WHERE id = $1 AND restaurant_id = $2 AND archived = false)"
+	if err = tx.QueryRowContext(ctx, query, iid, rid).Scan(&exists); err != nil {
+		return fmt.Errorf("could not query item: %v", err)
+	}
+
+	if !exists {
+		return ErrItemNotFound
+	}
+
+	query = "DELETE FROM combo_choice WHERE slot_id IN (SELECT id FROM combo_slot WHERE item_id = $1)"
+	if _, err = tx.ExecContext(ctx, query, iid); err != nil {
+		return fmt.Errorf("could not clear combo choices: %v", err)
+	}
+
+	if _, err = tx.ExecContext(ctx, "DELETE FROM combo_slot WHERE item_id = $1", iid); err != nil {
+		return fmt.Errorf("could not clear combo slots: %v", err)
+	}
+
+	for _, sl := range slots {
+		var sid int64
+		query = "INSERT INTO combo_slot (item_id, name, quantity) VALUES ($1, $2, $3) RETURNING id"
+		if err = tx.QueryRowContext(ctx, query, iid, sl.Name, sl.Quantity).Scan(&sid); err != nil {
+			return fmt.Errorf("could not create combo slot: %v", err)
+		}
+
+		// Combos can't be nested.
+		query = `
+			INSERT INTO combo_choice (slot_id, item_id)
+			SELECT $1, id FROM item
+			WHERE id = $2 AND restaurant_id = $3 AND archived = false
+				AND NOT EXISTS (SELECT 1 FROM combo_slot WHERE item_id = item.id)`
+		for _, c := range sl.Choices {
+			res, err := tx.ExecContext(ctx, query, sid, c.ItemId, rid)
+			if isUniqueViolation(err) {
+				return ErrInvalidCombo
+			}
+
+			if err != nil {
+				return fmt.Errorf("could not create combo choice: %v", err)
+			}
+
+			if n, _ := res.RowsAffected(); n == 0 {
+				return ErrInvalidCombo
+			}
+		}
+	}
+
+	// Neither can a combo be a component of another one.
+	if len(slots) != 0 {
+		query = "SELECT EXISTS (SELECT 1 FROM combo_choice WHERE item_id = $1)"
+		if err = tx.QueryRowContext(ctx, query, iid).Scan(&exists); err != nil {
+			return fmt.Errorf("could not query combo choices: %v", err)
+		}
+
+		if exists {
+			return ErrInvalidCombo
+		}
+	}
+
+	if err = tx.Commit(); err != nil {
+		return fmt.Errorf("could not set combo slots: could not commit transaction: %v", err)
+	}
+
+	return nil
+}
+
+// insertComboComponents validates the chosen components of a combo order
+// line and stores them with it. Lines of plain items have nothing to do.
+func insertComboComponents(ctx context.Context, tx *sql.Tx, rid string, oiid int64, l OrderLineInput,
+	offCategories, offItems map[int64]bool) error {
+	combos, err := comboSlots(ctx, tx, rid, l.ItemId)
+	if err != nil {
+		return err
+	}
+
+	slots, ok := combos[l.ItemId]
+	if !ok {
+		if len(l.Choices) != 0 {
+			return ErrInvalidComboChoice
+		}
+		return nil
+	}
+
+	scheduleChoices(slots, offCategories, offItems)
+
+	cc, err := chooseComponents(slots, l.Choices)
+	if err != nil {
+		return err
+	}
+
+	query := `
+		INSERT INTO order_item_component (order_item_id, position, item_id, slot, quantity)
+		VALUES ($1, $2, $3, $4, $5)`
+	for _, c := range cc {
+		if _, err = tx.ExecContext(ctx, query, oiid, c.Position, c.ItemId, c.Slot, c.Quantity); err != nil {
+			return fmt.Errorf("could not add combo component: %v", err)
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,71 @@
+package service
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestChooseComponents(t *testing.T) {
+	slots := []ComboSlot{
+		{Id: 1, Name: "Main", Quantity: 1, Choices: []ComboChoice{{ItemId: 10, Available: true}}},
+		{Id: 2, Name: "Drink", Quantity: 2, Choices: []ComboChoice{
+			{ItemId: 20, Available: true},
+			{ItemId: 21, Available: false},
+		}},
+	}
+
+	var tt = []struct {
+		Label      string
+		Choices    map[int64]int64
+		Components []comboComponent
+		Err        error
+	}{
+		{Label: "Test should fill fixed slots and chosen ones", Choices: map[int64]int64{2: 20}, Components: []comboComponent{
+			{Position: 0, Slot: "Main", ItemId: 10, Quantity: 1},
+			{Position: 1, Slot: "Drink", ItemId: 20, Quantity: 2},
+		}},
+		{Label: "Test should reject a missing choice", Choices: nil, Err: ErrInvalidComboChoice},
+		{Label: "Test should reject an unavailable choice", Choices: map[int64]int64{2: 21}, Err: ErrInvalidComboChoice},
+		{Label: "Test should reject an item that is not a choice", Choices: map[int64]int64{2: 10}, Err: ErrInvalidComboChoice},
+		{Label: "Test should reject a choice for an unknown slot", Choices: map[int64]int64{2: 20, 3: 20}, Err: ErrInvalidComboChoice},
+	}
+
+	for _, test := range tt {
+		t.Run(test.Label, func(t *testing.T) {
+			cc, err := chooseComponents(slots, test.Choices)
+			if err != test.Err {
+				t.Error("Got:", err, "| Want:", test.Err)
+			}
+			if !cmp.Equal(cc, test.Components) {
+				t.Error("Got:", cc, "| Want:", test.Components)
+			}
+		})
+	}
+}
+
+func TestComboAvailable(t *testing.T) {
+	var tt = []struct {
+		Label string
+		Slots []ComboSlot
+		Want  bool
+	}{
+		{Label: "Test should treat plain items as available", Want: true},
+		{Label: "Test should be available with a choice for every slot", Slots: []ComboSlot{
+			{Id: 1, Choices: []ComboChoice{{ItemId: 10, Available: true}}},
+			{Id: 2, Choices: []ComboChoice{{ItemId: 20}, {ItemId: 21, Available: true}}},
+		}, Want: true},
+		{Label: "Test should not be available with a sold out fixed component", Slots: []ComboSlot{
+			{Id: 1, Choices: []ComboChoice{{ItemId: 10}}},
+			{Id: 2, Choices: []ComboChoice{{ItemId: 20, Available: true}}},
+		}},
+	}
+
+	for _, test := range tt {
+		t.Run(test.Label, func(t *testing.T) {
+			if got := comboAvailable(test.Slots); got != test.Want {
+				t.Error("Got:", got, "| Want:", test.Want)
+			}
+		})
+	}
+}
@@ -0,0 +1,278 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// Spice levels of an item.
+const (
+	SpiceNone = iota
+	SpiceMild
+	SpiceMedium
+	SpiceHot
+)
+
+const (
+	tagKindDiet     = "diet"
+	tagKindAllergen = "allergen"
+)
+
+var (
+	// ErrUnknownTag denotes a dietary tag or allergen outside the vocabulary.
+	ErrUnknownTag = errors.New("unknown dietary tag or allergen")
+	// ErrInvalidSpiceLevel denotes a spice level outside none to hot.
+	ErrInvalidSpiceLevel = errors.New("invalid spice level")
+)
+
+// DietaryTags items can be labelled with.
+var DietaryTags = []string{"halal", "vegetarian", "vegan", "gluten_free", "dairy_free"}
+
+// Allergens items can be labelled with.
+var Allergens = []string{"nuts", "peanuts", "gluten", "dairy", "egg", "soy", "fish", "shellfish", "sesame", "mustard"}
+
+// Dietary information of an item.
+type Dietary struct {
+	Tags       []string `json:"tags"`
+	Allergens  []string `json:"allergens"`
+	SpiceLevel int      `json:"spice_level"`
+}
+
+// MenuFilter narrows the public menu down to items with all the tags, none
+// of the excluded allergens and at most the spice level, when given. Modifier
+// options and combo choices with excluded allergens are left out of the
+// items that can be ordered without them.
+type MenuFilter struct {
+	Tags             []string
+	ExcludeAllergens []string
+	MaxSpice         *int
+}
+
+func contains(ss []string, s string) bool {
+	for _, v := range ss {
+		if v == s {
+			return true
+		}
+	}
+
+	return false
+}
+
+// normalizeTags lower cases and dedupes the labels, rejecting those not in
+// the vocabulary.
+func normalizeTags(tags, vocabulary []string) ([]string, error) {
+	seen := make(map[string]bool, len(tags))
+	tt := make([]string, 0, len(tags))
+	for _, t := range tags {
+		t = strings.ToLower(strings.TrimSpace(t))
+		if !contains(vocabulary, t) {
+			return nil, ErrUnknownTag
+		}
+
+		if !seen[t] {
+			seen[t] = true
+			tt = append(tt, t)
+		}
+	}
+
+	sort.Strings(tt)
+	return tt, nil
+}
+
+func (f MenuFilter) matches(d Dietary) bool {
+	for _, t := range f.Tags {
+		if !contains(d.Tags, t) {
+			return false
+		}
+	}
+
+	if f.excludes(d.Allergens) {
+		return false
+	}
+
+	return f.MaxSpice == nil || d.SpiceLevel <= *f.MaxSpice
+}
+
+// excludes tells whether any of the allergens is excluded.
+func (f MenuFilter) excludes(allergens []string) bool {
+	for _, a := range f.ExcludeAllergens {
+		if contains(allergens, a) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// trimOptions drops the modifier options and combo choices of the item that
+// have excluded allergens, along with modifier groups left empty. It reports
+// false when the item can't be ordered without them: a group is left with
+// fewer options than it requires or a combo slot with no choices.
+func (f MenuFilter) trimOptions(i *MenuItem, tags map[int64]*Dietary) bool {
+	if len(f.ExcludeAllergens) == 0 {
+		return true
+	}
+
+	groups := make([]ModifierGroup, 0, len(i.Modifiers))
+	for _, g := range i.Modifiers {
+		oo := make([]ModifierOption, 0, len(g.Options))
+		for _, o := range g.Options {
+			if !f.excludes(o.Allergens) {
+				oo = append(oo, o)
+			}
+		}
+
+		if len(oo) < g.Min {
+			return false
+		}
+
+		if len(oo) == 0 {
+			continue
+		}
+
+		g.Options = oo
+		if g.Max > len(oo) {
+			g.Max = len(oo)
+		}
+		groups = append(groups, g)
+	}
+	i.Modifiers = groups
+
+	for j, s := range i.Combo {
+		cc := make([]ComboChoice, 0, len(s.Choices))
+		for _, c := range s.Choices {
+			if t, ok := tags[c.ItemId]; !ok || !f.excludes(t.Allergens) {
+				cc = append(cc, c)
+			}
+		}
+
+		if len(cc) == 0 {
+			return false
+		}
+		i.Combo[j].Choices = cc
+	}
+
+	return true
+}
+
+// itemTags loads the dietary tags and allergens of the restaurant's items.
+// Spice levels live on the item itself.
+func itemTags(ctx context.Context, q queryer, rid string) (map[int64]*Dietary, error) {
+	query := `
+		SELECT t.item_id, t.kind, t.tag
+		FROM item_tag t INNER JOIN item i ON t.item_id = i.id
+		WHERE i.restaurant_id = $1
+		ORDER BY t.item_id, t.tag`
+	rows, err := q.QueryContext(ctx, query, rid)
+	if err != nil {
+		return nil, fmt.Errorf("could not query item tags: %v", err)
+	}
+
+	defer rows.Close()
+	tags := make(map[int64]*Dietary)
+	for rows.Next() {
+		var iid int64
+		var kind, tag string
+		if err = rows.Scan(&iid, &kind, &tag); err != nil {
+			return nil, fmt.Errorf("could not scan item tag: %v", err)
+		}
+
+		d, ok := tags[iid]
+		if !ok {
+			d = &Dietary{Tags: make([]string, 0), Allergens: make([]string, 0)}
+			tags[iid] = d
+		}
+
+		if kind == tagKindAllergen {
+			d.Allergens = append(d.Allergens, tag)
+		} else {
+			d.Tags = append(d.Tags, tag)
+		}
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("could not iterate item tags: %v", err)
+	}
+
+	return tags, nil
+}
+
+// dietaryOf an item from the loaded tags and its spice level.
+func dietaryOf(tags map[int64]*Dietary, iid int64, spice int) Dietary {
+	d := Dietary{Tags: make([]string, 0), Allergens: make([]string, 0)}
+	if t, ok := tags[iid]; ok {
+		d = *t
+	}
+
+	d.SpiceLevel = spice
+	return d
+}
+
+// SetItemDietary replaces the dietary tags, allergens and spice level of a
+// menu item.
+func (s *Service) SetItemDietary(ctx context.Context, rid string, iid int64, d Dietary) error {
+	uid, auth := ctx.Value(KeyAuthFoodProviderID).(int64)
+	if !auth {
+		return ErrUnauthenticated
+	}
+
+	if !rxUUID.MatchString(rid) {
+		return ErrRestaurantNotFound
+	}
+
+	tags, err := normalizeTags(d.Tags, DietaryTags)
+	if err != nil {
+		return err
+	}
+
+	allergens, err := normalizeTags(d.Allergens, Allergens)
+	if err != nil {
+		return err
+	}
+
+	if d.SpiceLevel < SpiceNone || d.SpiceLevel > SpiceHot {
+		return ErrInvalidSpiceLevel
+	}
+
+	if _, err := s.checkPermission(ctx, Manager, uid, rid); err != nil {
+		return err
+	}
+
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("could not begin tx: %v", err)
+	}
+	defer func() { _ = tx.Rollback() }()
+
+	query := "UPDATE item SET spice_level = $1 WHERE id = $2 AND restaurant_id = $3 AND archived = false"
+	res, err := tx.ExecContext(ctx, query, d.SpiceLevel, iid, rid)
+	if err != nil {
+		return fmt.Errorf("could not update spice level: %v", err)
+	}
+
+	if n, _ := res.RowsAffected(); n == 0 {
+		return ErrItemNotFound
+	}
+
+	if _, err = tx.ExecContext(ctx, "DELETE FROM item_tag WHERE item_id = $1", iid); err != nil {
+		return fmt.Errorf("could not clear item tags: %v", err)
+	}
+
+	query = "INSERT INTO item_tag (item_id, kind, tag) VALUES ($1, $2, $3)"
+	for kind, tt := range map[string][]string{tagKindDiet: tags, tagKindAllergen: allergens} {
+		for _, t := range tt {
+			if _, err = tx.ExecContext(ctx, query, iid, kind, t); err != nil {
+				return fmt.Errorf("could not add item tag: %v", err)
+			}
+		}
+	}
+
+	if err = tx.Commit(); err != nil {
+		return fmt.Errorf("could not update item dietary information: could not commit transaction: %v", err)
+	}
+
+	return nil
+}
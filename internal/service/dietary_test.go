@@ -0,0 +1,120 @@
+package service
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestNormalizeTags(t *testing.T) {
+	var tt = []struct {
+		Label string
+		Tags  []string
+		Want  []string
+		Err   error
+	}{
+		{Label: "Test should lower case, trim and sort tags", Tags: []string{" Vegan", "HALAL"}, Want: []string{"halal", "vegan"}},
+		{Label: "Test should drop duplicates", Tags: []string{"vegan", "Vegan"}, Want: []string{"vegan"}},
+		{Label: "Test should accept no tags", Tags: nil, Want: []string{}},
+		{Label: "Test should reject an unknown tag", Tags: []string{"vegan", "keto"}, Err: ErrUnknownTag},
+	}
+
+	for _, test := range tt {
+		t.Run(test.Label, func(t *testing.T) {
+			tags, err := normalizeTags(test.Tags, DietaryTags)
+			if err != test.Err {
+				t.Error("Got:", err, "| Want:", test.Err)
+			}
+			if err == nil && !cmp.Equal(tags, test.Want) {
+				t.Error("Got:", tags, "| Want:", test.Want)
+			}
+		})
+	}
+}
+
+func TestMenuFilterMatches(t *testing.T) {
+	mild := SpiceMild
+	d := Dietary{Tags: []string{"halal", "vegetarian"}, Allergens: []string{"dairy"}, SpiceLevel: SpiceMedium}
+
+	var tt = []struct {
+		Label  string
+		Filter MenuFilter
+		Want   bool
+	}{
+		{Label: "Test should match without filters", Filter: MenuFilter{}, Want: true},
+		{Label: "Test should match all the tags", Filter: MenuFilter{Tags: []string{"halal", "vegetarian"}}, Want: true},
+		{Label: "Test should not match a missing tag", Filter: MenuFilter{Tags: []string{"halal", "vegan"}}, Want: false},
+		{Label: "Test should exclude an allergen", Filter: MenuFilter{ExcludeAllergens: []string{"dairy"}}, Want: false},
+		{Label: "Test should keep items free of the allergens", Filter: MenuFilter{ExcludeAllergens: []string{"nuts"}}, Want: true},
+		{Label: "Test should exclude spicier items", Filter: MenuFilter{MaxSpice: &mild}, Want: false},
+	}
+
+	for _, test := range tt {
+		t.Run(test.Label, func(t *testing.T) {
+			if got := test.Filter.matches(d); got != test.Want {
+				t.Error("Got:", got, "| Want:", test.Want)
+			}
+		})
+	}
+}
+
+func TestMenuFilterTrimOptions(t *testing.T) {
+	tags := map[int64]*Dietary{2: {Allergens: []string{"dairy"}}, 3: {Allergens: []string{"nuts"}}}
+	item := func(min int) MenuItem {
+		return MenuItem{
+			Modifiers: []ModifierGroup{{Id: 1, Name: "Sauce", Min: min, Max: 2, Options: []ModifierOption{
+				{Id: 1, Name: "Mayo", Allergens: []string{"egg"}},
+				{Id: 2, Name: "Chilli", Allergens: []string{}},
+			}}},
+			Combo: []ComboSlot{
+				{Id: 1, Name: "Main", Choices: []ComboChoice{{ItemId: 1}}},
+				{Id: 2, Name: "Drink", Choices: []ComboChoice{{ItemId: 2}, {ItemId: 4}}},
+			},
+		}
+	}
+
+	var tt = []struct {
+		Label       string
+		Filter      MenuFilter
+		Min         int
+		Want        bool
+		WantOptions int
+		WantDrinks  int
+	}{
+		{Label: "Test should keep everything without excluded allergens", Filter: MenuFilter{}, Want: true, WantOptions: 2, WantDrinks: 2},
+		{Label: "Test should drop options with an excluded allergen", Filter: MenuFilter{ExcludeAllergens: []string{"egg"}},
+			Want: true, WantOptions: 1, WantDrinks: 2},
+		{Label: "Test should drop combo choices with an excluded allergen", Filter: MenuFilter{ExcludeAllergens: []string{"dairy"}},
+			Want: true, WantOptions: 2, WantDrinks: 1},
+		{Label: "Test should hide items missing required options", Filter: MenuFilter{ExcludeAllergens: []string{"egg"}},
+			Min: 2},
+	}
+
+	for _, test := range tt {
+		t.Run(test.Label, func(t *testing.T) {
+			i := item(test.Min)
+			got := test.Filter.trimOptions(&i, tags)
+			if got != test.Want {
+				t.Fatal("Got:", got, "| Want:", test.Want)
+			}
+
+			if !got {
+				return
+			}
+
+			if n := len(i.Modifiers[0].Options); n != test.WantOptions {
+				t.Error("Got:", n, "options | Want:", test.WantOptions)
+			}
+
+			if n := len(i.Combo[1].Choices); n != test.WantDrinks {
+				t.Error("Got:", n, "drinks | Want:", test.WantDrinks)
+			}
+		})
+	}
+
+	i := item(0)
+	i.Combo[0].Choices[0].ItemId = 3
+	if (MenuFilter{ExcludeAllergens: []string{"nuts"}}).trimOptions(&i, tags) {
+		t.Error("Got a combo whose fixed component has an excluded allergen")
+	}
+}
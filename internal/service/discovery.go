@@ -0,0 +1,423 @@
+package service
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/lib/pq"
+)
+
+// Orders of the restaurant listing.
+const (
+	SortByRating  = "rating"
+	SortByReviews = "reviews"
+	SortByNewest  = "newest"
+	SortByTitle   = "title"
+)
+
+// maxListingScans is how many pages of the listing are looked through for
+// restaurants open now before returning a short page.
+const maxListingScans = 5
+
+var (
+	// ErrInvalidSort denotes a restaurant listing order other than rating, reviews, newest or title.
+	ErrInvalidSort = errors.New("invalid sort")
+	// ErrInvalidCursor denotes a malformed listing cursor or one of a listing in another order.
+	ErrInvalidCursor = errors.New("invalid cursor")
+	// ErrInvalidRating denotes a rating outside 0 to 5.
+	ErrInvalidRating = errors.New("invalid rating")
+)
+
+// Cuisines restaurants can be labelled with.
+var Cuisines = []string{"bangladeshi", "indian", "chinese", "thai", "italian", "american", "mexican", "japanese",
+	"korean", "arabic", "turkish", "fast_food", "bakery", "cafe", "desserts", "seafood", "bbq"}
+
+// RestaurantFilter narrows the listing of restaurants down to those in the
+// city and area, open now, rated at least MinRating and serving any of the
+// cuisines, when given. After is the Next cursor of the previous page.
+type RestaurantFilter struct {
+	City      string
+	Area      string
+	OpenNow   bool
+	MinRating float64
+	Cuisines  []string
+	Sort      string
+	After     string
+	First     int
+}
+
+// RestaurantSummary is a restaurant as listed to customers.
+type RestaurantSummary struct {
+	Id        string    `json:"id"`
+	Title     string    `json:"title"`
+	About     string    `json:"about"`
+	Avatar    string    `json:"avatar,omitempty"`
+	Cover     string    `json:"cover,omitempty"`
+	City      string    `json:"city"`
+	Area      string    `json:"area"`
+	Rating    float64   `json:"rating"`
+	Reviews   int       `json:"review_count"`
+	Cuisines  []string  `json:"cuisines"`
+	OpenNow   bool      `json:"open_now"`
+	Distance  *float64  `json:"distance_km,omitempty"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// RestaurantPage of the listing. Next is empty on the last page.
+type RestaurantPage struct {
+	Restaurants []RestaurantSummary `json:"restaurants"`
+	Next        string              `json:"next,omitempty"`
+}
+
+// RestaurantProfile is a restaurant's page for customers.
+type RestaurantProfile struct {
+	Id       string          `json:"id"`
+	Title    string          `json:"title"`
+	About    string          `json:"about"`
+	Avatar   string          `json:"avatar,omitempty"`
+	Cover    string          `json:"cover,omitempty"`
+	Location string          `json:"location"`
+	City     string          `json:"city"`
+	Area     string          `json:"area"`
+	Country  string          `json:"country"`
+	Phone    string          `json:"phone"`
+	Position *Coordinates    `json:"coordinates,omitempty"`
+	Rating   float64         `json:"rating"`
+	Reviews  int             `json:"review_count"`
+	Cuisines []string        `json:"cuisines"`
+	Gallery  []string        `json:"gallery"`
+	Offers   []string        `json:"offers"`
+	Hours    RestaurantHours `json:"hours"`
+}
+
+// listingCursor is where a page of the listing ended: the sort key of its
+// last restaurant, with the id breaking ties.
+type listingCursor struct {
+	Sort      string     `json:"s"`
+	Id        string     `json:"id"`
+	Rating    float64    `json:"r,omitempty"`
+	Reviews   int        `json:"n,omitempty"`
+	CreatedAt *time.Time `json:"c,omitempty"`
+	Title     string     `json:"t,omitempty"`
+}
+
+func cursorOf(sort string, r RestaurantSummary) listingCursor {
+	c := listingCursor{Sort: sort, Id: r.Id}
+	switch sort {
+	case SortByRating:
+		c.Rating = r.Rating
+	case SortByReviews:
+		c.Reviews = r.Reviews
+	case SortByNewest:
+		c.CreatedAt = &r.CreatedAt
+	case SortByTitle:
+		c.Title = r.Title
+	}
+
+	return c
+}
+
+func (c listingCursor) String() string {
+	b, _ := json.Marshal(c)
+	return base64.RawURLEncoding.EncodeToString(b)
+}
+
+// parseCursor of a listing in the given order.
+func parseCursor(s, sort string) (listingCursor, error) {
+	var c listingCursor
+	b, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil {
+		return c, ErrInvalidCursor
+	}
+
+	if err = json.Unmarshal(b, &c); err != nil || c.Sort != sort || !rxUUID.MatchString(c.Id) {
+		return c, ErrInvalidCursor
+	}
+
+	return c, nil
+}
+
+// normalize trims the filter, defaulting to the best rated restaurants
+// first, and checks it.
+func (f *RestaurantFilter) normalize() error {
+	f.City = strings.TrimSpace(f.City)
+	f.Area = strings.TrimSpace(f.Area)
+	f.First = normalizePageSize(f.First)
+	if f.Sort == "" {
+		f.Sort = SortByRating
+	}
+
+	if f.Sort != SortByRating && f.Sort != SortByReviews && f.Sort != SortByNewest && f.Sort != SortByTitle {
+		return ErrInvalidSort
+	}
+
+	if f.MinRating < 0 || f.MinRating > 5 {
+		return ErrInvalidRating
+	}
+
+	cuisines, err := normalizeTags(f.Cuisines, Cuisines)
+	if err != nil {
+		return err
+	}
+
+	f.Cuisines = cuisines
+	return nil
+}
+
+// restaurantCuisines of the restaurants, by id.
+func restaurantCuisines(ctx context.Context, q queryer, ids []string) (map[string][]string, error) {
+	cc := make(map[string][]string, len(ids))
+	if len(ids) == 0 {
+		return cc, nil
+	}
+
+	query := "SELECT restaurant_id, cuisine FROM restaurant_cuisine WHERE restaurant_id = ANY($1::UUID[]) ORDER BY cuisine"
+	rows, err := q.QueryContext(ctx, query, pq.Array(ids))
+	if err != nil {
+		return nil, fmt.Errorf("could not query restaurant cuisines: %v", err)
+	}
+
+	defer rows.Close()
+	for rows.Next() {
+		var rid, cuisine string
+		if err = rows.Scan(&rid, &cuisine); err != nil {
+			return nil, fmt.Errorf("could not scan restaurant cuisine: %v", err)
+		}
+
+		cc[rid] = append(cc[rid], cuisine)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("could not iterate restaurant cuisines: %v", err)
+	}
+
+	return cc, nil
+}
+
+// listRestaurants after the cursor, at most first of them, with their
+// cuisines and whether they're open now.
+func (s *Service) listRestaurants(ctx context.Context, f RestaurantFilter, after *listingCursor, first int) ([]RestaurantSummary, error) {
+	data := map[string]interface{}{
+		"locale":       localeOf(ctx),
+		"city":         f.City,
+		"area":         f.Area,
+		"min_rating":   f.MinRating,
+		"sort":         f.Sort,
+		"first":        first,
+		"cuisines":     pq.Array(f.Cuisines),
+		"has_cuisines": len(f.Cuisines) != 0,
+		"after":        after != nil,
+	}
+	if after != nil {
+		data["cid"] = after.Id
+		data["crating"] = after.Rating
+		data["creviews"] = after.Reviews
+		data["ccreated"] = after.CreatedAt
+		data["ctitle"] = after.Title
+	}
+
+	query, args, err := buildQuery(`
+		SELECT r.id, r.title, COALESCE(NULLIF(t.about, ''), r.about, ''), COALESCE(r.avatar, ''),
+			COALESCE(r.cover, ''), r.city, r.area, r.rating, r.review_count, r.created_at
+		FROM restaurant r LEFT JOIN restaurant_translation t ON r.id = t.restaurant_id AND t.locale = @locale
+		WHERE r.active = true
+		{{if .city}}
+		AND lower(r.city) = lower(@city)
+		{{end}}
+		{{if .area}}
+		AND lower(r.area) = lower(@area)
+		{{end}}
+		{{if .min_rating}}
+		AND r.rating >= @min_rating
+		{{end}}
+		{{if .has_cuisines}}
+		AND EXISTS (SELECT 1 FROM restaurant_cuisine c WHERE c.restaurant_id = r.id AND c.cuisine = ANY(@cuisines::STRING[]))
+		{{end}}
+		{{if .after}}
+			{{if eq .sort "rating"}}
+			AND (r.rating < @crating OR (r.rating = @crating AND r.id > @cid))
+			{{else if eq .sort "reviews"}}
+			AND (r.review_count < @creviews OR (r.review_count = @creviews AND r.id > @cid))
+			{{else if eq .sort "newest"}}
+			AND (r.created_at < @ccreated OR (r.created_at = @ccreated AND r.id > @cid))
+			{{else}}
+			AND r.title > @ctitle
+			{{end}}
+		{{end}}
+		{{if eq .sort "rating"}}
+		ORDER BY r.rating DESC, r.id
+		{{else if eq .sort "reviews"}}
+		ORDER BY r.review_count DESC, r.id
+		{{else if eq .sort "newest"}}
+		ORDER BY r.created_at DESC, r.id
+		{{else}}
+		ORDER BY r.title
+		{{end}}
+		LIMIT @first`, data)
+	if err != nil {
+		return nil, fmt.Errorf("could not build restaurants sql query: %v", err)
+	}
+
+	rows, err := s.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("could not query restaurants: %v", err)
+	}
+
+	defer rows.Close()
+	rr := make([]RestaurantSummary, 0, first)
+	for rows.Next() {
+		var r RestaurantSummary
+		if err = rows.Scan(&r.Id, &r.Title, &r.About, &r.Avatar, &r.Cover, &r.City, &r.Area, &r.Rating, &r.Reviews,
+			&r.CreatedAt); err != nil {
+			return nil, fmt.Errorf("could not scan restaurant: %v", err)
+		}
+
+		rr = append(rr, r)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("could not iterate restaurants: %v", err)
+	}
+
+	if err = s.completeRestaurants(ctx, rr); err != nil {
+		return nil, err
+	}
+
+	return rr, nil
+}
+
+// completeRestaurants of a listing with their cuisines, whether they're open
+// now and the URLs of their pictures.
+func (s *Service) completeRestaurants(ctx context.Context, rr []RestaurantSummary) error {
+	ids := make([]string, 0, len(rr))
+	for _, r := range rr {
+		ids = append(ids, r.Id)
+	}
+
+	cc, err := restaurantCuisines(ctx, s.db, ids)
+	if err != nil {
+		return err
+	}
+
+	hh, err := restaurantsHours(ctx, s.db, ids)
+	if err != nil {
+		return err
+	}
+
+	for i := range rr {
+		r := &rr[i]
+		r.OpenNow = hh[r.Id].OpenNow
+		r.Cuisines = cc[r.Id]
+		if r.Cuisines == nil {
+			r.Cuisines = make([]string, 0)
+		}
+		if r.Avatar != "" {
+			r.Avatar = s.restaurantPictureURL(r.Id, r.Avatar)
+		}
+		if r.Cover != "" {
+			r.Cover = s.restaurantPictureURL(r.Id, r.Cover)
+		}
+	}
+
+	return nil
+}
+
+// GetRestaurants lists the active restaurants to customers, a page at a
+// time. Restaurants closed now are skipped while looking for open ones, at
+// most maxListingScans pages of them, so a page can come back short or
+// empty with a Next cursor to carry on from.
+func (s *Service) GetRestaurants(ctx context.Context, f RestaurantFilter) (RestaurantPage, error) {
+	p := RestaurantPage{Restaurants: make([]RestaurantSummary, 0)}
+	if err := f.normalize(); err != nil {
+		return p, err
+	}
+
+	var after *listingCursor
+	if f.After != "" {
+		c, err := parseCursor(f.After, f.Sort)
+		if err != nil {
+			return p, err
+		}
+
+		after = &c
+	}
+
+	for scans := 1; ; scans++ {
+		rr, err := s.listRestaurants(ctx, f, after, f.First)
+		if err != nil {
+			return p, err
+		}
+
+		for _, r := range rr {
+			c := cursorOf(f.Sort, r)
+			after = &c
+			if f.OpenNow && !r.OpenNow {
+				continue
+			}
+
+			p.Restaurants = append(p.Restaurants, r)
+			if len(p.Restaurants) == f.First {
+				p.Next = c.String()
+				return p, nil
+			}
+		}
+
+		if len(rr) < f.First {
+			return p, nil
+		}
+
+		if scans == maxListingScans {
+			p.Next = after.String()
+			return p, nil
+		}
+	}
+}
+
+// SetRestaurantCuisines replaces the cuisines the restaurant is listed under.
+func (s *Service) SetRestaurantCuisines(ctx context.Context, rid string, cuisines []string) error {
+	uid, auth := ctx.Value(KeyAuthFoodProviderID).(int64)
+	if !auth {
+		return ErrUnauthenticated
+	}
+
+	if !rxUUID.MatchString(rid) {
+		return ErrRestaurantNotFound
+	}
+
+	cuisines, err := normalizeTags(cuisines, Cuisines)
+	if err != nil {
+		return err
+	}
+
+	if _, err := s.checkPermission(ctx, Manager, uid, rid); err != nil {
+		return err
+	}
+
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("could not begin tx: %v", err)
+	}
+	defer func() { _ = tx.Rollback() }()
+
+	if _, err = tx.ExecContext(ctx, "DELETE FROM restaurant_cuisine WHERE restaurant_id = $1", rid); err != nil {
+		return fmt.Errorf("could not clear restaurant cuisines: %v", err)
+	}
+
+	query := "INSERT INTO restaurant_cuisine (restaurant_id, cuisine) VALUES ($1, $2)"
+	for _, c := range cuisines {
+		if _, err = tx.ExecContext(ctx, query, rid, c); err != nil {
+			return fmt.Errorf("could not insert restaurant cuisine: %v", err)
+		}
+	}
+
+	if err = tx.Commit(); err != nil {
+		return fmt.Errorf("could not set restaurant cuisines: could not commit transaction: %v", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,79 @@
+package service
+
+import (
+	"testing"
+	"time"
+)
+
+func TestListingCursor(t *testing.T) {
+	created := time.Date(2024, 3, 1, 12, 30, 0, 0, time.UTC)
+	r := RestaurantSummary{Id: "5b4c8a55-0ac4-4f5d-a7c2-3c1d2fd0e6a1", Title: "White Canary", Rating: 4.5, Reviews: 12,
+		CreatedAt: created}
+
+	for _, sort := range []string{SortByRating, SortByReviews, SortByNewest, SortByTitle} {
+		t.Run("Test should read back a cursor by "+sort, func(t *testing.T) {
+			c, err := parseCursor(cursorOf(sort, r).String(), sort)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			want := cursorOf(sort, r)
+			if c.Id != want.Id || c.Rating != want.Rating || c.Reviews != want.Reviews || c.Title != want.Title ||
+				(want.CreatedAt != nil && (c.CreatedAt == nil || !c.CreatedAt.Equal(created))) {
+				t.Errorf("Got: %+v | Want: %+v", c, want)
+			}
+		})
+	}
+
+	var tt = []struct {
+		Label  string
+		Cursor string
+		Sort   string
+	}{
+		{Label: "Test should reject a malformed cursor", Cursor: "not a cursor!", Sort: SortByRating},
+		{Label: "Test should reject a cursor of another order", Cursor: cursorOf(SortByTitle, r).String(), Sort: SortByRating},
+		{Label: "Test should reject a cursor without an id", Cursor: cursorOf(SortByRating, RestaurantSummary{}).String(),
+			Sort: SortByRating},
+	}
+
+	for _, test := range tt {
+		t.Run(test.Label, func(t *testing.T) {
+			if _, err := parseCursor(test.Cursor, test.Sort); err != ErrInvalidCursor {
+				t.Error("Got:", err, "| Want:", ErrInvalidCursor)
+			}
+		})
+	}
+}
+
+func TestRestaurantFilterNormalize(t *testing.T) {
+	var tt = []struct {
+		Label  string
+		Filter RestaurantFilter
+		Want   error
+	}{
+		{Label: "Test should accept an empty filter", Filter: RestaurantFilter{}},
+		{Label: "Test should accept a filter", Filter: RestaurantFilter{City: "Dhaka", MinRating: 4, Cuisines: []string{"Thai"},
+			Sort: SortByNewest}},
+		{Label: "Test should reject an unknown order", Filter: RestaurantFilter{Sort: "distance"}, Want: ErrInvalidSort},
+		{Label: "Test should reject a rating above 5", Filter: RestaurantFilter{MinRating: 5.5}, Want: ErrInvalidRating},
+		{Label: "Test should reject an unknown cuisine", Filter: RestaurantFilter{Cuisines: []string{"martian"}},
+			Want: ErrUnknownTag},
+	}
+
+	for _, test := range tt {
+		t.Run(test.Label, func(t *testing.T) {
+			if err := test.Filter.normalize(); err != test.Want {
+				t.Error("Got:", err, "| Want:", test.Want)
+			}
+		})
+	}
+
+	f := RestaurantFilter{City: " Dhaka ", Cuisines: []string{"Thai", "thai", "cafe"}}
+	if err := f.normalize(); err != nil {
+		t.Fatal(err)
+	}
+
+	if f.City != "Dhaka" || f.Sort != SortByRating || f.First != defaultPageSize || len(f.Cuisines) != 2 {
+		t.Errorf("Got: %+v | Want a trimmed filter by rating", f)
+	}
+}
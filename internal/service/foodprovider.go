@@ -46,7 +46,7 @@ func (s *Service) CreateFoodProvider(ctx context.Context, email, fullname, phone
 		return ErrInvalidFullname
 	}
 
-	phone = strings.TrimSpace(phone)
+	phone = normalizePhone(phone)
 	if !rxPhone.MatchString(phone) {
 		return ErrInvalidPhone
 	}
@@ -96,7 +96,7 @@ func (s *Service) CreateRole(ctx context.Context, rid, fullname, phone, password
 		return ErrInvalidFullname
 	}
 
-	phone = strings.TrimSpace(phone)
+	phone = normalizePhone(phone)
 	if !rxPhone.MatchString(phone) {
 		return ErrInvalidPhone
 	}
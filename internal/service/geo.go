@@ -0,0 +1,183 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"sort"
+)
+
+const (
+	earthRadiusKm   = 6371.0
+	defaultRadiusKm = 5
+	maxRadiusKm     = 50
+)
+
+var (
+	// ErrInvalidCoordinates denotes a latitude outside -90 to 90 or a longitude outside -180 to 180.
+	ErrInvalidCoordinates = errors.New("invalid coordinates")
+	// ErrInvalidRadius denotes a nearby search radius outside 0 to 50 km.
+	ErrInvalidRadius = errors.New("invalid radius")
+)
+
+// Coordinates of a place in decimal degrees.
+type Coordinates struct {
+	Latitude  float64 `json:"latitude"`
+	Longitude float64 `json:"longitude"`
+}
+
+// NearbyQuery looks for restaurants within RadiusKm of the coordinates, 5
+// km when left out.
+type NearbyQuery struct {
+	Coordinates
+	RadiusKm float64
+	Limit    int
+}
+
+func (c Coordinates) validate() error {
+	if math.IsNaN(c.Latitude) || math.IsNaN(c.Longitude) || c.Latitude < -90 || c.Latitude > 90 ||
+		c.Longitude < -180 || c.Longitude > 180 {
+		return ErrInvalidCoordinates
+	}
+
+	return nil
+}
+
+// distanceKm between two places along the earth's surface, by the haversine
+// formula.
+func distanceKm(a, b Coordinates) float64 {
+	rad := func(deg float64) float64 { return deg * math.Pi / 180 }
+	dLat := rad(b.Latitude - a.Latitude)
+	dLng := rad(b.Longitude - a.Longitude)
+	h := math.Sin(dLat/2)*math.Sin(dLat/2) +
+		math.Cos(rad(a.Latitude))*math.Cos(rad(b.Latitude))*math.Sin(dLng/2)*math.Sin(dLng/2)
+	return 2 * earthRadiusKm * math.Asin(math.Min(1, math.Sqrt(h)))
+}
+
+// boundingBox around the coordinates holding every place within radiusKm,
+// to narrow a search down before measuring distances. Its longitudes are
+// left open, as false, near the poles and across the antimeridian.
+func boundingBox(c Coordinates, radiusKm float64) (lo, hi Coordinates, lngBound bool) {
+	dLat := radiusKm / earthRadiusKm * 180 / math.Pi
+	lo.Latitude, hi.Latitude = math.Max(c.Latitude-dLat, -90), math.Min(c.Latitude+dLat, 90)
+	if lo.Latitude == -90 || hi.Latitude == 90 {
+		return lo, hi, false
+	}
+
+	dLng := dLat / math.Cos(c.Latitude*math.Pi/180)
+	lo.Longitude, hi.Longitude = c.Longitude-dLng, c.Longitude+dLng
+	if lo.Longitude < -180 || hi.Longitude > 180 {
+		return lo, hi, false
+	}
+
+	return lo, hi, true
+}
+
+// SetRestaurantCoordinates of a restaurant, used to find it nearby.
+func (s *Service) SetRestaurantCoordinates(ctx context.Context, rid string, c Coordinates) error {
+	uid, auth := ctx.Value(KeyAuthFoodProviderID).(int64)
+	if !auth {
+		return ErrUnauthenticated
+	}
+
+	if !rxUUID.MatchString(rid) {
+		return ErrRestaurantNotFound
+	}
+
+	if err := c.validate(); err != nil {
+		return err
+	}
+
+	if _, err := s.checkPermission(ctx, Manager, uid, rid); err != nil {
+		return err
+	}
+
+	query := "UPDATE restaurant SET latitude = $1, longitude = $2 WHERE id = $3"
+	if _, err := s.db.ExecContext(ctx, query, c.Latitude, c.Longitude, rid); err != nil {
+		return fmt.Errorf("could not update restaurant coordinates: %v", err)
+	}
+
+	return nil
+}
+
+// NearbyRestaurants lists the active restaurants within the radius of the
+// customer, nearest first, with how far they are.
+func (s *Service) NearbyRestaurants(ctx context.Context, q NearbyQuery) ([]RestaurantSummary, error) {
+	if err := q.validate(); err != nil {
+		return nil, err
+	}
+
+	if q.RadiusKm == 0 {
+		q.RadiusKm = defaultRadiusKm
+	}
+
+	if q.RadiusKm < 0 || q.RadiusKm > maxRadiusKm || math.IsNaN(q.RadiusKm) {
+		return nil, ErrInvalidRadius
+	}
+
+	q.Limit = normalizePageSize(q.Limit)
+	lo, hi, lngBound := boundingBox(q.Coordinates, q.RadiusKm)
+	query, args, err := buildQuery(`
+		SELECT r.id, r.title, COALESCE(NULLIF(t.about, ''), r.about, ''), COALESCE(r.avatar, ''),
+			COALESCE(r.cover, ''), r.city, r.area, r.rating, r.review_count, r.created_at, r.latitude, r.longitude
+		FROM restaurant r LEFT JOIN restaurant_translation t ON r.id = t.restaurant_id AND t.locale = @locale
+		WHERE r.active = true AND r.latitude BETWEEN @min_lat AND @max_lat
+		{{if .lng_bound}}
+		AND r.longitude BETWEEN @min_lng AND @max_lng
+		{{else}}
+		AND r.longitude IS NOT NULL
+		{{end}}`, map[string]interface{}{
+		"locale":    localeOf(ctx),
+		"min_lat":   lo.Latitude,
+		"max_lat":   hi.Latitude,
+		"min_lng":   lo.Longitude,
+		"max_lng":   hi.Longitude,
+		"lng_bound": lngBound,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("could not build nearby restaurants sql query: %v", err)
+	}
+
+	rows, err := s.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("could not query nearby restaurants: %v", err)
+	}
+
+	defer rows.Close()
+	rr := make([]RestaurantSummary, 0)
+	for rows.Next() {
+		var r RestaurantSummary
+		var c Coordinates
+		if err = rows.Scan(&r.Id, &r.Title, &r.About, &r.Avatar, &r.Cover, &r.City, &r.Area, &r.Rating, &r.Reviews,
+			&r.CreatedAt, &c.Latitude, &c.Longitude); err != nil {
+			return nil, fmt.Errorf("could not scan nearby restaurant: %v", err)
+		}
+
+		d := distanceKm(q.Coordinates, c)
+		if d > q.RadiusKm {
+			continue
+		}
+
+		d = math.Round(d*1000) / 1000
+		r.Distance = &d
+		rr = append(rr, r)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("could not iterate nearby restaurants: %v", err)
+	}
+
+	sort.SliceStable(rr, func(i, j int) bool {
+		return *rr[i].Distance < *rr[j].Distance
+	})
+	if len(rr) > q.Limit {
+		rr = rr[:q.Limit]
+	}
+
+	if err = s.completeRestaurants(ctx, rr); err != nil {
+		return nil, err
+	}
+
+	return rr, nil
+}
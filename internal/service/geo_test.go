@@ -0,0 +1,85 @@
+package service
+
+import (
+	"math"
+	"testing"
+)
+
+func TestDistanceKm(t *testing.T) {
+	gulshan := Coordinates{Latitude: 23.7925, Longitude: 90.4078}
+	var tt = []struct {
+		Label string
+		From  Coordinates
+		To    Coordinates
+		Want  float64
+	}{
+		{Label: "Test should measure no distance to the same place", From: gulshan, To: gulshan},
+		{Label: "Test should measure a city distance", From: gulshan, To: Coordinates{Latitude: 23.7461, Longitude: 90.3742},
+			Want: 6.13},
+		{Label: "Test should measure a country distance", From: gulshan, To: Coordinates{Latitude: 22.3569, Longitude: 91.7832},
+			Want: 213.9},
+		{Label: "Test should measure across the antimeridian", From: Coordinates{Longitude: 179.9},
+			To: Coordinates{Longitude: -179.9}, Want: 22.24},
+	}
+
+	for _, test := range tt {
+		t.Run(test.Label, func(t *testing.T) {
+			if got := distanceKm(test.From, test.To); math.Abs(got-test.Want) > test.Want*0.01+0.001 {
+				t.Error("Got:", got, "| Want:", test.Want)
+			}
+		})
+	}
+}
+
+func TestBoundingBox(t *testing.T) {
+	c := Coordinates{Latitude: 23.7925, Longitude: 90.4078}
+	lo, hi, lngBound := boundingBox(c, 5)
+	if !lngBound {
+		t.Fatal("Got the longitudes left open | Want them bound")
+	}
+
+	for _, p := range []Coordinates{
+		{Latitude: c.Latitude + 0.0449, Longitude: c.Longitude},
+		{Latitude: c.Latitude, Longitude: c.Longitude - 0.049},
+	} {
+		if d := distanceKm(c, p); d > 5 {
+			t.Fatal("Got:", d, "| Want a place within 5 km")
+		}
+
+		if p.Latitude < lo.Latitude || p.Latitude > hi.Latitude || p.Longitude < lo.Longitude || p.Longitude > hi.Longitude {
+			t.Errorf("Got %+v outside %+v - %+v | Want it in the box", p, lo, hi)
+		}
+	}
+
+	if _, _, lngBound = boundingBox(Coordinates{Latitude: 89.99}, 5); lngBound {
+		t.Error("Got the longitudes bound near the pole | Want them left open")
+	}
+
+	if _, _, lngBound = boundingBox(Coordinates{Longitude: 179.99}, 5); lngBound {
+		t.Error("Got the longitudes bound across the antimeridian | Want them left open")
+	}
+}
+
+func TestCoordinatesValidate(t *testing.T) {
+	var tt = []struct {
+		Label       string
+		Coordinates Coordinates
+		Want        error
+	}{
+		{Label: "Test should accept coordinates", Coordinates: Coordinates{Latitude: 23.7925, Longitude: 90.4078}},
+		{Label: "Test should accept the edges", Coordinates: Coordinates{Latitude: -90, Longitude: 180}},
+		{Label: "Test should reject a latitude past the pole", Coordinates: Coordinates{Latitude: 90.1},
+			Want: ErrInvalidCoordinates},
+		{Label: "Test should reject a longitude out of range", Coordinates: Coordinates{Longitude: -180.5},
+			Want: ErrInvalidCoordinates},
+		{Label: "Test should reject NaN", Coordinates: Coordinates{Latitude: math.NaN()}, Want: ErrInvalidCoordinates},
+	}
+
+	for _, test := range tt {
+		t.Run(test.Label, func(t *testing.T) {
+			if err := test.Coordinates.validate(); err != test.Want {
+				t.Error("Got:", err, "| Want:", test.Want)
+			}
+		})
+	}
+}
@@ -0,0 +1,445 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/lib/pq"
+)
+
+const maxPause = time.Hour * 24 * 7
+
+var (
+	// ErrInvalidHours denotes opening hours with an unknown day or malformed or empty times.
+	ErrInvalidHours = errors.New("invalid opening hours")
+	// ErrInvalidTimeZone denotes a time zone name outside the IANA database.
+	ErrInvalidTimeZone = errors.New("invalid time zone")
+	// ErrHoursExceptionNotFound denotes a not found holiday or special hours date.
+	ErrHoursExceptionNotFound = errors.New("hours exception not found")
+	// ErrInvalidPause denotes a pause of ordering longer than a week.
+	ErrInvalidPause = errors.New("invalid pause")
+	// ErrRestaurantClosed denotes an order placed outside opening hours or while ordering is paused.
+	ErrRestaurantClosed = errors.New("restaurant is closed")
+)
+
+// OpeningHours is one of a restaurant's weekly opening ranges. A day can
+// have several. A close time before the open time runs past midnight into
+// the next day, and 00:00 to 24:00 is open all day.
+type OpeningHours struct {
+	Day   time.Weekday `json:"day"`
+	Open  string       `json:"open"`
+	Close string       `json:"close"`
+}
+
+// HoursException replaces the weekly hours on a date, for a holiday or an
+// event. Without times the restaurant is closed all day.
+type HoursException struct {
+	Date  string `json:"date"`
+	Open  string `json:"open,omitempty"`
+	Close string `json:"close,omitempty"`
+	Note  string `json:"note"`
+}
+
+// RestaurantHours is when a restaurant is open, in its time zone, and
+// whether it takes orders right now. A restaurant without weekly hours is
+// open every day.
+type RestaurantHours struct {
+	TimeZone        string           `json:"timezone"`
+	Weekly          []OpeningHours   `json:"weekly"`
+	Exceptions      []HoursException `json:"exceptions"`
+	Paused          bool             `json:"paused"`
+	PausedUntil     *time.Time       `json:"paused_until,omitempty"`
+	OpenNow         bool             `json:"open_now"`
+	AcceptingOrders bool             `json:"accepting_orders"`
+}
+
+// validTimes tells whether open and close are distinct H:MM or HH:MM times.
+// Close can be 24:00 for a range running to the end of the day.
+func validTimes(open, close string) bool {
+	o, ok := clockMinutes(open)
+	c, closeOk := clockMinutes(close)
+	return ok && closeOk && o != 24*60 && o != c
+}
+
+// minutes of the range since midnight.
+func (h OpeningHours) minutes() (int, int) {
+	open, _ := clockMinutes(h.Open)
+	close, _ := clockMinutes(h.Close)
+	return open, close
+}
+
+func (h OpeningHours) validate() error {
+	if h.Day < time.Sunday || h.Day > time.Saturday || !validTimes(h.Open, h.Close) {
+		return ErrInvalidHours
+	}
+
+	return nil
+}
+
+func (e HoursException) validate() error {
+	if _, err := time.Parse(scheduleDateLayout, e.Date); err != nil {
+		return ErrInvalidHours
+	}
+
+	if (e.Open != "" || e.Close != "") && !validTimes(e.Open, e.Close) {
+		return ErrInvalidHours
+	}
+
+	if len([]rune(e.Note)) > 50 {
+		return ErrInvalidHours
+	}
+
+	return nil
+}
+
+// dayRanges of the restaurant on a date: its exception's, if it has one,
+// or else the weekly ones. A day without weekly hours is open all day.
+func (h RestaurantHours) dayRanges(date time.Time) []OpeningHours {
+	for _, e := range h.Exceptions {
+		if e.Date == date.Format(scheduleDateLayout) {
+			if e.Open == "" {
+				return nil
+			}
+			return []OpeningHours{{Day: date.Weekday(), Open: e.Open, Close: e.Close}}
+		}
+	}
+
+	if len(h.Weekly) == 0 {
+		return []OpeningHours{{Day: date.Weekday(), Open: "00:00", Close: "24:00"}}
+	}
+
+	rr := make([]OpeningHours, 0)
+	for _, r := range h.Weekly {
+		if r.Day == date.Weekday() {
+			rr = append(rr, r)
+		}
+	}
+
+	return rr
+}
+
+// openAt reports whether t, in the restaurant's time zone, falls within its
+// hours. Overnight ranges of the day before count.
+func (h RestaurantHours) openAt(t time.Time) bool {
+	m := minutesOf(t)
+	for _, r := range h.dayRanges(t.AddDate(0, 0, -1)) {
+		if open, close := r.minutes(); close < open && m < close {
+			return true
+		}
+	}
+
+	for _, r := range h.dayRanges(t) {
+		if open, close := r.minutes(); m >= open && (close < open || m < close) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// pausedAt reports whether ordering is paused at t.
+func (h RestaurantHours) pausedAt(t time.Time) bool {
+	return h.Paused && (h.PausedUntil == nil || t.Before(*h.PausedUntil))
+}
+
+// restaurantHours loads the restaurant's hours with its exceptions from
+// yesterday on, and tells whether it's open now.
+func restaurantHours(ctx context.Context, q queryer, rid string) (RestaurantHours, error) {
+	hh, err := restaurantsHours(ctx, q, []string{rid})
+	if err != nil {
+		return RestaurantHours{}, err
+	}
+
+	h, ok := hh[rid]
+	if !ok {
+		return h, ErrRestaurantNotFound
+	}
+
+	return h, nil
+}
+
+// restaurantsHours loads the hours of the restaurants, by id, in three
+// queries however many there are. Unknown ids are left out.
+func restaurantsHours(ctx context.Context, q queryer, ids []string) (map[string]RestaurantHours, error) {
+	hh := make(map[string]*RestaurantHours, len(ids))
+	now := make(map[string]time.Time, len(ids))
+	if len(ids) == 0 {
+		return map[string]RestaurantHours{}, nil
+	}
+
+	query := "SELECT id, timezone, ordering_paused, paused_until FROM restaurant WHERE id = ANY($1::UUID[])"
+	rows, err := q.QueryContext(ctx, query, pq.Array(ids))
+	if err != nil {
+		return nil, fmt.Errorf("could not query restaurant hours: %v", err)
+	}
+
+	defer rows.Close()
+	for rows.Next() {
+		var rid string
+		h := RestaurantHours{Weekly: make([]OpeningHours, 0), Exceptions: make([]HoursException, 0)}
+		if err = rows.Scan(&rid, &h.TimeZone, &h.Paused, &h.PausedUntil); err != nil {
+			return nil, fmt.Errorf("could not scan restaurant hours: %v", err)
+		}
+
+		loc, err := time.LoadLocation(h.TimeZone)
+		if err != nil {
+			return nil, fmt.Errorf("could not load restaurant time zone: %v", err)
+		}
+
+		hh[rid], now[rid] = &h, time.Now().In(loc)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("could not iterate restaurant hours: %v", err)
+	}
+
+	query = `
+		SELECT restaurant_id, day, open_time, close_time FROM opening_hours
+		WHERE restaurant_id = ANY($1::UUID[])
+		ORDER BY restaurant_id, day, open_time`
+	rows, err = q.QueryContext(ctx, query, pq.Array(ids))
+	if err != nil {
+		return nil, fmt.Errorf("could not query opening hours: %v", err)
+	}
+
+	defer rows.Close()
+	for rows.Next() {
+		var rid string
+		var r OpeningHours
+		if err = rows.Scan(&rid, &r.Day, &r.Open, &r.Close); err != nil {
+			return nil, fmt.Errorf("could not scan opening hours: %v", err)
+		}
+
+		if h, ok := hh[rid]; ok {
+			h.Weekly = append(h.Weekly, r)
+		}
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("could not iterate opening hours: %v", err)
+	}
+
+	// Two days back in UTC is yesterday or earlier in every time zone; the
+	// exceptions before each restaurant's own yesterday are dropped below.
+	query = `
+		SELECT restaurant_id, date, open_time, close_time, note FROM hours_exception
+		WHERE restaurant_id = ANY($1::UUID[]) AND date >= $2
+		ORDER BY date`
+	rows, err = q.QueryContext(ctx, query, pq.Array(ids), time.Now().UTC().AddDate(0, 0, -2).Format(scheduleDateLayout))
+	if err != nil {
+		return nil, fmt.Errorf("could not query hours exceptions: %v", err)
+	}
+
+	defer rows.Close()
+	for rows.Next() {
+		var rid string
+		var e HoursException
+		if err = rows.Scan(&rid, &e.Date, &e.Open, &e.Close, &e.Note); err != nil {
+			return nil, fmt.Errorf("could not scan hours exception: %v", err)
+		}
+
+		h, ok := hh[rid]
+		if !ok || e.Date < now[rid].AddDate(0, 0, -1).Format(scheduleDateLayout) {
+			continue
+		}
+
+		h.Exceptions = append(h.Exceptions, e)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("could not iterate hours exceptions: %v", err)
+	}
+
+	res := make(map[string]RestaurantHours, len(hh))
+	for rid, h := range hh {
+		if !h.pausedAt(now[rid]) {
+			h.Paused, h.PausedUntil = false, nil
+		}
+
+		h.OpenNow = h.openAt(now[rid])
+		h.AcceptingOrders = h.OpenNow && !h.Paused
+		res[rid] = *h
+	}
+
+	return res, nil
+}
+
+// GetRestaurantHours of a restaurant, for its customers and staff alike.
+func (s *Service) GetRestaurantHours(ctx context.Context, rid string) (RestaurantHours, error) {
+	if !rxUUID.MatchString(rid) {
+		return RestaurantHours{}, ErrRestaurantNotFound
+	}
+
+	return restaurantHours(ctx, s.db, rid)
+}
+
+// SetRestaurantHours replaces the restaurant's weekly hours and sets its
+// time zone.
+func (s *Service) SetRestaurantHours(ctx context.Context, rid, tz string, weekly []OpeningHours) error {
+	uid, auth := ctx.Value(KeyAuthFoodProviderID).(int64)
+	if !auth {
+		return ErrUnauthenticated
+	}
+
+	if !rxUUID.MatchString(rid) {
+		return ErrRestaurantNotFound
+	}
+
+	if _, err := time.LoadLocation(tz); tz == "" || err != nil {
+		return ErrInvalidTimeZone
+	}
+
+	for _, h := range weekly {
+		if err := h.validate(); err != nil {
+			return err
+		}
+	}
+
+	if _, err := s.checkPermission(ctx, Manager, uid, rid); err != nil {
+		return err
+	}
+
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("could not begin tx: %v", err)
+	}
+	defer func() { _ = tx.Rollback() }()
+
+	if _, err = tx.ExecContext(ctx, "UPDATE restaurant SET timezone = $1 WHERE id = $2", tz, rid); err != nil {
+		return fmt.Errorf("could not update time zone: %v", err)
+	}
+
+	if _, err = tx.ExecContext(ctx, "DELETE FROM opening_hours WHERE restaurant_id = $1", rid); err != nil {
+		return fmt.Errorf("could not delete opening hours: %v", err)
+	}
+
+	query := "INSERT INTO opening_hours (restaurant_id, day, open_time, close_time) VALUES ($1, $2, $3, $4)"
+	for _, h := range weekly {
+		if _, err = tx.ExecContext(ctx, query, rid, h.Day, h.Open, h.Close); err != nil {
+			return fmt.Errorf("could not insert opening hours: %v", err)
+		}
+	}
+
+	if err = tx.Commit(); err != nil {
+		return fmt.Errorf("could not set opening hours: could not commit transaction: %v", err)
+	}
+
+	return nil
+}
+
+// SetHoursException of a restaurant on a date, replacing the one there.
+func (s *Service) SetHoursException(ctx context.Context, rid string, e HoursException) error {
+	uid, auth := ctx.Value(KeyAuthFoodProviderID).(int64)
+	if !auth {
+		return ErrUnauthenticated
+	}
+
+	if !rxUUID.MatchString(rid) {
+		return ErrRestaurantNotFound
+	}
+
+	if err := e.validate(); err != nil {
+		return err
+	}
+
+	if _, err := s.checkPermission(ctx, Manager, uid, rid); err != nil {
+		return err
+	}
+
+	query := `
+		INSERT INTO hours_exception (restaurant_id, date, open_time, close_time, note) VALUES ($1, $2, $3, $4, $5)
+		ON CONFLICT (restaurant_id, date) DO UPDATE SET open_time = excluded.open_time,
+			close_time = excluded.close_time, note = excluded.note`
+	if _, err := s.db.ExecContext(ctx, query, rid, e.Date, e.Open, e.Close, e.Note); err != nil {
+		return fmt.Errorf("could not set hours exception: %v", err)
+	}
+
+	return nil
+}
+
+// DeleteHoursException of a restaurant, back to its weekly hours on the date.
+func (s *Service) DeleteHoursException(ctx context.Context, rid, date string) error {
+	uid, auth := ctx.Value(KeyAuthFoodProviderID).(int64)
+	if !auth {
+		return ErrUnauthenticated
+	}
+
+	if !rxUUID.MatchString(rid) {
+		return ErrRestaurantNotFound
+	}
+
+	if _, err := s.checkPermission(ctx, Manager, uid, rid); err != nil {
+		return err
+	}
+
+	query := "DELETE FROM hours_exception WHERE restaurant_id = $1 AND date = $2"
+	res, err := s.db.ExecContext(ctx, query, rid, date)
+	if err != nil {
+		return fmt.Errorf("could not delete hours exception: %v", err)
+	}
+
+	if n, _ := res.RowsAffected(); n == 0 {
+		return ErrHoursExceptionNotFound
+	}
+
+	return nil
+}
+
+// PauseOrdering of a restaurant for a while, e.g. when the kitchen is
+// swamped, or until it's resumed when d is zero.
+func (s *Service) PauseOrdering(ctx context.Context, rid string, d time.Duration) error {
+	uid, auth := ctx.Value(KeyAuthFoodProviderID).(int64)
+	if !auth {
+		return ErrUnauthenticated
+	}
+
+	if !rxUUID.MatchString(rid) {
+		return ErrRestaurantNotFound
+	}
+
+	if d < 0 || d > maxPause {
+		return ErrInvalidPause
+	}
+
+	if _, err := s.checkPermission(ctx, Manager, uid, rid); err != nil {
+		return err
+	}
+
+	var until *time.Time
+	if d != 0 {
+		t := time.Now().Add(d)
+		until = &t
+	}
+
+	query := "UPDATE restaurant SET ordering_paused = true, paused_until = $1 WHERE id = $2"
+	if _, err := s.db.ExecContext(ctx, query, until, rid); err != nil {
+		return fmt.Errorf("could not pause ordering: %v", err)
+	}
+
+	return nil
+}
+
+// ResumeOrdering of a restaurant paused with PauseOrdering.
+func (s *Service) ResumeOrdering(ctx context.Context, rid string) error {
+	uid, auth := ctx.Value(KeyAuthFoodProviderID).(int64)
+	if !auth {
+		return ErrUnauthenticated
+	}
+
+	if !rxUUID.MatchString(rid) {
+		return ErrRestaurantNotFound
+	}
+
+	if _, err := s.checkPermission(ctx, Manager, uid, rid); err != nil {
+		return err
+	}
+
+	query := "UPDATE restaurant SET ordering_paused = false, paused_until = NULL WHERE id = $1"
+	if _, err := s.db.ExecContext(ctx, query, rid); err != nil {
+		return fmt.Errorf("could not resume ordering: %v", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,121 @@
+package service
+
+import (
+	"testing"
+	"time"
+)
+
+func TestOpenAt(t *testing.T) {
+	at := func(v string) time.Time {
+		t, _ := time.Parse("2006-01-02 15:04", v)
+		return t
+	}
+
+	// 2024-03-01 is a Friday.
+	h := RestaurantHours{
+		Weekly: []OpeningHours{
+			{Day: time.Friday, Open: "11:00", Close: "15:00"},
+			{Day: time.Friday, Open: "18:00", Close: "02:00"},
+			{Day: time.Saturday, Open: "12:00", Close: "22:00"},
+			{Day: time.Monday, Open: "11:00", Close: "22:00"},
+		},
+		Exceptions: []HoursException{
+			{Date: "2024-03-04", Note: "Holiday"},
+			{Date: "2024-03-05", Open: "17:00", Close: "20:00", Note: "Event"},
+		},
+	}
+
+	unpadded := RestaurantHours{Weekly: []OpeningHours{{Day: time.Sunday, Open: "9:00", Close: "17:00"}}}
+	allDay := RestaurantHours{Weekly: []OpeningHours{{Day: time.Sunday, Open: "00:00", Close: "24:00"}}}
+
+	var tt = []struct {
+		Label string
+		Hours RestaurantHours
+		Time  time.Time
+		Want  bool
+	}{
+		{Label: "Test should be open in the first range", Hours: h, Time: at("2024-03-01 11:00"), Want: true},
+		{Label: "Test should be closed between ranges", Hours: h, Time: at("2024-03-01 16:30")},
+		{Label: "Test should be closed at the close time", Hours: h, Time: at("2024-03-01 15:00")},
+		{Label: "Test should be open in the second range", Hours: h, Time: at("2024-03-01 23:30"), Want: true},
+		{Label: "Test should be open past midnight", Hours: h, Time: at("2024-03-02 01:30"), Want: true},
+		{Label: "Test should be closed after the overnight range", Hours: h, Time: at("2024-03-02 02:30")},
+		{Label: "Test should be closed on a day without hours", Hours: h, Time: at("2024-03-03 13:00")},
+		{Label: "Test should be closed on a holiday", Hours: h, Time: at("2024-03-04 13:00")},
+		{Label: "Test should use the special hours", Hours: h, Time: at("2024-03-05 18:00"), Want: true},
+		{Label: "Test should be closed outside the special hours", Hours: h, Time: at("2024-03-05 13:00")},
+		{Label: "Test should be open within unpadded hours", Hours: unpadded, Time: at("2024-03-03 10:00"), Want: true},
+		{Label: "Test should be closed after unpadded hours", Hours: unpadded, Time: at("2024-03-03 23:00")},
+		{Label: "Test should be open all day until midnight", Hours: allDay, Time: at("2024-03-03 23:59"), Want: true},
+		{Label: "Test should be closed the day after an all day range", Hours: allDay, Time: at("2024-03-04 00:30")},
+		{Label: "Test should be open without weekly hours", Time: at("2024-03-03 04:00"), Want: true},
+		{Label: "Test should be closed on a holiday without weekly hours",
+			Hours: RestaurantHours{Exceptions: []HoursException{{Date: "2024-03-04"}}}, Time: at("2024-03-04 13:00")},
+	}
+
+	for _, test := range tt {
+		t.Run(test.Label, func(t *testing.T) {
+			if got := test.Hours.openAt(test.Time); got != test.Want {
+				t.Error("Got:", got, "| Want:", test.Want)
+			}
+		})
+	}
+}
+
+func TestPausedAt(t *testing.T) {
+	now := time.Now()
+	past, future := now.Add(-time.Minute), now.Add(time.Minute)
+
+	var tt = []struct {
+		Label string
+		Hours RestaurantHours
+		Want  bool
+	}{
+		{Label: "Test should not be paused", Hours: RestaurantHours{}},
+		{Label: "Test should be paused until resumed", Hours: RestaurantHours{Paused: true}, Want: true},
+		{Label: "Test should be paused for a while", Hours: RestaurantHours{Paused: true, PausedUntil: &future}, Want: true},
+		{Label: "Test should resume after the pause", Hours: RestaurantHours{Paused: true, PausedUntil: &past}},
+	}
+
+	for _, test := range tt {
+		t.Run(test.Label, func(t *testing.T) {
+			if got := test.Hours.pausedAt(now); got != test.Want {
+				t.Error("Got:", got, "| Want:", test.Want)
+			}
+		})
+	}
+}
+
+func TestHoursValidate(t *testing.T) {
+	var tt = []struct {
+		Label string
+		Err   error
+		Want  error
+	}{
+		{Label: "Test should accept hours", Err: OpeningHours{Day: time.Monday, Open: "09:00", Close: "17:00"}.validate()},
+		{Label: "Test should accept overnight hours", Err: OpeningHours{Day: time.Friday, Open: "18:00", Close: "02:00"}.validate()},
+		{Label: "Test should reject an unknown day", Err: OpeningHours{Day: 7, Open: "09:00", Close: "17:00"}.validate(),
+			Want: ErrInvalidHours},
+		{Label: "Test should accept unpadded hours", Err: OpeningHours{Day: time.Monday, Open: "9:00", Close: "17:00"}.validate()},
+		{Label: "Test should accept an all day range", Err: OpeningHours{Day: time.Monday, Open: "00:00", Close: "24:00"}.validate()},
+		{Label: "Test should reject opening at 24:00", Err: OpeningHours{Day: time.Monday, Open: "24:00", Close: "02:00"}.validate(),
+			Want: ErrInvalidHours},
+		{Label: "Test should reject a malformed time", Err: OpeningHours{Day: time.Monday, Open: "9am", Close: "17:00"}.validate(),
+			Want: ErrInvalidHours},
+		{Label: "Test should reject an empty range", Err: OpeningHours{Day: time.Monday, Open: "09:00", Close: "09:00"}.validate(),
+			Want: ErrInvalidHours},
+		{Label: "Test should accept a holiday", Err: HoursException{Date: "2024-12-16", Note: "Victory Day"}.validate()},
+		{Label: "Test should accept special hours", Err: HoursException{Date: "2024-12-31", Open: "18:00", Close: "03:00"}.validate()},
+		{Label: "Test should reject a malformed date", Err: HoursException{Date: "16-12-2024"}.validate(), Want: ErrInvalidHours},
+		{Label: "Test should reject a missing close time", Err: HoursException{Date: "2024-12-31", Open: "18:00"}.validate(),
+			Want: ErrInvalidHours},
+	}
+
+	for _, test := range tt {
+		t.Run(test.Label, func(t *testing.T) {
+			if test.Err != test.Want {
+				t.Error("Got:", test.Err, "| Want:", test.Want)
+			}
+		})
+	}
+}
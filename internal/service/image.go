@@ -0,0 +1,69 @@
+package service
+
+import (
+	"fmt"
+	"image"
+	"image/jpeg"
+	"image/png"
+	"io"
+	"os"
+	"path"
+
+	"github.com/disintegration/imaging"
+	gonanoid "github.com/matoous/go-nanoid"
+)
+
+// saveImage decodes a png or jpeg picture from r and writes it inside dir
+// under a random name, which is returned. The picture is cropped to w x h
+// when both are given.
+func saveImage(r io.Reader, dir string, w, h int) (string, error) {
+	r = io.LimitReader(r, MaxImageBytes)
+	img, format, err := image.Decode(r)
+	if err == image.ErrFormat {
+		return "", ErrUnsupportedImageFormat
+	}
+
+	if err != nil {
+		return "", fmt.Errorf("could not read image: %v", err)
+	}
+
+	if format != "png" && format != "jpeg" {
+		return "", ErrUnsupportedImageFormat
+	}
+
+	imageName, err := gonanoid.Nanoid()
+	if err != nil {
+		return "", fmt.Errorf("could not generate image filename: %v", err)
+	}
+
+	if format == "png" {
+		imageName += ".png"
+	} else {
+		imageName += ".jpg"
+	}
+
+	if err = os.MkdirAll(dir, 0755); err != nil {
+		return "", fmt.Errorf("failed to create path for image: %v", err)
+	}
+
+	f, err := os.Create(path.Join(dir, imageName))
+	if err != nil {
+		return "", fmt.Errorf("could not create image file: %v", err)
+	}
+	defer f.Close()
+
+	if w > 0 && h > 0 {
+		img = imaging.Fill(img, w, h, imaging.Center, imaging.CatmullRom)
+	}
+	if format == "png" {
+		err = png.Encode(f, img)
+	} else {
+		err = jpeg.Encode(f, img, nil)
+	}
+	if err != nil {
+		defer os.Remove(path.Join(dir, imageName))
+		return "", fmt.Errorf("could not write image to disk: %v", err)
+	}
+
+	return imageName, nil
+}
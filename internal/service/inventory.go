@@ -0,0 +1,345 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+var (
+	// ErrIngredientNotFound denotes a not found ingredient.
+	ErrIngredientNotFound = errors.New("ingredient not found")
+	// ErrIngredientExists denotes an ingredient with that name already exists in the restaurant.
+	ErrIngredientExists = errors.New("ingredient already exists")
+	// ErrOutOfStock denotes an order needing more of an ingredient than is left in stock.
+	ErrOutOfStock = errors.New("out of stock")
+)
+
+type Ingredient struct {
+	Id       int64   `json:"id"`
+	Name     string  `json:"name"`
+	Unit     string  `json:"unit"`
+	Stock    float64 `json:"stock"`
+	LowStock float64 `json:"low_stock"`
+	Low      bool    `json:"low"`
+}
+
+// RecipeLine is the quantity of one ingredient used to make an item.
+type RecipeLine struct {
+	IngredientId int64   `json:"ingredient_id"`
+	Name         string  `json:"name,omitempty"`
+	Unit         string  `json:"unit,omitempty"`
+	Quantity     float64 `json:"quantity"`
+}
+
+// CreateIngredient tracked in the restaurant's stock.
+func (s *Service) CreateIngredient(ctx context.Context, rid, name, unit string, stock, lowStock float64) (int64, error) {
+	uid, auth := ctx.Value(KeyAuthFoodProviderID).(int64)
+	if !auth {
+		return 0, ErrUnauthenticated
+	}
+
+	if !rxUUID.MatchString(rid) {
+		return 0, ErrInvalidRestaurantId
+	}
+
+	name = strings.TrimSpace(name)
+	unit = strings.TrimSpace(unit)
+	if name == "" || unit == "" {
+		return 0, ErrEmptyValue
+	}
+
+	if stock < 0 || lowStock < 0 {
+		return 0, ErrInvalidQuantity
+	}
+
+	if _, err := s.checkPermission(ctx, Manager, uid, rid); err != nil {
+		return 0, err
+	}
+
+	var id int64
+	query := "INSERT INTO ingredient (restaurant_id, name, unit, stock, low_stock) VALUES ($1, $2, $3, $4, $5) RETURNING id"
+	err := s.db.QueryRowContext(ctx, query, rid, name, unit, stock, lowStock).Scan(&id)
+	if isUniqueViolation(err) {
+		return 0, ErrIngredientExists
+	}
+
+	if err != nil {
+		return 0, fmt.Errorf("could not create ingredient: %v", err)
+	}
+
+	return id, nil
+}
+
+// GetIngredients of the restaurant. When lowOnly is set only the ingredients
+// at or below their low stock level are returned.
+func (s *Service) GetIngredients(ctx context.Context, rid string, lowOnly bool) ([]Ingredient, error) {
+	uid, auth := ctx.Value(KeyAuthFoodProviderID).(int64)
+	if !auth {
+		return nil, ErrUnauthenticated
+	}
+
+	if !rxUUID.MatchString(rid) {
+		return nil, ErrInvalidRestaurantId
+	}
+
+	if _, err := s.checkPermission(ctx, Waiter, uid, rid); err != nil {
+		return nil, err
+	}
+
+	query := `
+		SELECT id, name, unit, stock, low_stock, stock <= low_stock
+		FROM ingredient
+		WHERE restaurant_id = $1 AND ($2 = false OR stock <= low_stock)
+		ORDER BY name`
+	rows, err := s.db.QueryContext(ctx, query, rid, lowOnly)
+	if err != nil {
+		return nil, fmt.Errorf("could not query ingredients: %v", err)
+	}
+
+	defer rows.Close()
+	ii := make([]Ingredient, 0)
+	for rows.Next() {
+		var i Ingredient
+		if err = rows.Scan(&i.Id, &i.Name, &i.Unit, &i.Stock, &i.LowStock, &i.Low); err != nil {
+			return nil, fmt.Errorf("could not scan ingredient: %v", err)
+		}
+
+		ii = append(ii, i)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("could not iterate ingredients: %v", err)
+	}
+
+	return ii, nil
+}
+
+// UpdateIngredientStock sets the counted stock and low stock level of an
+// ingredient, putting items back on the menu once there is enough of it.
+func (s *Service) UpdateIngredientStock(ctx context.Context, rid string, id int64, stock, lowStock float64) error {
+	uid, auth := ctx.Value(KeyAuthFoodProviderID).(int64)
+	if !auth {
+		return ErrUnauthenticated
+	}
+
+	if !rxUUID.MatchString(rid) {
+		return ErrInvalidRestaurantId
+	}
+
+	if stock < 0 || lowStock < 0 {
+		return ErrInvalidQuantity
+	}
+
+	if _, err := s.checkPermission(ctx, Manager, uid, rid); err != nil {
+		return err
+	}
+
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("could not begin tx: %v", err)
+	}
+	defer func() { _ = tx.Rollback() }()
+
+	query := "UPDATE ingredient SET stock = $1, low_stock = $2 WHERE id = $3 AND restaurant_id = $4"
+	res, err := tx.ExecContext(ctx, query, stock, lowStock, id, rid)
+	if err != nil {
+		return fmt.Errorf("could not update ingredient: %v", err)
+	}
+
+	if n, _ := res.RowsAffected(); n == 0 {
+		return ErrIngredientNotFound
+	}
+
+	if err = updateSoldOut(ctx, tx, rid); err != nil {
+		return err
+	}
+
+	if err = tx.Commit(); err != nil {
+		return fmt.Errorf("could not update ingredient: could not commit transaction: %v", err)
+	}
+
+	return nil
+}
+
+// GetRecipe of a menu item.
+func (s *Service) GetRecipe(ctx context.Context, rid string, iid int64) ([]RecipeLine, error) {
+	uid, auth := ctx.Value(KeyAuthFoodProviderID).(int64)
+	if !auth {
+		return nil, ErrUnauthenticated
+	}
+
+	if !rxUUID.MatchString(rid) {
+		return nil, ErrInvalidRestaurantId
+	}
+
+	if _, err := s.checkPermission(ctx, Waiter, uid, rid); err != nil {
+		return nil, err
+	}
+
+	query := `
+		SELECT g.id, g.name, g.unit, rc.quantity
+		FROM recipe rc
+			INNER JOIN ingredient g ON rc.ingredient_id = g.id
+			INNER JOIN item i ON rc.item_id = i.id
+		WHERE rc.item_id = $1 AND i.restaurant_id = $2
+		ORDER BY g.name`
+	rows, err := s.db.QueryContext(ctx, query, iid, rid)
+	if err != nil {
+		return nil, fmt.Errorf("could not query recipe: %v", err)
+	}
+
+	defer rows.Close()
+	rr := make([]RecipeLine, 0)
+	for rows.Next() {
+		var r RecipeLine
+		if err = rows.Scan(&r.IngredientId, &r.Name, &r.Unit, &r.Quantity); err != nil {
+			return nil, fmt.Errorf("could not scan recipe: %v", err)
+		}
+
+		rr = append(rr, r)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("could not iterate recipe: %v", err)
+	}
+
+	return rr, nil
+}
+
+// SetRecipe replaces the ingredients used to make a menu item.
+func (s *Service) SetRecipe(ctx context.Context, rid string, iid int64, lines []RecipeLine) error {
+	uid, auth := ctx.Value(KeyAuthFoodProviderID).(int64)
+	if !auth {
+		return ErrUnauthenticated
+	}
+
+	if !rxUUID.MatchString(rid) {
+		return ErrInvalidRestaurantId
+	}
+
+	for _, l := range lines {
+		if l.Quantity <= 0 {
+			return ErrInvalidQuantity
+		}
+	}
+
+	if _, err := s.checkPermission(ctx, Manager, uid, rid); err != nil {
+		return err
+	}
+
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("could not begin tx: %v", err)
+	}
+	defer func() { _ = tx.Rollback() }()
+
+	var exists bool
+	query := "SELECT EXISTS (SELECT 1 FROM item WHERE id = $1 AND restaurant_id = $2)"
+	if err = tx.QueryRowContext(ctx, query, iid, rid).Scan(&exists); err != nil {
+		return fmt.Errorf("could not query item: %v", err)
+	}
+
+	if !exists {
+		return ErrItemNotFound
+	}
+
+	if _, err = tx.ExecContext(ctx, "DELETE FROM recipe WHERE item_id = $1", iid); err != nil {
+		return fmt.Errorf("could not clear recipe: %v", err)
+	}
+
+	query = `
+		INSERT INTO recipe (item_id, ingredient_id, quantity)
+		SELECT $1, id, $3 FROM ingredient WHERE id = $2 AND restaurant_id = $4`
+	for _, l := range lines {
+		res, err := tx.ExecContext(ctx, query, iid, l.IngredientId, l.Quantity, rid)
+		if isUniqueViolation(err) {
+			return ErrIngredientExists
+		}
+
+		if err != nil {
+			return fmt.Errorf("could not add recipe ingredient: %v", err)
+		}
+
+		if n, _ := res.RowsAffected(); n == 0 {
+			return ErrIngredientNotFound
+		}
+	}
+
+	if err = updateSoldOut(ctx, tx, rid); err != nil {
+		return err
+	}
+
+	if err = tx.Commit(); err != nil {
+		return fmt.Errorf("could not set recipe: could not commit transaction: %v", err)
+	}
+
+	return nil
+}
+
+// useStock takes the ingredients of every item in the order out of stock,
+// including the components of combos. A negative sign puts them back, e.g.
+// when an accepted order is cancelled. Stock never goes below zero: taking
+// more than is left fails with ErrOutOfStock and the caller's tx must be
+// rolled back.
+func useStock(ctx context.Context, tx *sql.Tx, rid string, oid int64, sign int) error {
+	query := `
+		WITH used AS (
+			SELECT oi.item_id, oi.quantity
+			FROM order_item oi
+			WHERE oi.order_id = $1
+			UNION ALL
+			SELECT c.item_id, c.quantity * oi.quantity
+			FROM order_item oi INNER JOIN order_item_component c ON c.order_item_id = oi.id
+			WHERE oi.order_id = $1
+		)
+		UPDATE ingredient SET stock = stock - $2 * (
+			SELECT SUM(rc.quantity * u.quantity)
+			FROM used u INNER JOIN recipe rc ON u.item_id = rc.item_id
+			WHERE rc.ingredient_id = ingredient.id)
+		WHERE id IN (
+			SELECT rc.ingredient_id
+			FROM used u INNER JOIN recipe rc ON u.item_id = rc.item_id)
+		RETURNING stock`
+	rows, err := tx.QueryContext(ctx, query, oid, sign)
+	if err != nil {
+		return fmt.Errorf("could not update stock: %v", err)
+	}
+
+	defer rows.Close()
+	for rows.Next() {
+		var stock float64
+		if err = rows.Scan(&stock); err != nil {
+			return fmt.Errorf("could not scan stock: %v", err)
+		}
+
+		if stock < 0 {
+			return ErrOutOfStock
+		}
+	}
+
+	if err = rows.Err(); err != nil {
+		return fmt.Errorf("could not iterate stock: %v", err)
+	}
+
+	return updateSoldOut(ctx, tx, rid)
+}
+
+// updateSoldOut marks the restaurant's items as sold out when any of their
+// ingredients does not have enough stock left for one more, and back on
+// when it does.
+func updateSoldOut(ctx context.Context, tx *sql.Tx, rid string) error {
+	query := `
+		UPDATE item SET sold_out = EXISTS (
+			SELECT 1
+			FROM recipe rc INNER JOIN ingredient g ON rc.ingredient_id = g.id
+			WHERE rc.item_id = item.id AND g.stock < rc.quantity)
+		WHERE restaurant_id = $1`
+	if _, err := tx.ExecContext(ctx, query, rid); err != nil {
+		return fmt.Errorf("could not update sold out items: %v", err)
+	}
+
+	return nil
+}
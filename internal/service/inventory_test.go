@@ -0,0 +1,120 @@
+package service
+
+import (
+	"context"
+	"net/url"
+	"strconv"
+	"testing"
+
+	"github.com/hako/branca"
+	"github.com/jackc/pgx"
+	"github.com/jackc/pgx/stdlib"
+)
+
+func TestUseStock(t *testing.T) {
+	tearDown := SetupTest()
+	defer tearDown()
+
+	ctx := context.TODO()
+
+	codec := branca.NewBranca("supersecretkeyyoushouldnotcommit")
+	codec.SetTTL(uint32(TokenLifeSpan.Seconds()))
+
+	fpCodec := branca.NewBranca("supersecretkeyyoushouldcommitnot")
+	fpCodec.SetTTL(uint32(TokenLifeSpan.Seconds()))
+
+	c, err := pgx.ParseURI(pgURL.String())
+	if err != nil {
+		log.Fatalf(err.Error())
+	}
+
+	db := stdlib.OpenDB(c)
+
+	if err := ValidateSchema(db); err != nil {
+		log.Fatalf("failed to validate schema: %v\n", err)
+	}
+
+	s := New(db, codec, fpCodec, nil, url.URL{})
+
+	_ = s.CreateFoodProvider(ctx, "johndoe@gmail.com", "John Snow", "01867584576", "ilovegolang")
+	user, _ := s.FoodProviderLogin(ctx, "01867584576", "ilovegolang")
+	ctx = context.WithValue(ctx, KeyAuthFoodProviderID, user.AuthUser.ID)
+	_ = s.CreateRestaurant(ctx, "test.Title", "test.About", "01616534596", "test.Location", "test.City", "test.Area", "test.Country")
+	user, _ = s.FoodProviderLogin(ctx, "01867584576", "ilovegolang")
+	rid := (*user.Restaurants)[0].Id
+
+	_ = s.CreateCategory(ctx, rid, "Rice", true)
+	cc, _ := s.GetCategoriesByRestaurant(ctx, rid)
+	_ = s.CreateItem(ctx, rid, cc[0].Id, "Biryani", "Mutton", 320, true)
+	menu, _ := s.GetMenuForFp(ctx, rid)
+	iid, _ := strconv.ParseInt((*menu)[0].Id, 10, 64)
+
+	gid, err := s.CreateIngredient(ctx, rid, "Basmati", "kg", 3, 0)
+	if err != nil {
+		t.Fatal("Got:", err, "| Want: the ingredient created")
+	}
+
+	if err = s.SetRecipe(ctx, rid, iid, []RecipeLine{{IngredientId: gid, Quantity: 1}}); err != nil {
+		t.Fatal("Got:", err, "| Want: the recipe set")
+	}
+
+	var cust int64
+	if err = db.QueryRow("INSERT INTO users (email, fullname) VALUES ('jane@gmail.com', 'Jane Doe') RETURNING id").Scan(&cust); err != nil {
+		t.Fatal(err)
+	}
+
+	order := func(quantity int) int64 {
+		var oid int64
+		query := "INSERT INTO orders (cust_id, restaurant_id, status) VALUES ($1, $2, $3) RETURNING id"
+		if err := db.QueryRow(query, cust, rid, OrderPlaced).Scan(&oid); err != nil {
+			t.Fatal(err)
+		}
+
+		query = "INSERT INTO order_item (order_id, item_id, quantity, price) VALUES ($1, $2, $3, 320)"
+		if _, err := db.Exec(query, oid, iid, quantity); err != nil {
+			t.Fatal(err)
+		}
+
+		return oid
+	}
+
+	first, second, third := order(2), order(1), order(1)
+
+	var tt = []struct {
+		Label   string
+		Order   int64
+		Status  int64
+		Want    error
+		Stock   float64
+		SoldOut bool
+	}{
+		{Label: "Test should take the ingredients out of stock on accept", Order: first, Status: OrderAccepted, Want: nil, Stock: 1},
+		{Label: "Test should mark the item sold out when the stock runs out", Order: second, Status: OrderAccepted, Want: nil, Stock: 0, SoldOut: true},
+		{Label: "Test should not accept an order with the stock run out", Order: third, Status: OrderAccepted, Want: ErrOutOfStock, Stock: 0, SoldOut: true},
+		{Label: "Test should not restock an order that was never accepted", Order: third, Status: OrderCancelled, Want: nil, Stock: 0, SoldOut: true},
+		{Label: "Test should restock and mark the item back on when cancelled", Order: second, Status: OrderCancelled, Want: nil, Stock: 1},
+		{Label: "Test should not take the stock again when the order moves on", Order: first, Status: OrderPreparing, Want: nil, Stock: 1},
+	}
+
+	for _, test := range tt {
+		t.Run(test.Label, func(t *testing.T) {
+			if got := s.UpdateOrderStatus(ctx, rid, test.Order, test.Status); got != test.Want {
+				t.Error("Got:", got, "| Want:", test.Want)
+			}
+
+			var stock float64
+			if err := db.QueryRow("SELECT stock FROM ingredient WHERE id = $1", gid).Scan(&stock); err != nil {
+				t.Fatal(err)
+			}
+
+			var soldOut bool
+			if err := db.QueryRow("SELECT sold_out FROM item WHERE id = $1", iid).Scan(&soldOut); err != nil {
+				t.Fatal(err)
+			}
+
+			if stock != test.Stock || soldOut != test.SoldOut {
+				t.Error("Got:", stock, soldOut, "| Want:", test.Stock, test.SoldOut)
+			}
+		})
+	}
+}
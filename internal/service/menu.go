@@ -3,26 +3,59 @@ package service
 import (
 	"context"
 	"database/sql"
+	"errors"
 	"fmt"
-	"github.com/disintegration/imaging"
-	gonanoid "github.com/matoous/go-nanoid"
-	"image"
-	"image/jpeg"
-	"image/png"
 	"io"
 	"os"
 	"path"
+	"strconv"
 	"strings"
 )
 
 type Item struct {
-	Id                string  `json:"id"`
-	Name              string  `json:"name"`
-	Category          string  `json:"category"`
-	CategoryAvailable string  `json:"category_available"`
-	Description       string  `json:"description"`
-	Price             float64 `json:"price"`
-	Availability      bool    `json:"availability"`
+	Id                string   `json:"id"`
+	Name              string   `json:"name"`
+	Category          string   `json:"category"`
+	CategoryAvailable string   `json:"category_available"`
+	Description       string   `json:"description"`
+	Price             float64  `json:"price"`
+	Availability      bool     `json:"availability"`
+	SoldOut           bool     `json:"sold_out"`
+	Pictures          []string `json:"pictures"`
+	Dietary
+}
+
+var (
+	// ErrCategoryNotFound denotes a not found menu category.
+	ErrCategoryNotFound = errors.New("category not found")
+	// ErrInvalidPositions denotes a reorder list that doesn't hold every category or item exactly once.
+	ErrInvalidPositions = errors.New("invalid positions")
+	// ErrInvalidPictureSlot denotes an item gallery slot other than 1, 2 or 3.
+	ErrInvalidPictureSlot = errors.New("invalid picture slot")
+	// ErrItemPictureNotFound denotes an empty item gallery slot.
+	ErrItemPictureNotFound = errors.New("item picture not found")
+)
+
+// MenuItem is an item as customers see it on the menu.
+type MenuItem struct {
+	Id           string          `json:"id"`
+	Name         string          `json:"name"`
+	Description  string          `json:"description"`
+	Price        float64         `json:"price"`
+	RegularPrice float64         `json:"regular_price,omitempty"`
+	Deal         string          `json:"deal,omitempty"`
+	Pictures     []string        `json:"pictures"`
+	Variants     []Variant       `json:"variants,omitempty"`
+	Modifiers    []ModifierGroup `json:"modifiers,omitempty"`
+	Combo        []ComboSlot     `json:"combo,omitempty"`
+	Dietary
+}
+
+// MenuCategory is an available category with its available items.
+type MenuCategory struct {
+	Id    int64      `json:"id"`
+	Label string     `json:"label"`
+	Items []MenuItem `json:"items"`
 }
 
 type Category struct {
@@ -47,13 +80,19 @@ func (s *Service) CreateCategory(ctx context.Context, rid, name string, availabi
 		return err
 	}
 
+	if err := checkUnlinked(ctx, s.db, rid); err != nil {
+		return err
+	}
+
 	tx, err := s.db.BeginTx(ctx, nil)
 	if err != nil {
 		return fmt.Errorf("could not begin tx: %v", err)
 	}
 	defer func() { _ = tx.Rollback() }()
 
-	query := "INSERT INTO category(restaurant, label, availability) VALUES ($1, $2, $3)"
+	query := `
+		INSERT INTO category(restaurant, label, availability, position)
+		VALUES ($1, $2, $3, (SELECT COALESCE(MAX(position), 0) + 1 FROM category WHERE restaurant = $1))`
 	_, err = tx.ExecContext(ctx, query, rid, name, availability)
 	u := isUniqueViolation(err)
 	if u {
@@ -68,6 +107,7 @@ func (s *Service) CreateCategory(ctx context.Context, rid, name string, availabi
 		return fmt.Errorf("failed to update restaurant: could not commit transaction: %v", err)
 	}
 
+	s.syncBranches(ctx, rid)
 	return nil
 }
 
@@ -86,7 +126,8 @@ func (s *Service) GetCategoriesByRestaurant(ctx context.Context, rid string) ([]
 	query := `
 		SELECT label, id
  		FROM category
-		WHERE restaurant = $1`
+		WHERE restaurant = $1 AND archived = false
+		ORDER BY position, id`
 	rows, err := s.db.QueryContext(ctx, query, rid)
 	if err == sql.ErrNoRows {
 		return c, nil
@@ -167,8 +208,15 @@ func (s *Service) CreateItem(ctx context.Context, rid string, cid int64, name, d
 		return err
 	}
 
+	if err := checkUnlinked(ctx, s.db, rid); err != nil {
+		return err
+	}
+
 	var id string
-	query := "INSERT INTO item (restaurant_id, category_id, name, description, price, availability) VALUES ($1, $2, $3, $4, $5, $6) RETURNING id"
+	query := `
+		INSERT INTO item (restaurant_id, category_id, name, description, price, availability, position)
+		VALUES ($1, $2, $3, $4, $5, $6, (SELECT COALESCE(MAX(position), 0) + 1 FROM item WHERE category_id = $2))
+		RETURNING id`
 	err := s.db.QueryRowContext(ctx, query, rid, cid, name, description, price, available).Scan(&id)
 	unique := isUniqueViolation(err)
 	if unique {
@@ -178,11 +226,445 @@ func (s *Service) CreateItem(ctx context.Context, rid string, cid int64, name, d
 		return err
 	}
 
+	s.menuChanged(ctx, rid)
+	return nil
+}
+
+// UpdateCategory label and availability.
+func (s *Service) UpdateCategory(ctx context.Context, rid string, cid int64, label string, availability bool) error {
+	uid, auth := ctx.Value(KeyAuthFoodProviderID).(int64)
+	if !auth {
+		return ErrUnauthenticated
+	}
+
+	if !rxUUID.MatchString(rid) {
+		return ErrRestaurantNotFound
+	}
+
+	label = strings.TrimSpace(label)
+	if label == "" {
+		return ErrEmptyValue
+	}
+
+	if _, err := s.checkPermission(ctx, Manager, uid, rid); err != nil {
+		return err
+	}
+
+	if err := checkUnlinked(ctx, s.db, rid); err != nil {
+		return err
+	}
+
+	query := "UPDATE category SET label = $1, availability = $2 WHERE id = $3 AND restaurant = $4 AND archived = false"
+	res, err := s.db.ExecContext(ctx, query, label, availability, cid, rid)
+	if isUniqueViolation(err) {
+		return ErrTitleTaken
+	}
+
+	if err != nil {
+		return fmt.Errorf("could not update category: %v", err)
+	}
+
+	if n, _ := res.RowsAffected(); n == 0 {
+		return ErrCategoryNotFound
+	}
+
+	s.menuChanged(ctx, rid)
+	return nil
+}
+
+// SetCategoryItemsAvailability turns every item of the category on or off
+// at once.
+func (s *Service) SetCategoryItemsAvailability(ctx context.Context, rid string, cid int64, availability bool) error {
+	uid, auth := ctx.Value(KeyAuthFoodProviderID).(int64)
+	if !auth {
+		return ErrUnauthenticated
+	}
+
+	if !rxUUID.MatchString(rid) {
+		return ErrRestaurantNotFound
+	}
+
+	if _, err := s.checkPermission(ctx, Manager, uid, rid); err != nil {
+		return err
+	}
+
+	if err := checkUnlinked(ctx, s.db, rid); err != nil {
+		return err
+	}
+
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("could not begin tx: %v", err)
+	}
+	defer func() { _ = tx.Rollback() }()
+
+	var exists bool
+	query := "SELECT EXISTS (SELECT 1 FROM category WHERE id = $1 AND restaurant = $2 AND archived = false)"
+	if err = tx.QueryRowContext(ctx, query, cid, rid).Scan(&exists); err != nil {
+		return fmt.Errorf("could not query category: %v", err)
+	}
+
+	if !exists {
+		return ErrCategoryNotFound
+	}
+
+	query = "UPDATE item SET availability = $1 WHERE category_id = $2 AND restaurant_id = $3 AND archived = false"
+	if _, err = tx.ExecContext(ctx, query, availability, cid, rid); err != nil {
+		return fmt.Errorf("could not update category items: %v", err)
+	}
+
+	if err = tx.Commit(); err != nil {
+		return fmt.Errorf("could not update category items: could not commit transaction: %v", err)
+	}
+
+	s.menuChanged(ctx, rid)
+	return nil
+}
+
+// ArchiveCategory removes the category and its items from the menu. They are
+// kept in the database so past orders still refer to them.
+func (s *Service) ArchiveCategory(ctx context.Context, rid string, cid int64) error {
+	uid, auth := ctx.Value(KeyAuthFoodProviderID).(int64)
+	if !auth {
+		return ErrUnauthenticated
+	}
+
+	if !rxUUID.MatchString(rid) {
+		return ErrRestaurantNotFound
+	}
+
+	if _, err := s.checkPermission(ctx, Manager, uid, rid); err != nil {
+		return err
+	}
+
+	if err := checkUnlinked(ctx, s.db, rid); err != nil {
+		return err
+	}
+
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("could not begin tx: %v", err)
+	}
+	defer func() { _ = tx.Rollback() }()
+
+	query := "UPDATE category SET archived = true WHERE id = $1 AND restaurant = $2 AND archived = false"
+	res, err := tx.ExecContext(ctx, query, cid, rid)
+	if err != nil {
+		return fmt.Errorf("could not archive category: %v", err)
+	}
+
+	if n, _ := res.RowsAffected(); n == 0 {
+		return ErrCategoryNotFound
+	}
+
+	query = "UPDATE item SET archived = true WHERE category_id = $1 AND restaurant_id = $2"
+	if _, err = tx.ExecContext(ctx, query, cid, rid); err != nil {
+		return fmt.Errorf("could not archive category items: %v", err)
+	}
+
+	if err = tx.Commit(); err != nil {
+		return fmt.Errorf("could not archive category: could not commit transaction: %v", err)
+	}
+
+	s.menuChanged(ctx, rid)
+	return nil
+}
+
+// UpdateItem details, moving it to another category of the restaurant when
+// cid changes.
+func (s *Service) UpdateItem(ctx context.Context, rid string, iid, cid int64, name, description string, price float64, available bool) error {
+	uid, auth := ctx.Value(KeyAuthFoodProviderID).(int64)
+	if !auth {
+		return ErrUnauthenticated
+	}
+
+	if !rxUUID.MatchString(rid) {
+		return ErrRestaurantNotFound
+	}
+
+	name = strings.TrimSpace(name)
+	description = strings.TrimSpace(description)
+	if name == "" {
+		return ErrEmptyValue
+	}
+
+	if price <= 0 {
+		return ErrInvalidPrice
+	}
+
+	if _, err := s.checkPermission(ctx, Manager, uid, rid); err != nil {
+		return err
+	}
+
+	if err := checkUnlinked(ctx, s.db, rid); err != nil {
+		return err
+	}
+
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("could not begin tx: %v", err)
+	}
+	defer func() { _ = tx.Rollback() }()
+
+	var exists bool
+	query := "SELECT EXISTS (SELECT 1 FROM category WHERE id = $1 AND restaurant = $2 AND archived = false)"
+	if err = tx.QueryRowContext(ctx, query, cid, rid).Scan(&exists); err != nil {
+		return fmt.Errorf("could not query category: %v", err)
+	}
+
+	if !exists {
+		return ErrCategoryNotFound
+	}
+
+	var previous float64
+	query = "SELECT price FROM item WHERE id = $1 AND restaurant_id = $2 AND archived = false"
+	err = tx.QueryRowContext(ctx, query, iid, rid).Scan(&previous)
+	if err == sql.ErrNoRows {
+		return ErrItemNotFound
+	}
+
+	if err != nil {
+		return fmt.Errorf("could not query item price: %v", err)
+	}
+
+	query = `
+		UPDATE item SET category_id = $1, name = $2, description = $3, price = $4, availability = $5,
+			position = CASE WHEN category_id = $1 THEN position
+				ELSE (SELECT COALESCE(MAX(position), 0) + 1 FROM item WHERE category_id = $1) END
+		WHERE id = $6 AND restaurant_id = $7 AND archived = false`
+	res, err := tx.ExecContext(ctx, query, cid, name, description, price, available, iid, rid)
+	if isUniqueViolation(err) {
+		return ErrItemAlreadyExists
+	}
+
+	if err != nil {
+		return fmt.Errorf("could not update item: %v", err)
+	}
+
+	if n, _ := res.RowsAffected(); n == 0 {
+		return ErrItemNotFound
+	}
+
+	if price != previous {
+		if err = recordPriceChange(ctx, tx, iid, uid, previous, price); err != nil {
+			return err
+		}
+	}
+
+	if err = tx.Commit(); err != nil {
+		return fmt.Errorf("could not update item: could not commit transaction: %v", err)
+	}
+
+	s.menuChanged(ctx, rid)
+	return nil
+}
+
+// ArchiveItem removes the item from the menu. It is kept in the database so
+// past orders still refer to it.
+func (s *Service) ArchiveItem(ctx context.Context, rid string, iid int64) error {
+	uid, auth := ctx.Value(KeyAuthFoodProviderID).(int64)
+	if !auth {
+		return ErrUnauthenticated
+	}
+
+	if !rxUUID.MatchString(rid) {
+		return ErrRestaurantNotFound
+	}
+
+	if _, err := s.checkPermission(ctx, Manager, uid, rid); err != nil {
+		return err
+	}
+
+	if err := checkUnlinked(ctx, s.db, rid); err != nil {
+		return err
+	}
+
+	query := "UPDATE item SET archived = true WHERE id = $1 AND restaurant_id = $2 AND archived = false"
+	res, err := s.db.ExecContext(ctx, query, iid, rid)
+	if err != nil {
+		return fmt.Errorf("could not archive item: %v", err)
+	}
+
+	if n, _ := res.RowsAffected(); n == 0 {
+		return ErrItemNotFound
+	}
+
+	s.menuChanged(ctx, rid)
 	return nil
 }
 
-// UpdateItemPicture of the authenticated restaurant returning the new avatar URL.
-func (s *Service) UpdateItemPicture(ctx context.Context, r io.Reader, rid, iid string, slot int) (string, error) {
+// isPermutation reports whether ordered holds exactly the ids of current.
+func isPermutation(current, ordered []int64) bool {
+	if len(current) != len(ordered) {
+		return false
+	}
+
+	seen := make(map[int64]bool, len(current))
+	for _, id := range current {
+		seen[id] = true
+	}
+
+	for _, id := range ordered {
+		if !seen[id] {
+			return false
+		}
+		delete(seen, id)
+	}
+
+	return true
+}
+
+// reorder gives the rows of the listed ids their position in the list. The
+// list must hold every id the current query returns.
+func reorder(ctx context.Context, tx *sql.Tx, current, update string, ordered []int64, args ...interface{}) error {
+	rows, err := tx.QueryContext(ctx, current, args...)
+	if err != nil {
+		return fmt.Errorf("could not query positions: %v", err)
+	}
+
+	defer rows.Close()
+	ids := make([]int64, 0, len(ordered))
+	for rows.Next() {
+		var id int64
+		if err = rows.Scan(&id); err != nil {
+			return fmt.Errorf("could not scan position: %v", err)
+		}
+
+		ids = append(ids, id)
+	}
+
+	if err = rows.Err(); err != nil {
+		return fmt.Errorf("could not iterate positions: %v", err)
+	}
+
+	if !isPermutation(ids, ordered) {
+		return ErrInvalidPositions
+	}
+
+	for i, id := range ordered {
+		if _, err = tx.ExecContext(ctx, update, i+1, id); err != nil {
+			return fmt.Errorf("could not update position: %v", err)
+		}
+	}
+
+	return nil
+}
+
+// ReorderCategories of the restaurant's menu. The list holds the ids of all
+// its categories in their new display order.
+func (s *Service) ReorderCategories(ctx context.Context, rid string, ordered []int64) error {
+	uid, auth := ctx.Value(KeyAuthFoodProviderID).(int64)
+	if !auth {
+		return ErrUnauthenticated
+	}
+
+	if !rxUUID.MatchString(rid) {
+		return ErrRestaurantNotFound
+	}
+
+	if _, err := s.checkPermission(ctx, Manager, uid, rid); err != nil {
+		return err
+	}
+
+	if err := checkUnlinked(ctx, s.db, rid); err != nil {
+		return err
+	}
+
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("could not begin tx: %v", err)
+	}
+	defer func() { _ = tx.Rollback() }()
+
+	err = reorder(ctx, tx,
+		"SELECT id FROM category WHERE restaurant = $1 AND archived = false",
+		"UPDATE category SET position = $1 WHERE id = $2",
+		ordered, rid)
+	if err != nil {
+		return err
+	}
+
+	if err = tx.Commit(); err != nil {
+		return fmt.Errorf("could not reorder categories: could not commit transaction: %v", err)
+	}
+
+	s.syncBranches(ctx, rid)
+	return nil
+}
+
+// ReorderItems of a menu category. The list holds the ids of all the items in
+// the category in their new display order.
+func (s *Service) ReorderItems(ctx context.Context, rid string, cid int64, ordered []int64) error {
+	uid, auth := ctx.Value(KeyAuthFoodProviderID).(int64)
+	if !auth {
+		return ErrUnauthenticated
+	}
+
+	if !rxUUID.MatchString(rid) {
+		return ErrRestaurantNotFound
+	}
+
+	if _, err := s.checkPermission(ctx, Manager, uid, rid); err != nil {
+		return err
+	}
+
+	if err := checkUnlinked(ctx, s.db, rid); err != nil {
+		return err
+	}
+
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("could not begin tx: %v", err)
+	}
+	defer func() { _ = tx.Rollback() }()
+
+	var exists bool
+	query := "SELECT EXISTS (SELECT 1 FROM category WHERE id = $1 AND restaurant = $2 AND archived = false)"
+	if err = tx.QueryRowContext(ctx, query, cid, rid).Scan(&exists); err != nil {
+		return fmt.Errorf("could not query category: %v", err)
+	}
+
+	if !exists {
+		return ErrCategoryNotFound
+	}
+
+	err = reorder(ctx, tx,
+		"SELECT id FROM item WHERE category_id = $1 AND restaurant_id = $2 AND archived = false",
+		"UPDATE item SET position = $1 WHERE id = $2",
+		ordered, cid, rid)
+	if err != nil {
+		return err
+	}
+
+	if err = tx.Commit(); err != nil {
+		return fmt.Errorf("could not reorder items: could not commit transaction: %v", err)
+	}
+
+	s.syncBranches(ctx, rid)
+	return nil
+}
+
+// itemPictureColumns of item_gallery by slot.
+var itemPictureColumns = [...]string{"image1", "image2", "image3"}
+
+// itemPictureColumn of item_gallery holding a gallery slot.
+func itemPictureColumn(slot int) (string, error) {
+	if slot < 1 || slot > len(itemPictureColumns) {
+		return "", ErrInvalidPictureSlot
+	}
+
+	return itemPictureColumns[slot-1], nil
+}
+
+// ItemPicture is a picture in one of an item's gallery slots.
+type ItemPicture struct {
+	Slot int    `json:"slot"`
+	URL  string `json:"url"`
+}
+
+// UpdateItemPicture puts a picture in one of the three gallery slots of a
+// menu item, replacing the one there, and returns its URL.
+func (s *Service) UpdateItemPicture(ctx context.Context, r io.Reader, rid string, iid int64, slot int) (string, error) {
 	uid, ok := ctx.Value(KeyAuthFoodProviderID).(int64)
 	if !ok {
 		return "", ErrUnauthenticated
@@ -192,74 +674,147 @@ func (s *Service) UpdateItemPicture(ctx context.Context, r io.Reader, rid, iid s
 		return "", ErrInvalidRestaurantId
 	}
 
+	column, err := itemPictureColumn(slot)
+	if err != nil {
+		return "", err
+	}
+
 	if _, err := s.checkPermission(ctx, Manager, uid, rid); err != nil {
-		fmt.Println("Permission Failed!")
-		return "", ErrUnauthenticated
+		return "", err
 	}
 
-	r = io.LimitReader(r, MaxImageBytes)
-	img, format, err := image.Decode(r)
-	if err == image.ErrFormat {
-		return "", ErrUnsupportedImageFormat
+	if err := checkUnlinked(ctx, s.db, rid); err != nil {
+		return "", err
 	}
 
-	if err != nil {
-		return "", fmt.Errorf("could not read dp: %v", err)
+	var exists bool
+	query := "SELECT EXISTS (SELECT 1 FROM item WHERE id = $1 AND restaurant_id = $2 AND archived = false)"
+	if err := s.db.QueryRowContext(ctx, query, iid, rid).Scan(&exists); err != nil {
+		return "", fmt.Errorf("could not query item: %v", err)
 	}
 
-	if format != "png" && format != "jpeg" {
-		return "", ErrUnsupportedImageFormat
+	if !exists {
+		return "", ErrItemNotFound
 	}
 
-	dp, err := gonanoid.Nanoid()
+	dir := path.Join(restaurantDir, rid, "items")
+	picture, err := saveImage(r, dir, 800, 800)
 	if err != nil {
-		return "", fmt.Errorf("could not generate dp filename: %v", err)
+		return "", err
+	}
+
+	var old sql.NullString
+	query = `
+		INSERT INTO item_gallery (item_id, ` + column + `)
+		VALUES ($1, $2)
+		ON CONFLICT (item_id) DO UPDATE SET ` + column + ` = excluded.` + column + `
+		RETURNING (SELECT ` + column + ` FROM item_gallery WHERE item_id = $1)`
+	if err = s.db.QueryRowContext(ctx, query, iid, picture).Scan(&old); err != nil {
+		defer os.Remove(path.Join(dir, picture))
+		return "", fmt.Errorf("could not update item picture: %v", err)
 	}
 
-	if format == "png" {
-		dp += ".png"
-	} else {
-		dp += ".jpg"
+	if old.Valid && old.String != "" {
+		defer os.Remove(path.Join(dir, old.String))
 	}
 
-	displayPicturePath := path.Join(restaurantDir, rid)
-	if _, err := os.Stat(displayPicturePath); os.IsNotExist(err) {
-		err = os.Mkdir(displayPicturePath, os.ModeDir)
-		return "", fmt.Errorf("failed to create path for image: %v", err)
+	s.menuChanged(ctx, rid)
+
+	return s.itemPictureURL(rid, picture), nil
+}
+
+// DeleteItemPicture in a gallery slot of a menu item.
+func (s *Service) DeleteItemPicture(ctx context.Context, rid string, iid int64, slot int) error {
+	uid, ok := ctx.Value(KeyAuthFoodProviderID).(int64)
+	if !ok {
+		return ErrUnauthenticated
 	}
-	displayPicturePath = path.Join(displayPicturePath, dp)
-	f, err := os.Create(displayPicturePath)
+
+	if !rxUUID.MatchString(rid) {
+		return ErrInvalidRestaurantId
+	}
+
+	column, err := itemPictureColumn(slot)
 	if err != nil {
-		return "", fmt.Errorf("could not create dp file: %v", err)
+		return err
 	}
-	defer f.Close()
 
-	img = imaging.Fill(img, 400, 400, imaging.Center, imaging.CatmullRom)
-	if format == "png" {
-		err = png.Encode(f, img)
-	} else {
-		err = jpeg.Encode(f, img, nil)
+	if _, err := s.checkPermission(ctx, Manager, uid, rid); err != nil {
+		return err
 	}
+
+	if err := checkUnlinked(ctx, s.db, rid); err != nil {
+		return err
+	}
+
+	var old sql.NullString
+	query := `
+		UPDATE item_gallery SET ` + column + ` = NULL
+		WHERE item_id = $1 AND EXISTS (SELECT 1 FROM item WHERE id = $1 AND restaurant_id = $2)
+		RETURNING (SELECT ` + column + ` FROM item_gallery WHERE item_id = $1)`
+	err = s.db.QueryRowContext(ctx, query, iid, rid).Scan(&old)
+	if err == sql.ErrNoRows {
+		return ErrItemPictureNotFound
+	}
+
 	if err != nil {
-		return "", fmt.Errorf("could not write dp to disk: %v", err)
+		return fmt.Errorf("could not delete item picture: %v", err)
 	}
 
-	var oldDp sql.NullString
-	if err = s.db.QueryRowContext(ctx, `
-		UPDATE restaurant SET avatar = $1 WHERE id = $2
-		RETURNING (SELECT avatar FROM restaurant WHERE id = $2) AS old_dp`, dp, rid).
-		Scan(&oldDp); err != nil {
-		defer os.Remove(displayPicturePath)
-		return "", fmt.Errorf("could not update dp: %v", err)
+	if !old.Valid || old.String == "" {
+		return ErrItemPictureNotFound
 	}
 
-	if oldDp.Valid {
-		defer os.Remove(path.Join(restaurantDir, rid, oldDp.String))
+	defer os.Remove(path.Join(restaurantDir, rid, "items", old.String))
+
+	s.menuChanged(ctx, rid)
+
+	return nil
+}
+
+// GetItemPictures in the gallery of a menu item.
+func (s *Service) GetItemPictures(ctx context.Context, rid string, iid int64) ([]ItemPicture, error) {
+	if !rxUUID.MatchString(rid) {
+		return nil, ErrInvalidRestaurantId
 	}
-	dpURL := s.origin
-	dpURL.Path = "/img/restaurant/" + rid + "/" + dp
 
-	return dpURL.String(), nil
+	var exists bool
+	query := "SELECT EXISTS (SELECT 1 FROM item WHERE id = $1 AND restaurant_id = $2 AND archived = false)"
+	if err := s.db.QueryRowContext(ctx, query, iid, rid).Scan(&exists); err != nil {
+		return nil, fmt.Errorf("could not query item: %v", err)
+	}
+
+	if !exists {
+		return nil, ErrItemNotFound
+	}
+
+	var images [3]sql.NullString
+	query = "SELECT image1, image2, image3 FROM item_gallery WHERE item_id = $1"
+	err := s.db.QueryRowContext(ctx, query, iid).Scan(&images[0], &images[1], &images[2])
+	if err != nil && err != sql.ErrNoRows {
+		return nil, fmt.Errorf("could not query item pictures: %v", err)
+	}
+
+	pp := make([]ItemPicture, 0, len(images))
+	for i, img := range images {
+		if img.Valid && img.String != "" {
+			pp = append(pp, ItemPicture{Slot: i + 1, URL: s.itemPictureURL(rid, img.String)})
+		}
+	}
+
+	return pp, nil
+}
+
+// itemPictures turns the gallery columns of an item into picture URLs.
+func (s *Service) itemPictures(rid string, images [3]sql.NullString) []string {
+	pp := make([]string, 0, len(images))
+	for _, img := range images {
+		if img.Valid && img.String != "" {
+			pp = append(pp, s.itemPictureURL(rid, img.String))
+		}
+	}
+
+	return pp
 }
 
 func (s *Service) GetMenuForFp(ctx context.Context, rid string) (*[]Item, error) {
@@ -272,10 +827,19 @@ func (s *Service) GetMenuForFp(ctx context.Context, rid string) (*[]Item, error)
 		return nil, ErrRestaurantNotFound
 	}
 
+	tags, err := itemTags(ctx, s.db, rid)
+	if err != nil {
+		return nil, err
+	}
+
 	query := `
-		SELECT i.id, c.label, c.availability, i.name, i.description, i.price, i.availability
- 		FROM category c INNER JOIN item i ON c.id = i.category_id
-  		WHERE i.restaurant_id = $1`
+		SELECT i.id, c.label, c.availability, i.name, i.description, i.price, i.availability AND NOT i.sold_out, i.sold_out,
+			i.spice_level, g.image1, g.image2, g.image3
+ 		FROM category c
+			INNER JOIN item i ON c.id = i.category_id
+			LEFT JOIN item_gallery g ON i.id = g.item_id
+  		WHERE i.restaurant_id = $1 AND i.archived = false
+		ORDER BY c.position, c.id, i.position, i.id`
 	rows, err := s.db.QueryContext(ctx, query, rid)
 	if err == sql.ErrNoRows {
 		fmt.Println("[NO ROWS]:", err)
@@ -286,10 +850,17 @@ func (s *Service) GetMenuForFp(ctx context.Context, rid string) (*[]Item, error)
 	defer rows.Close()
 	for rows.Next() {
 		var i Item
-		if err = rows.Scan(&i.Id, &i.Category, &i.CategoryAvailable, &i.Name, &i.Description, &i.Price, &i.Availability); err != nil {
+		var spice int
+		var images [3]sql.NullString
+		if err = rows.Scan(&i.Id, &i.Category, &i.CategoryAvailable, &i.Name, &i.Description, &i.Price, &i.Availability, &i.SoldOut,
+			&spice, &images[0], &images[1], &images[2]); err != nil {
 			return nil, fmt.Errorf("could not get item: %v", err)
 		}
 
+		iid, _ := strconv.ParseInt(i.Id, 10, 64)
+		i.Pictures = s.itemPictures(rid, images)
+		i.Dietary = dietaryOf(tags, iid, spice)
+
 		m = append(m, i)
 	}
 
@@ -300,6 +871,164 @@ func (s *Service) GetMenuForFp(ctx context.Context, rid string) (*[]Item, error)
 	return &m, nil
 }
 
+// GetMenu of a restaurant for customers in the locale of the context. Only
+// available categories with at least one available item that is not sold out
+// and matches the filter are listed.
+func (s *Service) GetMenu(ctx context.Context, rid string, filter MenuFilter) ([]MenuCategory, error) {
+	if !rxUUID.MatchString(rid) {
+		return nil, ErrRestaurantNotFound
+	}
+
+	var err error
+	if filter.Tags, err = normalizeTags(filter.Tags, DietaryTags); err != nil {
+		return nil, err
+	}
+
+	if filter.ExcludeAllergens, err = normalizeTags(filter.ExcludeAllergens, Allergens); err != nil {
+		return nil, err
+	}
+
+	if filter.MaxSpice != nil && (*filter.MaxSpice < SpiceNone || *filter.MaxSpice > SpiceHot) {
+		return nil, ErrInvalidSpiceLevel
+	}
+
+	var exists bool
+	query := "SELECT EXISTS (SELECT 1 FROM restaurant WHERE id = $1 AND active = true)"
+	if err = s.db.QueryRowContext(ctx, query, rid).Scan(&exists); err != nil {
+		return nil, fmt.Errorf("could not query restaurant existence: %v", err)
+	}
+
+	if !exists {
+		return nil, ErrRestaurantNotFound
+	}
+
+	offCategories, offItems, err := offSchedule(ctx, s.db, rid)
+	if err != nil {
+		return nil, err
+	}
+
+	tags, err := itemTags(ctx, s.db, rid)
+	if err != nil {
+		return nil, err
+	}
+
+	// Translations fall back to the untranslated text field by field.
+	query = `
+		SELECT c.id, COALESCE(NULLIF(ct.label, ''), c.label), i.id, COALESCE(NULLIF(it.name, ''), i.name),
+			COALESCE(NULLIF(it.description, ''), i.description), i.price, i.spice_level, g.image1, g.image2, g.image3
+		FROM category c
+			INNER JOIN item i ON c.id = i.category_id
+			LEFT JOIN item_gallery g ON i.id = g.item_id
+			LEFT JOIN category_translation ct ON c.id = ct.category_id AND ct.locale = $2
+			LEFT JOIN item_translation it ON i.id = it.item_id AND it.locale = $2
+		WHERE i.restaurant_id = $1 AND c.availability = true AND i.availability = true AND i.sold_out = false
+			AND i.archived = false
+		ORDER BY c.position, c.id, i.position, i.id`
+	rows, err := s.db.QueryContext(ctx, query, rid, localeOf(ctx))
+	if err != nil {
+		return nil, fmt.Errorf("could not query menu: %v", err)
+	}
+
+	defer rows.Close()
+	m := make([]MenuCategory, 0)
+	for rows.Next() {
+		var c MenuCategory
+		var i MenuItem
+		var iid int64
+		var spice int
+		var images [3]sql.NullString
+		if err = rows.Scan(&c.Id, &c.Label, &iid, &i.Name, &i.Description, &i.Price, &spice,
+			&images[0], &images[1], &images[2]); err != nil {
+			return nil, fmt.Errorf("could not scan menu item: %v", err)
+		}
+
+		if offCategories[c.Id] || offItems[iid] {
+			continue
+		}
+
+		i.Dietary = dietaryOf(tags, iid, spice)
+		if !filter.matches(i.Dietary) {
+			continue
+		}
+
+		i.Id = strconv.FormatInt(iid, 10)
+
+		i.Pictures = s.itemPictures(rid, images)
+
+		if len(m) == 0 || m[len(m)-1].Id != c.Id {
+			c.Items = make([]MenuItem, 0)
+			m = append(m, c)
+		}
+
+		last := &m[len(m)-1]
+		last.Items = append(last.Items, i)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("could not iterate menu: %v", err)
+	}
+
+	opts, err := itemOptions(ctx, s.db, rid, 0)
+	if err != nil {
+		return nil, err
+	}
+
+	combos, err := comboSlots(ctx, s.db, rid, 0)
+	if err != nil {
+		return nil, err
+	}
+
+	pricing, err := activePricing(ctx, s.db, rid)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, c := range m {
+		for j, i := range c.Items {
+			iid, _ := strconv.ParseInt(i.Id, 10, 64)
+			if price, rule := pricing.price(iid, c.Id, i.Price); rule != nil {
+				c.Items[j].Price, c.Items[j].RegularPrice, c.Items[j].Deal = price, i.Price, rule.Name
+			}
+
+			if o, ok := opts[iid]; ok {
+				for k, v := range o.Variants {
+					o.Variants[k].Price, _ = pricing.price(iid, c.Id, v.Price)
+				}
+				c.Items[j].Variants = o.Variants
+				c.Items[j].Modifiers = o.Modifiers
+			}
+			c.Items[j].Combo = combos[iid]
+			scheduleChoices(c.Items[j].Combo, offCategories, offItems)
+		}
+	}
+
+	// Options and combo choices are only known now. Items that can't be
+	// ordered without their excluded allergens go, so do combos with a slot
+	// nothing can fill and categories left empty.
+	filtered := make([]MenuCategory, 0, len(m))
+	for _, c := range m {
+		items := make([]MenuItem, 0, len(c.Items))
+		for _, i := range c.Items {
+			if filter.trimOptions(&i, tags) && comboAvailable(i.Combo) {
+				items = append(items, i)
+			}
+		}
+
+		if len(items) != 0 {
+			c.Items = items
+			filtered = append(filtered, c)
+		}
+	}
+
+	return filtered, nil
+}
+
+func (s *Service) itemPictureURL(rid, image string) string {
+	u := s.origin
+	u.Path = "/img/restaurant/" + rid + "/items/" + image
+	return u.String()
+}
+
 //func (s *Service) GetMenuById(ctx context.Context, id string) (*[]Item, error) {
 //	_, auth := ctx.Value(KeyAuthFoodProviderID).(int64)
 //	if !auth {
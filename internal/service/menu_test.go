@@ -3,6 +3,8 @@ package service
 import (
 	"context"
 	"net/url"
+	"strconv"
+	"strings"
 	"testing"
 
 	"github.com/google/go-cmp/cmp"
@@ -49,12 +51,12 @@ func TestCategory(t *testing.T) {
 		log.Fatalf("failed to validate schema: %v\n", err)
 	}
 
-	s := New(db, nil, codec, fpCodec, nil, url.URL{})
+	s := New(db, codec, fpCodec, nil, url.URL{})
 
 	_ = s.CreateFoodProvider(ctx, "johndoe@gmail.com", "John Snow", "01867584576", "ilovegolang")
 	user, _ := s.FoodProviderLogin(ctx, "01867584576", "ilovegolang")
 	ctx = context.WithValue(ctx, KeyAuthFoodProviderID, user.AuthUser.ID)
-	_ = s.CreateRestaurant(ctx, "test.Title", "test.About", "01616534596", "test.Location", "test.City", "test.Area", "test.Country", "11AM", "10PM")
+	_ = s.CreateRestaurant(ctx, "test.Title", "test.About", "01616534596", "test.Location", "test.City", "test.Area", "test.Country")
 	user, _ = s.FoodProviderLogin(ctx, "01867584576", "ilovegolang")
 	r := user.Restaurants
 	rid := (*r)[0].Id
@@ -127,7 +129,7 @@ func TestMenu(t *testing.T) {
 	user, _ := s.FoodProviderLogin(ctx, "01867584576", "ilovegolang")
 	log.Println("AUTH:", user)
 	ctx = context.WithValue(ctx, KeyAuthFoodProviderID, user.AuthUser.ID)
-	_ = s.CreateRestaurant(ctx, "test.Title", "test.About", "01616534596", "test.Location", "test.City", "test.Area", "test.Country", "11AM", "10PM")
+	_ = s.CreateRestaurant(ctx, "test.Title", "test.About", "01616534596", "test.Location", "test.City", "test.Area", "test.Country")
 	user, _ = s.FoodProviderLogin(ctx, "01867584576", "ilovegolang")
 	//ctx = context.WithValue(ctx, KeyAuthFoodProviderID, user.AuthUser.ID)
 	r := user.Restaurants
@@ -163,3 +165,107 @@ func TestMenu(t *testing.T) {
 		})
 	}
 }
+
+func TestIsPermutation(t *testing.T) {
+	var tt = []struct {
+		Label   string
+		Ordered []int64
+		Want    bool
+	}{
+		{Label: "Test should accept every id in a new order", Ordered: []int64{3, 1, 2}, Want: true},
+		{Label: "Test should reject a missing id", Ordered: []int64{3, 1}, Want: false},
+		{Label: "Test should reject a repeated id", Ordered: []int64{3, 1, 1}, Want: false},
+		{Label: "Test should reject an unknown id", Ordered: []int64{3, 1, 4}, Want: false},
+	}
+
+	for _, test := range tt {
+		t.Run(test.Label, func(t *testing.T) {
+			if got := isPermutation([]int64{1, 2, 3}, test.Ordered); got != test.Want {
+				t.Error("Got:", got, "| Want:", test.Want)
+			}
+		})
+	}
+}
+
+func TestItemPictureColumn(t *testing.T) {
+	var tt = []struct {
+		Label  string
+		Slot   int
+		Column string
+		Want   error
+	}{
+		{Label: "Test should reject a negative slot", Slot: -1, Want: ErrInvalidPictureSlot},
+		{Label: "Test should reject slot 0", Slot: 0, Want: ErrInvalidPictureSlot},
+		{Label: "Test should map the first slot", Slot: 1, Column: "image1"},
+		{Label: "Test should map the last slot", Slot: 3, Column: "image3"},
+		{Label: "Test should reject the slot after the last", Slot: 4, Want: ErrInvalidPictureSlot},
+	}
+
+	for _, test := range tt {
+		t.Run(test.Label, func(t *testing.T) {
+			column, err := itemPictureColumn(test.Slot)
+			if err != test.Want || column != test.Column {
+				t.Error("Got:", column, err, "| Want:", test.Column, test.Want)
+			}
+		})
+	}
+}
+
+func TestItemPictureLinked(t *testing.T) {
+	tearDown := SetupTest()
+	defer tearDown()
+
+	ctx := context.TODO()
+
+	codec := branca.NewBranca("supersecretkeyyoushouldnotcommit")
+	codec.SetTTL(uint32(TokenLifeSpan.Seconds()))
+
+	fpCodec := branca.NewBranca("supersecretkeyyoushouldcommitnot")
+	fpCodec.SetTTL(uint32(TokenLifeSpan.Seconds()))
+
+	c, err := pgx.ParseURI(pgURL.String())
+	if err != nil {
+		log.Fatalf(err.Error())
+	}
+
+	db := stdlib.OpenDB(c)
+
+	if err := ValidateSchema(db); err != nil {
+		log.Fatalf("failed to validate schema: %v\n", err)
+	}
+
+	s := New(db, codec, fpCodec, nil, url.URL{})
+
+	_ = s.CreateFoodProvider(ctx, "johndoe@gmail.com", "John Snow", "01867584576", "ilovegolang")
+	user, _ := s.FoodProviderLogin(ctx, "01867584576", "ilovegolang")
+	ctx = context.WithValue(ctx, KeyAuthFoodProviderID, user.AuthUser.ID)
+	_ = s.CreateRestaurant(ctx, "test.Master", "test.About", "01616534596", "test.Location", "test.City", "test.Area", "test.Country")
+	_ = s.CreateRestaurant(ctx, "test.Branch", "test.About", "01616534597", "test.Location", "test.City", "test.Area", "test.Country")
+	user, _ = s.FoodProviderLogin(ctx, "01867584576", "ilovegolang")
+	r := *user.Restaurants
+	master, branch := r[0].Id, r[1].Id
+
+	_ = s.CreateCategory(ctx, master, "Breakfast", true)
+	cc, _ := s.GetCategoriesByRestaurant(ctx, master)
+	_ = s.CreateItem(ctx, master, cc[0].Id, "Paratha", "Flaky", 30, true)
+	if err := s.LinkMenu(ctx, branch, master); err != nil {
+		t.Fatal("Got:", err, "| Want: the branch linked")
+	}
+
+	menu, _ := s.GetMenuForFp(ctx, branch)
+	iid, _ := strconv.ParseInt((*menu)[0].Id, 10, 64)
+
+	t.Run("Test should not update a picture of a linked branch", func(t *testing.T) {
+		_, err := s.UpdateItemPicture(ctx, strings.NewReader(""), branch, iid, 1)
+		if err != ErrMenuLinked {
+			t.Error("Got:", err, "| Want:", ErrMenuLinked)
+		}
+	})
+
+	t.Run("Test should not delete a picture of a linked branch", func(t *testing.T) {
+		err := s.DeleteItemPicture(ctx, branch, iid, 1)
+		if err != ErrMenuLinked {
+			t.Error("Got:", err, "| Want:", ErrMenuLinked)
+		}
+	})
+}
@@ -0,0 +1,357 @@
+package service
+
+import (
+	"context"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+)
+
+// ErrInvalidImport denotes an import file that could not be read at all.
+var ErrInvalidImport = errors.New("invalid import file")
+
+const maxMenuNameLength = 25
+
+// MenuColumns of menu import and export files.
+var MenuColumns = []string{"category", "name", "description", "price", "availability"}
+
+// MenuRow is an item in a menu import or export.
+type MenuRow struct {
+	Category     string  `json:"category"`
+	Name         string  `json:"name"`
+	Description  string  `json:"description"`
+	Price        float64 `json:"price"`
+	Availability *bool   `json:"availability,omitempty"`
+}
+
+// ImportProblem is a reason a row can't be imported. Rows count from one,
+// not counting a CSV header.
+type ImportProblem struct {
+	Row     int    `json:"row"`
+	Field   string `json:"field"`
+	Problem string `json:"problem"`
+}
+
+// ImportReport tells what an import did or, in a dry run, would do.
+type ImportReport struct {
+	Items         int             `json:"items"`
+	NewCategories []string        `json:"new_categories"`
+	Problems      []ImportProblem `json:"problems"`
+	Applied       bool            `json:"applied"`
+}
+
+// Records of the row in MenuColumns order.
+func (r MenuRow) Records() []string {
+	return []string{
+		r.Category,
+		r.Name,
+		r.Description,
+		strconv.FormatFloat(r.Price, 'f', 2, 64),
+		strconv.FormatBool(r.Availability == nil || *r.Availability),
+	}
+}
+
+// parseMenuCSV reads menu rows from CSV with a header naming its columns.
+// Unparsable prices and availabilities are reported as problems.
+func parseMenuCSV(r io.Reader) ([]MenuRow, []ImportProblem, error) {
+	cr := csv.NewReader(r)
+	cr.FieldsPerRecord = -1
+	cr.TrimLeadingSpace = true
+
+	header, err := cr.Read()
+	if err != nil {
+		return nil, nil, ErrInvalidImport
+	}
+
+	columns := make(map[string]int)
+	for i, h := range header {
+		columns[strings.ToLower(strings.TrimSpace(h))] = i
+	}
+
+	for _, c := range []string{"category", "name", "price"} {
+		if _, ok := columns[c]; !ok {
+			return nil, nil, ErrInvalidImport
+		}
+	}
+
+	rows := make([]MenuRow, 0)
+	problems := make([]ImportProblem, 0)
+	for n := 1; ; n++ {
+		record, err := cr.Read()
+		if err == io.EOF {
+			break
+		}
+
+		if err != nil {
+			return nil, nil, ErrInvalidImport
+		}
+
+		field := func(c string) string {
+			if i, ok := columns[c]; ok && i < len(record) {
+				return strings.TrimSpace(record[i])
+			}
+			return ""
+		}
+
+		row := MenuRow{Category: field("category"), Name: field("name"), Description: field("description")}
+		if row.Price, err = strconv.ParseFloat(field("price"), 64); err != nil {
+			problems = append(problems, ImportProblem{Row: n, Field: "price", Problem: "not a number"})
+		}
+
+		if a := field("availability"); a != "" {
+			switch strings.ToLower(a) {
+			case "true", "yes", "1":
+				row.Availability = new(bool)
+				*row.Availability = true
+			case "false", "no", "0":
+				row.Availability = new(bool)
+			default:
+				problems = append(problems, ImportProblem{Row: n, Field: "availability", Problem: "not true or false"})
+			}
+		}
+
+		rows = append(rows, row)
+	}
+
+	return rows, problems, nil
+}
+
+// checkMenuRows validates the rows against each other and the current menu,
+// keyed by lower case names. Categories that don't exist yet are returned so
+// they can be created.
+func checkMenuRows(rows []MenuRow, items, categories map[string]bool) ([]string, []ImportProblem) {
+	newCategories := make([]string, 0)
+	problems := make([]ImportProblem, 0)
+	names := make(map[string]int)
+	created := make(map[string]bool)
+	for i, r := range rows {
+		n := i + 1
+		if r.Category == "" {
+			problems = append(problems, ImportProblem{Row: n, Field: "category", Problem: "empty"})
+		} else if len([]rune(r.Category)) > maxMenuNameLength {
+			problems = append(problems, ImportProblem{Row: n, Field: "category", Problem: "too long"})
+		} else if c := strings.ToLower(r.Category); !categories[c] && !created[c] {
+			created[c] = true
+			newCategories = append(newCategories, r.Category)
+		}
+
+		name := strings.ToLower(r.Name)
+		if r.Name == "" {
+			problems = append(problems, ImportProblem{Row: n, Field: "name", Problem: "empty"})
+		} else if len([]rune(r.Name)) > maxMenuNameLength {
+			problems = append(problems, ImportProblem{Row: n, Field: "name", Problem: "too long"})
+		} else if items[name] {
+			problems = append(problems, ImportProblem{Row: n, Field: "name", Problem: "already on the menu"})
+		} else if first, ok := names[name]; ok {
+			problems = append(problems, ImportProblem{Row: n, Field: "name", Problem: fmt.Sprintf("duplicate of row %d", first)})
+		} else {
+			names[name] = n
+		}
+
+		if len([]rune(r.Description)) > 255 {
+			problems = append(problems, ImportProblem{Row: n, Field: "description", Problem: "too long"})
+		}
+
+		if r.Price <= 0 {
+			problems = append(problems, ImportProblem{Row: n, Field: "price", Problem: "must be positive"})
+		}
+	}
+
+	return newCategories, problems
+}
+
+// ImportMenuCSV adds the items of a CSV file to the restaurant's menu. See
+// ImportMenu.
+func (s *Service) ImportMenuCSV(ctx context.Context, rid string, r io.Reader, dryRun bool) (ImportReport, error) {
+	rows, problems, err := parseMenuCSV(r)
+	if err != nil {
+		return ImportReport{}, err
+	}
+
+	return s.importMenu(ctx, rid, rows, problems, dryRun)
+}
+
+// ImportMenu adds the items to the restaurant's menu, creating their
+// categories as needed. Nothing is imported unless every row is valid, and
+// nothing at all in a dry run; the report tells what would happen.
+func (s *Service) ImportMenu(ctx context.Context, rid string, rows []MenuRow, dryRun bool) (ImportReport, error) {
+	return s.importMenu(ctx, rid, rows, nil, dryRun)
+}
+
+func (s *Service) importMenu(ctx context.Context, rid string, rows []MenuRow, problems []ImportProblem, dryRun bool) (ImportReport, error) {
+	var report ImportReport
+	uid, auth := ctx.Value(KeyAuthFoodProviderID).(int64)
+	if !auth {
+		return report, ErrUnauthenticated
+	}
+
+	if !rxUUID.MatchString(rid) {
+		return report, ErrRestaurantNotFound
+	}
+
+	if _, err := s.checkPermission(ctx, Manager, uid, rid); err != nil {
+		return report, err
+	}
+
+	if err := checkUnlinked(ctx, s.db, rid); err != nil {
+		return report, err
+	}
+
+	for i := range rows {
+		rows[i].Category = strings.TrimSpace(rows[i].Category)
+		rows[i].Name = strings.TrimSpace(rows[i].Name)
+		rows[i].Description = strings.TrimSpace(rows[i].Description)
+	}
+
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return report, fmt.Errorf("could not begin tx: %v", err)
+	}
+	defer func() { _ = tx.Rollback() }()
+
+	// Names are only unique among active items and categories, as in the
+	// schema, so archived ones don't block the rows a dry run lets through.
+	items := make(map[string]bool)
+	rs, err := tx.QueryContext(ctx, "SELECT name FROM item WHERE restaurant_id = $1 AND archived = false", rid)
+	if err != nil {
+		return report, fmt.Errorf("could not query items: %v", err)
+	}
+
+	defer rs.Close()
+	for rs.Next() {
+		var name string
+		if err = rs.Scan(&name); err != nil {
+			return report, fmt.Errorf("could not scan item: %v", err)
+		}
+
+		items[strings.ToLower(name)] = true
+	}
+
+	if err = rs.Err(); err != nil {
+		return report, fmt.Errorf("could not iterate items: %v", err)
+	}
+
+	categories := make(map[string]int64)
+	exists := make(map[string]bool)
+	rs, err = tx.QueryContext(ctx, "SELECT id, label FROM category WHERE restaurant = $1 AND archived = false", rid)
+	if err != nil {
+		return report, fmt.Errorf("could not query categories: %v", err)
+	}
+
+	defer rs.Close()
+	for rs.Next() {
+		var id int64
+		var label string
+		if err = rs.Scan(&id, &label); err != nil {
+			return report, fmt.Errorf("could not scan category: %v", err)
+		}
+
+		categories[strings.ToLower(label)] = id
+		exists[strings.ToLower(label)] = true
+	}
+
+	if err = rs.Err(); err != nil {
+		return report, fmt.Errorf("could not iterate categories: %v", err)
+	}
+
+	newCategories, rowProblems := checkMenuRows(rows, items, exists)
+	report.Items = len(rows)
+	report.NewCategories = newCategories
+	report.Problems = append(problems, rowProblems...)
+	if report.Problems == nil {
+		report.Problems = make([]ImportProblem, 0)
+	}
+
+	if dryRun || len(report.Problems) != 0 {
+		return report, nil
+	}
+
+	query := `
+		INSERT INTO category (restaurant, label, position)
+		VALUES ($1, $2, (SELECT COALESCE(MAX(position), 0) + 1 FROM category WHERE restaurant = $1))
+		RETURNING id`
+	for _, label := range newCategories {
+		var id int64
+		err = tx.QueryRowContext(ctx, query, rid, label).Scan(&id)
+		if isUniqueViolation(err) {
+			return report, ErrTitleTaken
+		}
+
+		if err != nil {
+			return report, fmt.Errorf("could not create category: %v", err)
+		}
+
+		categories[strings.ToLower(label)] = id
+	}
+
+	query = `
+		INSERT INTO item (restaurant_id, category_id, name, description, price, availability, position)
+		VALUES ($1, $2, $3, $4, $5, $6, (SELECT COALESCE(MAX(position), 0) + 1 FROM item WHERE category_id = $2))`
+	for _, r := range rows {
+		available := r.Availability == nil || *r.Availability
+		_, err = tx.ExecContext(ctx, query, rid, categories[strings.ToLower(r.Category)], r.Name, r.Description, r.Price, available)
+		if isUniqueViolation(err) {
+			return report, ErrItemAlreadyExists
+		}
+
+		if err != nil {
+			return report, fmt.Errorf("could not import item: %v", err)
+		}
+	}
+
+	if err = tx.Commit(); err != nil {
+		return report, fmt.Errorf("could not import menu: could not commit transaction: %v", err)
+	}
+
+	s.menuChanged(ctx, rid)
+	report.Applied = true
+	return report, nil
+}
+
+// ExportMenu of the restaurant in display order, ready to be imported into
+// another branch.
+func (s *Service) ExportMenu(ctx context.Context, rid string) ([]MenuRow, error) {
+	uid, auth := ctx.Value(KeyAuthFoodProviderID).(int64)
+	if !auth {
+		return nil, ErrUnauthenticated
+	}
+
+	if !rxUUID.MatchString(rid) {
+		return nil, ErrRestaurantNotFound
+	}
+
+	if _, err := s.checkPermission(ctx, Waiter, uid, rid); err != nil {
+		return nil, err
+	}
+
+	query := `
+		SELECT c.label, i.name, i.description, i.price, i.availability
+		FROM category c INNER JOIN item i ON c.id = i.category_id
+		WHERE i.restaurant_id = $1 AND i.archived = false
+		ORDER BY c.position, c.id, i.position, i.id`
+	rows, err := s.db.QueryContext(ctx, query, rid)
+	if err != nil {
+		return nil, fmt.Errorf("could not query menu: %v", err)
+	}
+
+	defer rows.Close()
+	mm := make([]MenuRow, 0)
+	for rows.Next() {
+		var m MenuRow
+		m.Availability = new(bool)
+		if err = rows.Scan(&m.Category, &m.Name, &m.Description, &m.Price, m.Availability); err != nil {
+			return nil, fmt.Errorf("could not scan menu item: %v", err)
+		}
+
+		mm = append(mm, m)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("could not iterate menu: %v", err)
+	}
+
+	return mm, nil
+}
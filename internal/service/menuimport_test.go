@@ -0,0 +1,66 @@
+package service
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestParseMenuCSV(t *testing.T) {
+	in := "Name,Category,Price,Availability\n" +
+		"Chicken Biryani,Rice,250,yes\n" +
+		"Borhani,Drinks,sixty,\n" +
+		"Firni,Dessert,80,maybe\n"
+
+	rows, problems, err := parseMenuCSV(strings.NewReader(in))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(rows) != 3 {
+		t.Fatal("Got:", len(rows), "rows | Want:", 3)
+	}
+
+	if rows[0].Name != "Chicken Biryani" || rows[0].Category != "Rice" || rows[0].Price != 250 ||
+		rows[0].Availability == nil || !*rows[0].Availability {
+		t.Error("Got:", rows[0], "| Want: Chicken Biryani in Rice for 250")
+	}
+
+	want := []ImportProblem{
+		{Row: 2, Field: "price", Problem: "not a number"},
+		{Row: 3, Field: "availability", Problem: "not true or false"},
+	}
+	if !cmp.Equal(problems, want) {
+		t.Error("Got:", problems, "| Want:", want)
+	}
+
+	if _, _, err = parseMenuCSV(strings.NewReader("name,price\nTea,20\n")); err != ErrInvalidImport {
+		t.Error("Got:", err, "| Want:", ErrInvalidImport)
+	}
+}
+
+func TestCheckMenuRows(t *testing.T) {
+	rows := []MenuRow{
+		{Category: "Rice", Name: "Kacchi", Price: 350},
+		{Category: "drinks", Name: "Borhani", Price: 60},
+		{Category: "Drinks", Name: "borhani", Price: 60},
+		{Category: "Dessert", Name: "Firni", Price: 0},
+		{Category: "Rice", Name: "Khichuri", Price: 180},
+	}
+
+	newCategories, problems := checkMenuRows(rows, map[string]bool{"khichuri": true}, map[string]bool{"rice": true})
+
+	if want := []string{"drinks", "Dessert"}; !cmp.Equal(newCategories, want) {
+		t.Error("Got:", newCategories, "| Want:", want)
+	}
+
+	want := []ImportProblem{
+		{Row: 3, Field: "name", Problem: "duplicate of row 2"},
+		{Row: 4, Field: "price", Problem: "must be positive"},
+		{Row: 5, Field: "name", Problem: "already on the menu"},
+	}
+	if !cmp.Equal(problems, want) {
+		t.Error("Got:", problems, "| Want:", want)
+	}
+}
@@ -0,0 +1,490 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+var (
+	// ErrInvalidMenuLink denotes linking a restaurant to itself, to a branch or while already linked.
+	ErrInvalidMenuLink = errors.New("invalid menu link")
+	// ErrMenuLinkNotFound denotes a restaurant not linked to a master menu.
+	ErrMenuLinkNotFound = errors.New("menu link not found")
+	// ErrMenuLinked denotes an edit to the menu of a branch linked to a master, which the next sync would undo.
+	ErrMenuLinked = errors.New("menu linked to a master")
+)
+
+// LinkedFields of master menu items that branches follow, besides which
+// categories there are with their labels, availability and order. Pictures,
+// tags, options, combos, schedules and pricing rules stay the branch's own.
+var LinkedFields = []string{"name", "description", "price", "availability"}
+
+// MenuLink of a branch whose menu follows its master's, in the fields it
+// lists. SyncError tells why the last sync failed, the branch still has the
+// menu of the one before.
+type MenuLink struct {
+	MasterId     string       `json:"master_id"`
+	MasterTitle  string       `json:"master_title"`
+	LinkedBy     int64        `json:"linked_by"`
+	LinkedAt     time.Time    `json:"linked_at"`
+	SyncedAt     *time.Time   `json:"synced_at"`
+	SyncError    string       `json:"sync_error,omitempty"`
+	LinkedFields []string     `json:"linked_fields"`
+	Items        []LinkedItem `json:"items"`
+}
+
+// LinkedItem is a master menu item as a branch has it. Price and
+// Availability are the branch's overrides, when it has them.
+type LinkedItem struct {
+	ItemId             int64    `json:"item_id"`
+	Name               string   `json:"name"`
+	MasterPrice        float64  `json:"master_price"`
+	MasterAvailability bool     `json:"master_availability"`
+	Price              *float64 `json:"price,omitempty"`
+	Availability       *bool    `json:"availability,omitempty"`
+}
+
+// MenuOverride of a master menu item's price or availability in a branch.
+type MenuOverride struct {
+	Price        *float64 `json:"price"`
+	Availability *bool    `json:"availability"`
+}
+
+// branchMenu is the master menu as a branch with the overrides gets it,
+// keyed by master item id. Ids are dropped since the branch has its own;
+// categories and items are matched by name.
+func branchMenu(master DraftMenu, overrides map[int64]MenuOverride) DraftMenu {
+	m := DraftMenu{Categories: make([]DraftCategory, len(master.Categories))}
+	for i, c := range master.Categories {
+		bc := DraftCategory{Label: c.Label, Availability: c.Availability, Items: make([]DraftItem, len(c.Items))}
+		for j, it := range c.Items {
+			o := overrides[it.Id]
+			bi := DraftItem{Name: it.Name, Description: it.Description, Price: it.Price, Availability: it.Availability}
+			if o.Price != nil {
+				bi.Price = *o.Price
+			}
+			if o.Availability != nil {
+				bi.Availability = o.Availability
+			}
+			bc.Items[j] = bi
+		}
+		m.Categories[i] = bc
+	}
+
+	return m
+}
+
+// menuOverrides of a branch by master item id.
+func menuOverrides(ctx context.Context, q queryer, branch string) (map[int64]MenuOverride, error) {
+	query := "SELECT item_id, price, availability FROM menu_override WHERE branch_id = $1"
+	rows, err := q.QueryContext(ctx, query, branch)
+	if err != nil {
+		return nil, fmt.Errorf("could not query menu overrides: %v", err)
+	}
+
+	defer rows.Close()
+	oo := make(map[int64]MenuOverride)
+	for rows.Next() {
+		var iid int64
+		var price sql.NullFloat64
+		var availability sql.NullBool
+		if err = rows.Scan(&iid, &price, &availability); err != nil {
+			return nil, fmt.Errorf("could not scan menu override: %v", err)
+		}
+
+		var o MenuOverride
+		if price.Valid {
+			o.Price = &price.Float64
+		}
+		if availability.Valid {
+			o.Availability = &availability.Bool
+		}
+		oo[iid] = o
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("could not iterate menu overrides: %v", err)
+	}
+
+	return oo, nil
+}
+
+// checkUnlinked rejects edits to the menu of a linked branch, since the
+// master's menu would overwrite them on the next sync. Branches change
+// prices and availability through overrides instead.
+func checkUnlinked(ctx context.Context, q queryer, rid string) error {
+	var linked bool
+	query := "SELECT EXISTS (SELECT 1 FROM menu_link WHERE branch_id = $1)"
+	if err := q.QueryRowContext(ctx, query, rid).Scan(&linked); err != nil {
+		return fmt.Errorf("could not query menu link: %v", err)
+	}
+
+	if linked {
+		return ErrMenuLinked
+	}
+
+	return nil
+}
+
+// syncBranch makes the branch's menu its master's with its overrides, price
+// changes recorded as made by uid. The outcome is kept on the link so a
+// failure shows up for the branch.
+func (s *Service) syncBranch(ctx context.Context, master, branch string, uid int64) error {
+	err := s.applyMaster(ctx, master, branch, uid)
+	if err != nil {
+		query := "UPDATE menu_link SET sync_error = $1 WHERE branch_id = $2"
+		if _, uerr := s.db.ExecContext(ctx, query, err.Error(), branch); uerr != nil {
+			return fmt.Errorf("could not record menu sync failure: %v: %v", uerr, err)
+		}
+		return err
+	}
+
+	query := "UPDATE menu_link SET synced_at = now(), sync_error = '' WHERE branch_id = $1"
+	if _, err = s.db.ExecContext(ctx, query, branch); err != nil {
+		return fmt.Errorf("could not record menu sync: %v", err)
+	}
+
+	return nil
+}
+
+func (s *Service) applyMaster(ctx context.Context, master, branch string, uid int64) error {
+	m, err := liveMenu(ctx, s.db, master)
+	if err != nil {
+		return err
+	}
+
+	overrides, err := menuOverrides(ctx, s.db, branch)
+	if err != nil {
+		return err
+	}
+
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("could not begin tx: %v", err)
+	}
+	defer func() { _ = tx.Rollback() }()
+
+	if err = applyMenu(ctx, tx, branch, uid, branchMenu(m, overrides)); err != nil {
+		return err
+	}
+
+	if err = tx.Commit(); err != nil {
+		return fmt.Errorf("could not sync branch menu: could not commit transaction: %v", err)
+	}
+
+	s.indexRestaurant(ctx, branch)
+	return nil
+}
+
+// syncBranches linked to the master after a change to its menu, price
+// changes recorded as made by the food provider behind the change or, for
+// changes nobody made just now like scheduled prices, whoever linked the
+// branch. The change is already stored, so failures are kept on the link and
+// logged; the branch catches up on the next one.
+//
+// Syncing runs inline on the caller's context on purpose: once the request
+// that changed the master returns, its branches serve the same menu. Each
+// branch is applied in its own transaction, so a request cancelled halfway
+// leaves the remaining branches on their previous menu until the next sync.
+func (s *Service) syncBranches(ctx context.Context, master string) {
+	actor, auth := ctx.Value(KeyAuthFoodProviderID).(int64)
+	rows, err := s.db.QueryContext(ctx, "SELECT branch_id, linked_by FROM menu_link WHERE master_id = $1", master)
+	if err != nil {
+		s.logger.WithError(err).WithField("master", master).Error("could not query branches")
+		return
+	}
+
+	type branch struct {
+		id  string
+		uid int64
+	}
+
+	bb := make([]branch, 0)
+	for rows.Next() {
+		var b branch
+		if err = rows.Scan(&b.id, &b.uid); err != nil {
+			rows.Close()
+			s.logger.WithError(err).WithField("master", master).Error("could not scan branch")
+			return
+		}
+
+		bb = append(bb, b)
+	}
+
+	rows.Close()
+	if err = rows.Err(); err != nil {
+		s.logger.WithError(err).WithField("master", master).Error("could not iterate branches")
+		return
+	}
+
+	for _, b := range bb {
+		if auth {
+			b.uid = actor
+		}
+
+		if err = s.syncBranch(ctx, master, b.id, b.uid); err != nil {
+			s.logger.WithError(err).WithFields(logrus.Fields{"master": master, "branch": b.id}).
+				Error("could not sync branch")
+		}
+	}
+}
+
+// menuChanged follows a committed change to the restaurant's menu: the
+// search index and its linked branches catch up.
+func (s *Service) menuChanged(ctx context.Context, rid string) {
+	s.indexRestaurant(ctx, rid)
+	s.syncBranches(ctx, rid)
+}
+
+// CopyMenu of a restaurant to another the caller owns, replacing its menu
+// as a new published version. Categories and items are matched by name;
+// pictures, options and tags stay behind.
+func (s *Service) CopyMenu(ctx context.Context, to, from string) (int, error) {
+	uid, auth := ctx.Value(KeyAuthFoodProviderID).(int64)
+	if !auth {
+		return 0, ErrUnauthenticated
+	}
+
+	if !rxUUID.MatchString(to) || !rxUUID.MatchString(from) {
+		return 0, ErrRestaurantNotFound
+	}
+
+	if to == from {
+		return 0, ErrInvalidMenuLink
+	}
+
+	for _, rid := range []string{to, from} {
+		if _, err := s.checkPermission(ctx, Owner, uid, rid); err != nil {
+			return 0, err
+		}
+	}
+
+	if err := checkUnlinked(ctx, s.db, to); err != nil {
+		return 0, err
+	}
+
+	if err := s.settlePrices(ctx, from); err != nil {
+		return 0, err
+	}
+
+	m, err := liveMenu(ctx, s.db, from)
+	if err != nil {
+		return 0, err
+	}
+
+	return s.publishMenu(ctx, to, uid, branchMenu(m, nil), 0, nil)
+}
+
+// LinkMenu of a branch to a master restaurant, both owned by the caller.
+// The branch's menu becomes the master's right away and follows its changes
+// from then on, in the LinkedFields. The branch's menu can't be edited
+// directly while linked.
+func (s *Service) LinkMenu(ctx context.Context, branch, master string) error {
+	uid, auth := ctx.Value(KeyAuthFoodProviderID).(int64)
+	if !auth {
+		return ErrUnauthenticated
+	}
+
+	if !rxUUID.MatchString(branch) || !rxUUID.MatchString(master) {
+		return ErrRestaurantNotFound
+	}
+
+	if branch == master {
+		return ErrInvalidMenuLink
+	}
+
+	for _, rid := range []string{branch, master} {
+		if _, err := s.checkPermission(ctx, Owner, uid, rid); err != nil {
+			return err
+		}
+	}
+
+	// Links are one level deep: masters aren't branches and branches have
+	// one master.
+	var invalid bool
+	query := `
+		SELECT EXISTS (SELECT 1 FROM menu_link
+			WHERE branch_id = $1 OR master_id = $1 OR branch_id = $2)`
+	if err := s.db.QueryRowContext(ctx, query, branch, master).Scan(&invalid); err != nil {
+		return fmt.Errorf("could not query menu links: %v", err)
+	}
+
+	if invalid {
+		return ErrInvalidMenuLink
+	}
+
+	query = "INSERT INTO menu_link (branch_id, master_id, linked_by) VALUES ($1, $2, $3)"
+	_, err := s.db.ExecContext(ctx, query, branch, master, uid)
+	if isUniqueViolation(err) {
+		return ErrInvalidMenuLink
+	}
+
+	if err != nil {
+		return fmt.Errorf("could not link menu: %v", err)
+	}
+
+	if err = s.settlePrices(ctx, master); err != nil {
+		return err
+	}
+
+	return s.syncBranch(ctx, master, branch, uid)
+}
+
+// UnlinkMenu of a branch from its master. The branch keeps the menu it has
+// and its overrides are dropped.
+func (s *Service) UnlinkMenu(ctx context.Context, branch string) error {
+	uid, auth := ctx.Value(KeyAuthFoodProviderID).(int64)
+	if !auth {
+		return ErrUnauthenticated
+	}
+
+	if !rxUUID.MatchString(branch) {
+		return ErrRestaurantNotFound
+	}
+
+	if _, err := s.checkPermission(ctx, Owner, uid, branch); err != nil {
+		return err
+	}
+
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("could not begin tx: %v", err)
+	}
+	defer func() { _ = tx.Rollback() }()
+
+	if _, err = tx.ExecContext(ctx, "DELETE FROM menu_override WHERE branch_id = $1", branch); err != nil {
+		return fmt.Errorf("could not delete menu overrides: %v", err)
+	}
+
+	res, err := tx.ExecContext(ctx, "DELETE FROM menu_link WHERE branch_id = $1", branch)
+	if err != nil {
+		return fmt.Errorf("could not unlink menu: %v", err)
+	}
+
+	if n, _ := res.RowsAffected(); n == 0 {
+		return ErrMenuLinkNotFound
+	}
+
+	if err = tx.Commit(); err != nil {
+		return fmt.Errorf("could not unlink menu: could not commit transaction: %v", err)
+	}
+
+	return nil
+}
+
+// GetMenuLink of a branch with the master's items and the branch's
+// overrides of them.
+func (s *Service) GetMenuLink(ctx context.Context, branch string) (MenuLink, error) {
+	var l MenuLink
+	uid, auth := ctx.Value(KeyAuthFoodProviderID).(int64)
+	if !auth {
+		return l, ErrUnauthenticated
+	}
+
+	if !rxUUID.MatchString(branch) {
+		return l, ErrRestaurantNotFound
+	}
+
+	if _, err := s.checkPermission(ctx, Manager, uid, branch); err != nil {
+		return l, err
+	}
+
+	query := `
+		SELECT l.master_id, r.title, l.linked_by, l.created_at, l.synced_at, l.sync_error
+		FROM menu_link l INNER JOIN restaurant r ON l.master_id = r.id
+		WHERE l.branch_id = $1`
+	err := s.db.QueryRowContext(ctx, query, branch).Scan(&l.MasterId, &l.MasterTitle, &l.LinkedBy, &l.LinkedAt,
+		&l.SyncedAt, &l.SyncError)
+	if err == sql.ErrNoRows {
+		return l, ErrMenuLinkNotFound
+	}
+
+	if err != nil {
+		return l, fmt.Errorf("could not query menu link: %v", err)
+	}
+
+	m, err := liveMenu(ctx, s.db, l.MasterId)
+	if err != nil {
+		return l, err
+	}
+
+	overrides, err := menuOverrides(ctx, s.db, branch)
+	if err != nil {
+		return l, err
+	}
+
+	l.LinkedFields = LinkedFields
+	l.Items = make([]LinkedItem, 0)
+	for _, c := range m.Categories {
+		for _, it := range c.Items {
+			o := overrides[it.Id]
+			l.Items = append(l.Items, LinkedItem{ItemId: it.Id, Name: it.Name, MasterPrice: it.Price,
+				MasterAvailability: *it.Availability, Price: o.Price, Availability: o.Availability})
+		}
+	}
+
+	return l, nil
+}
+
+// SetMenuOverride of a master item's price or availability in a branch. An
+// override without either is deleted.
+func (s *Service) SetMenuOverride(ctx context.Context, branch string, iid int64, o MenuOverride) error {
+	uid, auth := ctx.Value(KeyAuthFoodProviderID).(int64)
+	if !auth {
+		return ErrUnauthenticated
+	}
+
+	if !rxUUID.MatchString(branch) {
+		return ErrRestaurantNotFound
+	}
+
+	if o.Price != nil && *o.Price <= 0 {
+		return ErrInvalidPrice
+	}
+
+	if _, err := s.checkPermission(ctx, Manager, uid, branch); err != nil {
+		return err
+	}
+
+	var master string
+	query := "SELECT master_id FROM menu_link WHERE branch_id = $1"
+	err := s.db.QueryRowContext(ctx, query, branch).Scan(&master)
+	if err == sql.ErrNoRows {
+		return ErrMenuLinkNotFound
+	}
+
+	if err != nil {
+		return fmt.Errorf("could not query menu link: %v", err)
+	}
+
+	var exists bool
+	query = "SELECT EXISTS (SELECT 1 FROM item WHERE id = $1 AND restaurant_id = $2 AND archived = false)"
+	if err = s.db.QueryRowContext(ctx, query, iid, master).Scan(&exists); err != nil {
+		return fmt.Errorf("could not query item: %v", err)
+	}
+
+	if !exists {
+		return ErrItemNotFound
+	}
+
+	if o.Price == nil && o.Availability == nil {
+		query = "DELETE FROM menu_override WHERE branch_id = $1 AND item_id = $2"
+		_, err = s.db.ExecContext(ctx, query, branch, iid)
+	} else {
+		query = `
+			INSERT INTO menu_override (branch_id, item_id, price, availability) VALUES ($1, $2, $3, $4)
+			ON CONFLICT (branch_id, item_id) DO UPDATE SET price = excluded.price, availability = excluded.availability`
+		_, err = s.db.ExecContext(ctx, query, branch, iid, o.Price, o.Availability)
+	}
+
+	if err != nil {
+		return fmt.Errorf("could not set menu override: %v", err)
+	}
+
+	return s.syncBranch(ctx, master, branch, uid)
+}
@@ -0,0 +1,50 @@
+package service
+
+import "testing"
+
+func TestBranchMenu(t *testing.T) {
+	on, off := true, false
+	price := 300.0
+	master := DraftMenu{Categories: []DraftCategory{{Id: 4, Label: "Rice", Availability: &on, Items: []DraftItem{
+		{Id: 7, Name: "Biryani", Description: "Mutton", Price: 320, Availability: &on},
+		{Id: 8, Name: "Khichuri", Price: 180, Availability: &on},
+		{Id: 9, Name: "Polao", Price: 150, Availability: &off},
+	}}}}
+
+	m := branchMenu(master, map[int64]MenuOverride{
+		7: {Price: &price},
+		8: {Availability: &off},
+	})
+
+	c := m.Categories[0]
+	if c.Id != 0 || c.Label != "Rice" || !*c.Availability {
+		t.Errorf("Got: %+v | Want the category without its id", c)
+	}
+
+	var tt = []struct {
+		Label     string
+		Item      DraftItem
+		Price     float64
+		Available bool
+	}{
+		{Label: "Test should override the price", Item: c.Items[0], Price: 300, Available: true},
+		{Label: "Test should override the availability", Item: c.Items[1], Price: 180},
+		{Label: "Test should keep the master's item", Item: c.Items[2], Price: 150},
+	}
+
+	for _, test := range tt {
+		t.Run(test.Label, func(t *testing.T) {
+			if test.Item.Id != 0 {
+				t.Error("Got:", test.Item.Id, "| Want: no id")
+			}
+
+			if test.Item.Price != test.Price || *test.Item.Availability != test.Available {
+				t.Error("Got:", test.Item.Price, *test.Item.Availability, "| Want:", test.Price, test.Available)
+			}
+		})
+	}
+
+	if master.Categories[0].Items[1].Price != 180 || !*master.Categories[0].Items[1].Availability {
+		t.Error("Got the master menu changed")
+	}
+}
@@ -0,0 +1,804 @@
+package service
+
+import (
+	"context"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+)
+
+var (
+	// ErrInvalidMenuDraft denotes a draft menu with empty, too long or duplicate names or a non positive price.
+	ErrInvalidMenuDraft = errors.New("invalid menu draft")
+	// ErrMenuDraftNotFound denotes a restaurant without a saved draft menu.
+	ErrMenuDraftNotFound = errors.New("menu draft not found")
+	// ErrMenuVersionNotFound denotes a not found published menu version.
+	ErrMenuVersionNotFound = errors.New("menu version not found")
+	// ErrMenuDraftOutdated denotes a draft menu whose live menu changed after the draft started.
+	ErrMenuDraftOutdated = errors.New("menu changed since the draft started")
+)
+
+// DraftMenu is a whole menu, its categories and their items in display
+// order. Ids refer to categories and items of the live menu; new ones have
+// none. Availability defaults to true.
+type DraftMenu struct {
+	Categories []DraftCategory `json:"categories"`
+}
+
+// DraftCategory of a draft menu.
+type DraftCategory struct {
+	Id           int64       `json:"id,omitempty"`
+	Label        string      `json:"label"`
+	Availability *bool       `json:"availability,omitempty"`
+	Items        []DraftItem `json:"items"`
+}
+
+// DraftItem of a draft menu.
+type DraftItem struct {
+	Id           int64   `json:"id,omitempty"`
+	Name         string  `json:"name"`
+	Description  string  `json:"description"`
+	Price        float64 `json:"price"`
+	Availability *bool   `json:"availability,omitempty"`
+}
+
+// MenuDraft is the workspace where Managers stage a restaurant's next menu.
+// BaseVersion is the published version it started from.
+type MenuDraft struct {
+	BaseVersion int        `json:"base_version"`
+	Menu        DraftMenu  `json:"menu"`
+	Saved       bool       `json:"saved"`
+	UpdatedBy   int64      `json:"updated_by,omitempty"`
+	UpdatedAt   *time.Time `json:"updated_at,omitempty"`
+}
+
+// draftBase is the live menu a draft started from, its version and digest.
+type draftBase struct {
+	version int
+	digest  string
+}
+
+// MenuVersion is a published menu, the whole menu as it went live. Rollbacks
+// publish an earlier version again and tell which.
+type MenuVersion struct {
+	Number          int        `json:"number"`
+	RolledBackFrom  int        `json:"rolled_back_from,omitempty"`
+	PublishedBy     int64      `json:"published_by"`
+	PublishedByName string     `json:"published_by_name"`
+	PublishedAt     time.Time  `json:"published_at"`
+	Categories      int        `json:"categories"`
+	Items           int        `json:"items"`
+	Menu            *DraftMenu `json:"menu,omitempty"`
+}
+
+func available(b *bool) *bool {
+	if b == nil {
+		b = new(bool)
+		*b = true
+	}
+
+	return b
+}
+
+// normalize trims the names and defaults availabilities, then validates the
+// menu like the live one is.
+func (m *DraftMenu) normalize() error {
+	if m.Categories == nil {
+		m.Categories = make([]DraftCategory, 0)
+	}
+
+	labels := make(map[string]bool)
+	names := make(map[string]bool)
+	ids := make(map[int64]bool)
+	for i := range m.Categories {
+		c := &m.Categories[i]
+		c.Label = strings.TrimSpace(c.Label)
+		c.Availability = available(c.Availability)
+		label := strings.ToLower(c.Label)
+		if c.Label == "" || len([]rune(c.Label)) > maxMenuNameLength || labels[label] {
+			return ErrInvalidMenuDraft
+		}
+		labels[label] = true
+
+		if c.Items == nil {
+			c.Items = make([]DraftItem, 0)
+		}
+
+		for j := range c.Items {
+			it := &c.Items[j]
+			it.Name = strings.TrimSpace(it.Name)
+			it.Description = strings.TrimSpace(it.Description)
+			it.Availability = available(it.Availability)
+			name := strings.ToLower(it.Name)
+			if it.Name == "" || len([]rune(it.Name)) > maxMenuNameLength || names[name] ||
+				len([]rune(it.Description)) > 255 || it.Price <= 0 {
+				return ErrInvalidMenuDraft
+			}
+			names[name] = true
+
+			if it.Id != 0 {
+				if ids[it.Id] {
+					return ErrInvalidMenuDraft
+				}
+				ids[it.Id] = true
+			}
+		}
+	}
+
+	return nil
+}
+
+func (m DraftMenu) counts() (int, int) {
+	items := 0
+	for _, c := range m.Categories {
+		items += len(c.Items)
+	}
+
+	return len(m.Categories), items
+}
+
+// liveMenu of the restaurant as a draft menu, empty categories included.
+func liveMenu(ctx context.Context, q queryer, rid string) (DraftMenu, error) {
+	m := DraftMenu{Categories: make([]DraftCategory, 0)}
+	query := `
+		SELECT id, label, availability FROM category
+		WHERE restaurant = $1 AND archived = false
+		ORDER BY position, id`
+	rows, err := q.QueryContext(ctx, query, rid)
+	if err != nil {
+		return m, fmt.Errorf("could not query categories: %v", err)
+	}
+
+	defer rows.Close()
+	index := make(map[int64]int)
+	for rows.Next() {
+		c := DraftCategory{Availability: new(bool), Items: make([]DraftItem, 0)}
+		if err = rows.Scan(&c.Id, &c.Label, c.Availability); err != nil {
+			return m, fmt.Errorf("could not scan category: %v", err)
+		}
+
+		index[c.Id] = len(m.Categories)
+		m.Categories = append(m.Categories, c)
+	}
+
+	if err = rows.Err(); err != nil {
+		return m, fmt.Errorf("could not iterate categories: %v", err)
+	}
+
+	query = `
+		SELECT id, category_id, name, description, price, availability FROM item
+		WHERE restaurant_id = $1 AND archived = false
+		ORDER BY position, id`
+	rows, err = q.QueryContext(ctx, query, rid)
+	if err != nil {
+		return m, fmt.Errorf("could not query items: %v", err)
+	}
+
+	defer rows.Close()
+	for rows.Next() {
+		var cid int64
+		i := DraftItem{Availability: new(bool)}
+		if err = rows.Scan(&i.Id, &cid, &i.Name, &i.Description, &i.Price, i.Availability); err != nil {
+			return m, fmt.Errorf("could not scan item: %v", err)
+		}
+
+		if n, ok := index[cid]; ok {
+			m.Categories[n].Items = append(m.Categories[n].Items, i)
+		}
+	}
+
+	if err = rows.Err(); err != nil {
+		return m, fmt.Errorf("could not iterate items: %v", err)
+	}
+
+	return m, nil
+}
+
+type liveEntry struct {
+	name     string
+	price    float64
+	archived bool
+}
+
+// liveEntries of the restaurant, archived ones included, by id.
+func liveEntries(ctx context.Context, tx *sql.Tx, query, rid string) (map[int64]liveEntry, error) {
+	rows, err := tx.QueryContext(ctx, query, rid)
+	if err != nil {
+		return nil, fmt.Errorf("could not query menu entries: %v", err)
+	}
+
+	defer rows.Close()
+	ee := make(map[int64]liveEntry)
+	for rows.Next() {
+		var id int64
+		var e liveEntry
+		if err = rows.Scan(&id, &e.name, &e.price, &e.archived); err != nil {
+			return nil, fmt.Errorf("could not scan menu entry: %v", err)
+		}
+
+		ee[id] = e
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("could not iterate menu entries: %v", err)
+	}
+
+	return ee, nil
+}
+
+// entryId resolves a draft entry to a live one: by its id or else by name,
+// reviving an archived one when no active entry has the name. Archived
+// entries don't hold on to their names, several may share one. Entries
+// claimed by another entry of the draft are not matched by name, so
+// renaming one and adding a new entry with its old name creates the new one.
+func entryId(ee map[int64]liveEntry, claimed map[int64]bool, id int64, name string) (int64, bool) {
+	if id != 0 {
+		_, ok := ee[id]
+		return id, ok
+	}
+
+	var archived int64
+	for eid, e := range ee {
+		if claimed[eid] || !strings.EqualFold(e.name, name) {
+			continue
+		}
+
+		if !e.archived {
+			return eid, true
+		}
+
+		if archived == 0 || eid > archived {
+			archived = eid
+		}
+	}
+
+	return archived, true
+}
+
+// applyMenu makes the menu live: its categories and items are updated,
+// revived or created in its order and the rest archived. Price changes are
+// recorded as made by uid. Entries are archived first and created last, so
+// a name given up by one entry is free for another.
+func applyMenu(ctx context.Context, tx *sql.Tx, rid string, uid int64, m DraftMenu) error {
+	categories, err := liveEntries(ctx, tx, "SELECT id, label, 0, archived FROM category WHERE restaurant = $1", rid)
+	if err != nil {
+		return err
+	}
+
+	items, err := liveEntries(ctx, tx, "SELECT id, name, price, archived FROM item WHERE restaurant_id = $1", rid)
+	if err != nil {
+		return err
+	}
+
+	keptCategories := make(map[int64]bool)
+	keptItems := make(map[int64]bool)
+	for _, c := range m.Categories {
+		keptCategories[c.Id] = c.Id != 0
+		for _, it := range c.Items {
+			keptItems[it.Id] = it.Id != 0
+		}
+	}
+
+	cids := make([]int64, len(m.Categories))
+	iids := make([][]int64, len(m.Categories))
+	for i, c := range m.Categories {
+		cid, ok := entryId(categories, keptCategories, c.Id, c.Label)
+		if !ok {
+			return ErrCategoryNotFound
+		}
+		keptCategories[cid] = cid != 0
+		cids[i] = cid
+
+		iids[i] = make([]int64, len(c.Items))
+		for j, it := range c.Items {
+			iid, ok := entryId(items, keptItems, it.Id, it.Name)
+			if !ok {
+				return ErrItemNotFound
+			}
+			keptItems[iid] = iid != 0
+			iids[i][j] = iid
+		}
+	}
+
+	for iid, e := range items {
+		if !e.archived && !keptItems[iid] {
+			if _, err = tx.ExecContext(ctx, "UPDATE item SET archived = true WHERE id = $1", iid); err != nil {
+				return fmt.Errorf("could not archive item: %v", err)
+			}
+		}
+	}
+
+	for cid, e := range categories {
+		if !e.archived && !keptCategories[cid] {
+			if _, err = tx.ExecContext(ctx, "UPDATE category SET archived = true WHERE id = $1", cid); err != nil {
+				return fmt.Errorf("could not archive category: %v", err)
+			}
+		}
+	}
+
+	for _, created := range []bool{false, true} {
+		for i, c := range m.Categories {
+			if (cids[i] == 0) != created {
+				continue
+			}
+
+			if created {
+				query := "INSERT INTO category (restaurant, label, availability, position) VALUES ($1, $2, $3, $4) RETURNING id"
+				err = tx.QueryRowContext(ctx, query, rid, c.Label, *c.Availability, i+1).Scan(&cids[i])
+			} else {
+				query := "UPDATE category SET label = $1, availability = $2, position = $3, archived = false WHERE id = $4"
+				_, err = tx.ExecContext(ctx, query, c.Label, *c.Availability, i+1, cids[i])
+			}
+
+			if isUniqueViolation(err) {
+				return ErrTitleTaken
+			}
+
+			if err != nil {
+				return fmt.Errorf("could not apply category: %v", err)
+			}
+		}
+	}
+
+	for _, created := range []bool{false, true} {
+		for i, c := range m.Categories {
+			for j, it := range c.Items {
+				iid := iids[i][j]
+				if (iid == 0) != created {
+					continue
+				}
+
+				if created {
+					query := `
+						INSERT INTO item (restaurant_id, category_id, name, description, price, availability, position)
+						VALUES ($1, $2, $3, $4, $5, $6, $7)
+						RETURNING id`
+					err = tx.QueryRowContext(ctx, query, rid, cids[i], it.Name, it.Description, it.Price, *it.Availability, j+1).
+						Scan(&iid)
+				} else {
+					query := `
+						UPDATE item SET category_id = $1, name = $2, description = $3, price = $4, availability = $5,
+							position = $6, archived = false
+						WHERE id = $7`
+					_, err = tx.ExecContext(ctx, query, cids[i], it.Name, it.Description, it.Price, *it.Availability, j+1, iid)
+				}
+
+				if isUniqueViolation(err) {
+					return ErrItemAlreadyExists
+				}
+
+				if err != nil {
+					return fmt.Errorf("could not apply item: %v", err)
+				}
+
+				if e, ok := items[iid]; ok && e.price != it.Price {
+					if err = recordPriceChange(ctx, tx, iid, uid, e.price, it.Price); err != nil {
+						return err
+					}
+				}
+			}
+		}
+	}
+
+	return nil
+}
+
+// menuDigest identifies the content of a menu.
+func menuDigest(m DraftMenu) (string, error) {
+	b, err := json.Marshal(m)
+	if err != nil {
+		return "", fmt.Errorf("could not marshal menu: %v", err)
+	}
+
+	sum := sha256.Sum256(b)
+	return hex.EncodeToString(sum[:]), nil
+}
+
+// liveBase is the restaurant's live menu as a draft's base.
+func liveBase(ctx context.Context, q queryer, rid string) (draftBase, error) {
+	var b draftBase
+	live, err := liveMenu(ctx, q, rid)
+	if err != nil {
+		return b, err
+	}
+
+	if b.digest, err = menuDigest(live); err != nil {
+		return b, err
+	}
+
+	b.version, err = currentVersion(ctx, q, rid)
+	return b, err
+}
+
+// currentVersion of the restaurant's menu, zero before the first publish.
+func currentVersion(ctx context.Context, q queryer, rid string) (int, error) {
+	var n int
+	query := "SELECT COALESCE(MAX(number), 0) FROM menu_version WHERE restaurant_id = $1"
+	if err := q.QueryRowContext(ctx, query, rid).Scan(&n); err != nil {
+		return 0, fmt.Errorf("could not query menu version: %v", err)
+	}
+
+	return n, nil
+}
+
+// publishMenu applies the menu and records the live result, with the ids of
+// new entries, as the next version. With a base, the live menu must still be
+// the one the draft started from, due price changes included. Linked
+// branches get their menu from the master and can't publish their own.
+func (s *Service) publishMenu(ctx context.Context, rid string, uid int64, m DraftMenu, rolledBackFrom int,
+	base *draftBase) (int, error) {
+	if err := m.normalize(); err != nil {
+		return 0, err
+	}
+
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return 0, fmt.Errorf("could not begin tx: %v", err)
+	}
+	defer func() { _ = tx.Rollback() }()
+
+	// Publishes of the restaurant queue up behind each other for the next
+	// version number.
+	if _, err = tx.ExecContext(ctx, "SELECT id FROM restaurant WHERE id = $1 FOR UPDATE", rid); err != nil {
+		return 0, fmt.Errorf("could not lock restaurant: %v", err)
+	}
+
+	if err = checkUnlinked(ctx, tx, rid); err != nil {
+		return 0, err
+	}
+
+	if _, err = applyDuePrices(ctx, tx, rid); err != nil {
+		return 0, err
+	}
+
+	if base != nil {
+		live, err := liveBase(ctx, tx, rid)
+		if err != nil {
+			return 0, err
+		}
+
+		if live != *base {
+			return 0, ErrMenuDraftOutdated
+		}
+	}
+
+	if err = applyMenu(ctx, tx, rid, uid, m); err != nil {
+		return 0, err
+	}
+
+	live, err := liveMenu(ctx, tx, rid)
+	if err != nil {
+		return 0, err
+	}
+
+	b, err := json.Marshal(live)
+	if err != nil {
+		return 0, fmt.Errorf("could not marshal menu: %v", err)
+	}
+
+	n, err := currentVersion(ctx, tx, rid)
+	if err != nil {
+		return 0, err
+	}
+
+	n++
+	query := `
+		INSERT INTO menu_version (restaurant_id, number, menu, rolled_back_from, published_by)
+		VALUES ($1, $2, $3, $4, $5)`
+	if _, err = tx.ExecContext(ctx, query, rid, n, string(b), rolledBackFrom, uid); err != nil {
+		return 0, fmt.Errorf("could not record menu version: %v", err)
+	}
+
+	if _, err = tx.ExecContext(ctx, "DELETE FROM menu_draft WHERE restaurant_id = $1", rid); err != nil {
+		return 0, fmt.Errorf("could not delete menu draft: %v", err)
+	}
+
+	if err = tx.Commit(); err != nil {
+		return 0, fmt.Errorf("could not publish menu: could not commit transaction: %v", err)
+	}
+
+	s.menuChanged(ctx, rid)
+	return n, nil
+}
+
+// GetMenuDraft of a restaurant. Without a saved draft it's a copy of the
+// live menu to start from.
+func (s *Service) GetMenuDraft(ctx context.Context, rid string) (MenuDraft, error) {
+	var d MenuDraft
+	uid, auth := ctx.Value(KeyAuthFoodProviderID).(int64)
+	if !auth {
+		return d, ErrUnauthenticated
+	}
+
+	if !rxUUID.MatchString(rid) {
+		return d, ErrRestaurantNotFound
+	}
+
+	if _, err := s.checkPermission(ctx, Manager, uid, rid); err != nil {
+		return d, err
+	}
+
+	var menu []byte
+	var updatedAt time.Time
+	query := "SELECT base_version, menu, updated_by, updated_at FROM menu_draft WHERE restaurant_id = $1"
+	err := s.db.QueryRowContext(ctx, query, rid).Scan(&d.BaseVersion, &menu, &d.UpdatedBy, &updatedAt)
+	if err == sql.ErrNoRows {
+		if d.BaseVersion, err = currentVersion(ctx, s.db, rid); err != nil {
+			return d, err
+		}
+
+		d.Menu, err = liveMenu(ctx, s.db, rid)
+		return d, err
+	}
+
+	if err != nil {
+		return d, fmt.Errorf("could not query menu draft: %v", err)
+	}
+
+	if err = json.Unmarshal(menu, &d.Menu); err != nil {
+		return d, fmt.Errorf("could not unmarshal menu draft: %v", err)
+	}
+
+	d.Saved, d.UpdatedAt = true, &updatedAt
+	return d, nil
+}
+
+// SaveMenuDraft replaces the restaurant's draft menu. Customers keep seeing
+// the live menu until the draft is published.
+func (s *Service) SaveMenuDraft(ctx context.Context, rid string, m DraftMenu) error {
+	uid, auth := ctx.Value(KeyAuthFoodProviderID).(int64)
+	if !auth {
+		return ErrUnauthenticated
+	}
+
+	if !rxUUID.MatchString(rid) {
+		return ErrRestaurantNotFound
+	}
+
+	if err := m.normalize(); err != nil {
+		return err
+	}
+
+	if _, err := s.checkPermission(ctx, Manager, uid, rid); err != nil {
+		return err
+	}
+
+	if err := checkUnlinked(ctx, s.db, rid); err != nil {
+		return err
+	}
+
+	b, err := json.Marshal(m)
+	if err != nil {
+		return fmt.Errorf("could not marshal menu draft: %v", err)
+	}
+
+	base, err := liveBase(ctx, s.db, rid)
+	if err != nil {
+		return err
+	}
+
+	// A draft keeps the live menu it started from.
+	query := `
+		INSERT INTO menu_draft (restaurant_id, base_version, base_digest, menu, updated_by) VALUES ($1, $2, $3, $4, $5)
+		ON CONFLICT (restaurant_id) DO UPDATE SET menu = excluded.menu, updated_by = excluded.updated_by,
+			updated_at = now()`
+	if _, err = s.db.ExecContext(ctx, query, rid, base.version, base.digest, string(b), uid); err != nil {
+		return fmt.Errorf("could not save menu draft: %v", err)
+	}
+
+	return nil
+}
+
+// DiscardMenuDraft of a restaurant.
+func (s *Service) DiscardMenuDraft(ctx context.Context, rid string) error {
+	uid, auth := ctx.Value(KeyAuthFoodProviderID).(int64)
+	if !auth {
+		return ErrUnauthenticated
+	}
+
+	if !rxUUID.MatchString(rid) {
+		return ErrRestaurantNotFound
+	}
+
+	if _, err := s.checkPermission(ctx, Manager, uid, rid); err != nil {
+		return err
+	}
+
+	res, err := s.db.ExecContext(ctx, "DELETE FROM menu_draft WHERE restaurant_id = $1", rid)
+	if err != nil {
+		return fmt.Errorf("could not discard menu draft: %v", err)
+	}
+
+	if n, _ := res.RowsAffected(); n == 0 {
+		return ErrMenuDraftNotFound
+	}
+
+	return nil
+}
+
+// PreviewMenuDraft renders the restaurant's saved draft like its printable
+// HTML menu.
+func (s *Service) PreviewMenuDraft(ctx context.Context, rid string) ([]byte, error) {
+	d, err := s.GetMenuDraft(ctx, rid)
+	if err != nil {
+		return nil, err
+	}
+
+	if !d.Saved {
+		return nil, ErrMenuDraftNotFound
+	}
+
+	// Drafts aren't translated.
+	ctx = context.WithValue(ctx, KeyLocale, DefaultLocale)
+	m, _, _, err := s.printHeader(ctx, rid)
+	if err != nil {
+		return nil, err
+	}
+
+	tags, err := itemTags(ctx, s.db, rid)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, c := range d.Menu.Categories {
+		if !*c.Availability {
+			continue
+		}
+
+		pc := PrintCategory{Label: c.Label, Items: make([]PrintItem, 0)}
+		for _, i := range c.Items {
+			if *i.Availability {
+				pc.Items = append(pc.Items, PrintItem{Name: i.Name, Description: i.Description, Price: i.Price,
+					Dietary: dietaryOf(tags, i.Id, SpiceNone)})
+			}
+		}
+		m.Categories = append(m.Categories, pc)
+	}
+
+	return m.html()
+}
+
+// PublishMenuDraft makes the restaurant's saved draft the live menu at once
+// and returns its version number. A draft whose live menu changed since it
+// started, by a publish, a direct edit or a price change, isn't published
+// over those changes; discard it and start again.
+func (s *Service) PublishMenuDraft(ctx context.Context, rid string) (int, error) {
+	uid, auth := ctx.Value(KeyAuthFoodProviderID).(int64)
+	if !auth {
+		return 0, ErrUnauthenticated
+	}
+
+	if !rxUUID.MatchString(rid) {
+		return 0, ErrRestaurantNotFound
+	}
+
+	if _, err := s.checkPermission(ctx, Manager, uid, rid); err != nil {
+		return 0, err
+	}
+
+	var b []byte
+	var base draftBase
+	query := "SELECT menu, base_version, base_digest FROM menu_draft WHERE restaurant_id = $1"
+	err := s.db.QueryRowContext(ctx, query, rid).Scan(&b, &base.version, &base.digest)
+	if err == sql.ErrNoRows {
+		return 0, ErrMenuDraftNotFound
+	}
+
+	if err != nil {
+		return 0, fmt.Errorf("could not query menu draft: %v", err)
+	}
+
+	var m DraftMenu
+	if err = json.Unmarshal(b, &m); err != nil {
+		return 0, fmt.Errorf("could not unmarshal menu draft: %v", err)
+	}
+
+	return s.publishMenu(ctx, rid, uid, m, 0, &base)
+}
+
+// GetMenuVersions of a restaurant, latest first, without their menus.
+func (s *Service) GetMenuVersions(ctx context.Context, rid string) ([]MenuVersion, error) {
+	uid, auth := ctx.Value(KeyAuthFoodProviderID).(int64)
+	if !auth {
+		return nil, ErrUnauthenticated
+	}
+
+	if !rxUUID.MatchString(rid) {
+		return nil, ErrRestaurantNotFound
+	}
+
+	if _, err := s.checkPermission(ctx, Manager, uid, rid); err != nil {
+		return nil, err
+	}
+
+	query := `
+		SELECT v.number, v.rolled_back_from, v.published_by, f.fullname, v.published_at, v.menu
+		FROM menu_version v INNER JOIN foodprovider f ON v.published_by = f.id
+		WHERE v.restaurant_id = $1
+		ORDER BY v.number DESC`
+	rows, err := s.db.QueryContext(ctx, query, rid)
+	if err != nil {
+		return nil, fmt.Errorf("could not query menu versions: %v", err)
+	}
+
+	defer rows.Close()
+	vv := make([]MenuVersion, 0)
+	for rows.Next() {
+		var v MenuVersion
+		var b []byte
+		if err = rows.Scan(&v.Number, &v.RolledBackFrom, &v.PublishedBy, &v.PublishedByName, &v.PublishedAt, &b); err != nil {
+			return nil, fmt.Errorf("could not scan menu version: %v", err)
+		}
+
+		var m DraftMenu
+		if err = json.Unmarshal(b, &m); err != nil {
+			return nil, fmt.Errorf("could not unmarshal menu version: %v", err)
+		}
+
+		v.Categories, v.Items = m.counts()
+		vv = append(vv, v)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("could not iterate menu versions: %v", err)
+	}
+
+	return vv, nil
+}
+
+// GetMenuVersion of a restaurant with its menu.
+func (s *Service) GetMenuVersion(ctx context.Context, rid string, number int) (MenuVersion, error) {
+	var v MenuVersion
+	uid, auth := ctx.Value(KeyAuthFoodProviderID).(int64)
+	if !auth {
+		return v, ErrUnauthenticated
+	}
+
+	if !rxUUID.MatchString(rid) {
+		return v, ErrRestaurantNotFound
+	}
+
+	if _, err := s.checkPermission(ctx, Manager, uid, rid); err != nil {
+		return v, err
+	}
+
+	var b []byte
+	query := `
+		SELECT v.number, v.rolled_back_from, v.published_by, f.fullname, v.published_at, v.menu
+		FROM menu_version v INNER JOIN foodprovider f ON v.published_by = f.id
+		WHERE v.restaurant_id = $1 AND v.number = $2`
+	err := s.db.QueryRowContext(ctx, query, rid, number).Scan(&v.Number, &v.RolledBackFrom, &v.PublishedBy,
+		&v.PublishedByName, &v.PublishedAt, &b)
+	if err == sql.ErrNoRows {
+		return v, ErrMenuVersionNotFound
+	}
+
+	if err != nil {
+		return v, fmt.Errorf("could not query menu version: %v", err)
+	}
+
+	v.Menu = new(DraftMenu)
+	if err = json.Unmarshal(b, v.Menu); err != nil {
+		return v, fmt.Errorf("could not unmarshal menu version: %v", err)
+	}
+
+	v.Categories, v.Items = v.Menu.counts()
+	return v, nil
+}
+
+// RollbackMenu publishes an earlier version of the restaurant's menu again,
+// as a new version. A saved draft is discarded like on any publish.
+func (s *Service) RollbackMenu(ctx context.Context, rid string, number int) (int, error) {
+	v, err := s.GetMenuVersion(ctx, rid, number)
+	if err != nil {
+		return 0, err
+	}
+
+	uid := ctx.Value(KeyAuthFoodProviderID).(int64)
+	return s.publishMenu(ctx, rid, uid, *v.Menu, number, nil)
+}
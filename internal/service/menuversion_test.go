@@ -0,0 +1,219 @@
+package service
+
+import (
+	"context"
+	"net/url"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/hako/branca"
+	"github.com/jackc/pgx"
+	"github.com/jackc/pgx/stdlib"
+)
+
+func TestDraftMenuNormalize(t *testing.T) {
+	item := func(id int64, name string, price float64) DraftItem {
+		return DraftItem{Id: id, Name: name, Price: price}
+	}
+
+	var tt = []struct {
+		Label string
+		Menu  DraftMenu
+		Want  error
+	}{
+		{Label: "Test should accept an empty menu", Menu: DraftMenu{}},
+		{Label: "Test should accept a menu", Menu: DraftMenu{Categories: []DraftCategory{
+			{Label: "Rice", Items: []DraftItem{item(1, "Biryani", 320), item(0, "Khichuri", 180)}},
+			{Label: "Drinks"},
+		}}},
+		{Label: "Test should reject an empty label", Menu: DraftMenu{Categories: []DraftCategory{{Label: " "}}},
+			Want: ErrInvalidMenuDraft},
+		{Label: "Test should reject duplicate labels", Menu: DraftMenu{Categories: []DraftCategory{{Label: "Rice"}, {Label: "rice"}}},
+			Want: ErrInvalidMenuDraft},
+		{Label: "Test should reject duplicate names across categories", Menu: DraftMenu{Categories: []DraftCategory{
+			{Label: "Rice", Items: []DraftItem{item(0, "Biryani", 320)}},
+			{Label: "Specials", Items: []DraftItem{item(0, "BIRYANI", 350)}},
+		}}, Want: ErrInvalidMenuDraft},
+		{Label: "Test should reject duplicate ids", Menu: DraftMenu{Categories: []DraftCategory{
+			{Label: "Rice", Items: []DraftItem{item(1, "Biryani", 320), item(1, "Khichuri", 180)}},
+		}}, Want: ErrInvalidMenuDraft},
+		{Label: "Test should reject a free item", Menu: DraftMenu{Categories: []DraftCategory{
+			{Label: "Rice", Items: []DraftItem{item(0, "Biryani", 0)}},
+		}}, Want: ErrInvalidMenuDraft},
+		{Label: "Test should reject a long name", Menu: DraftMenu{Categories: []DraftCategory{
+			{Label: "Rice", Items: []DraftItem{item(0, strings.Repeat("a", 26), 10)}},
+		}}, Want: ErrInvalidMenuDraft},
+	}
+
+	for _, test := range tt {
+		t.Run(test.Label, func(t *testing.T) {
+			if err := test.Menu.normalize(); err != test.Want {
+				t.Error("Got:", err, "| Want:", test.Want)
+			}
+		})
+	}
+}
+
+func TestDraftMenuNormalizeDefaults(t *testing.T) {
+	off := false
+	m := DraftMenu{Categories: []DraftCategory{
+		{Label: " Rice ", Items: []DraftItem{{Name: " Biryani ", Price: 320}, {Name: "Khichuri", Price: 180, Availability: &off}}},
+	}}
+	if err := m.normalize(); err != nil {
+		t.Fatal(err)
+	}
+
+	c := m.Categories[0]
+	if c.Label != "Rice" || c.Items[0].Name != "Biryani" {
+		t.Errorf("Got: %q, %q | Want trimmed names", c.Label, c.Items[0].Name)
+	}
+
+	if !*c.Availability || !*c.Items[0].Availability || *c.Items[1].Availability {
+		t.Error("Got wrong availabilities | Want available unless set")
+	}
+
+	if nc, ni := m.counts(); nc != 1 || ni != 2 {
+		t.Error("Got:", nc, ni, "| Want: 1 2")
+	}
+}
+
+func TestEntryId(t *testing.T) {
+	ee := map[int64]liveEntry{
+		1: {name: "Biryani"},
+		2: {name: "Borhani", archived: true},
+		3: {name: "Biryani", archived: true},
+		4: {name: "Borhani", archived: true},
+	}
+
+	var tt = []struct {
+		Label   string
+		Id      int64
+		Name    string
+		Claimed int64
+		Also    int64
+		WantId  int64
+		WantOk  bool
+	}{
+		{Label: "Test should resolve by id", Id: 1, Name: "Other", WantId: 1, WantOk: true},
+		{Label: "Test should reject an unknown id", Id: 5, Name: "Biryani", WantId: 5},
+		{Label: "Test should prefer an active entry by name", Name: "Biryani", WantId: 1, WantOk: true},
+		{Label: "Test should revive the latest archived entry by name", Name: "borhani", WantId: 4, WantOk: true},
+		{Label: "Test should leave new entries without an id", Name: "Khichuri", WantOk: true},
+		{Label: "Test should resolve a claimed entry by id", Id: 1, Name: "Biryani", Claimed: 1, WantId: 1, WantOk: true},
+		{Label: "Test should not match a claimed entry by name", Name: "Biryani", Claimed: 1, WantId: 3, WantOk: true},
+		{Label: "Test should leave the name new when every match is claimed", Name: "Borhani", Claimed: 4, Also: 2, WantOk: true},
+	}
+
+	for _, test := range tt {
+		t.Run(test.Label, func(t *testing.T) {
+			claimed := map[int64]bool{test.Claimed: test.Claimed != 0, test.Also: test.Also != 0}
+			id, ok := entryId(ee, claimed, test.Id, test.Name)
+			if id != test.WantId || ok != test.WantOk {
+				t.Error("Got:", id, ok, "| Want:", test.WantId, test.WantOk)
+			}
+		})
+	}
+}
+
+func TestMenuDigest(t *testing.T) {
+	menu := func(price float64) DraftMenu {
+		return DraftMenu{Categories: []DraftCategory{{Id: 1, Label: "Rice", Items: []DraftItem{{Id: 2, Name: "Biryani", Price: price}}}}}
+	}
+
+	a, _ := menuDigest(menu(320))
+	b, _ := menuDigest(menu(320))
+	c, _ := menuDigest(menu(350))
+	if a != b {
+		t.Error("Got different digests | Want the same for the same menu")
+	}
+
+	if a == c {
+		t.Error("Got the same digest | Want another after a price change")
+	}
+}
+
+func TestApplyMenuRename(t *testing.T) {
+	tearDown := SetupTest()
+	defer tearDown()
+
+	ctx := context.TODO()
+
+	codec := branca.NewBranca("supersecretkeyyoushouldnotcommit")
+	codec.SetTTL(uint32(TokenLifeSpan.Seconds()))
+
+	fpCodec := branca.NewBranca("supersecretkeyyoushouldcommitnot")
+	fpCodec.SetTTL(uint32(TokenLifeSpan.Seconds()))
+
+	c, err := pgx.ParseURI(pgURL.String())
+	if err != nil {
+		log.Fatalf(err.Error())
+	}
+
+	db := stdlib.OpenDB(c)
+
+	if err := ValidateSchema(db); err != nil {
+		log.Fatalf("failed to validate schema: %v\n", err)
+	}
+
+	s := New(db, codec, fpCodec, nil, url.URL{})
+
+	_ = s.CreateFoodProvider(ctx, "johndoe@gmail.com", "John Snow", "01867584576", "ilovegolang")
+	user, _ := s.FoodProviderLogin(ctx, "01867584576", "ilovegolang")
+	uid := user.AuthUser.ID
+	ctx = context.WithValue(ctx, KeyAuthFoodProviderID, uid)
+	_ = s.CreateRestaurant(ctx, "test.Title", "test.About", "01616534596", "test.Location", "test.City", "test.Area", "test.Country")
+	user, _ = s.FoodProviderLogin(ctx, "01867584576", "ilovegolang")
+	rid := (*user.Restaurants)[0].Id
+
+	_ = s.CreateCategory(ctx, rid, "Rice", true)
+	cc, _ := s.GetCategoriesByRestaurant(ctx, rid)
+	_ = s.CreateItem(ctx, rid, cc[0].Id, "Biryani", "Mutton", 320, true)
+	menu, _ := s.GetMenuForFp(ctx, rid)
+	biryani, _ := strconv.ParseInt((*menu)[0].Id, 10, 64)
+
+	// The new item comes first, so it is only created fresh if it doesn't
+	// take over the row the rename refers to.
+	on := true
+	m := DraftMenu{Categories: []DraftCategory{{Id: cc[0].Id, Label: "Rice", Availability: &on, Items: []DraftItem{
+		{Name: "Biryani", Description: "Chicken", Price: 280, Availability: &on},
+		{Id: biryani, Name: "Kacchi", Description: "Mutton", Price: 320, Availability: &on},
+	}}}}
+
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() { _ = tx.Rollback() }()
+
+	if err = applyMenu(ctx, tx, rid, uid, m); err != nil {
+		t.Fatal("Got:", err, "| Want: the menu applied")
+	}
+
+	if err = tx.Commit(); err != nil {
+		t.Fatal(err)
+	}
+
+	var tt = []struct {
+		Label   string
+		Name    string
+		Renamed bool
+	}{
+		{Label: "Test should rename the item referenced by id", Name: "Kacchi", Renamed: true},
+		{Label: "Test should create the new item with the old name", Name: "Biryani"},
+	}
+
+	for _, test := range tt {
+		t.Run(test.Label, func(t *testing.T) {
+			var id int64
+			query := "SELECT id FROM item WHERE restaurant_id = $1 AND name = $2 AND archived = false"
+			if err := db.QueryRow(query, rid, test.Name).Scan(&id); err != nil {
+				t.Fatal("Got:", err, "| Want:", test.Name)
+			}
+
+			if (id == biryani) != test.Renamed {
+				t.Error("Got:", id, "| Want the renamed item:", test.Renamed)
+			}
+		})
+	}
+}
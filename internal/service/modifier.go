@@ -0,0 +1,443 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+var (
+	// ErrVariantNotFound denotes a not found item variant.
+	ErrVariantNotFound = errors.New("variant not found")
+	// ErrModifierGroupNotFound denotes a not found modifier group.
+	ErrModifierGroupNotFound = errors.New("modifier group not found")
+	// ErrInvalidVariant denotes a missing variant for an item with sizes or a variant of another item.
+	ErrInvalidVariant = errors.New("invalid variant")
+	// ErrInvalidModifiers denotes chosen options that don't fit the item's modifier groups.
+	ErrInvalidModifiers = errors.New("invalid modifiers")
+	// ErrInvalidModifierGroup denotes a modifier group without options or with impossible limits.
+	ErrInvalidModifierGroup = errors.New("invalid modifier group")
+)
+
+// Variant is a size or portion of an item with its own price, e.g. a half
+// or full biryani.
+type Variant struct {
+	Id    int64   `json:"id"`
+	Name  string  `json:"name"`
+	Price float64 `json:"price"`
+}
+
+// ModifierGroup is a set of options to choose from when ordering an item,
+// e.g. add-ons for a burger. Required groups need at least one option.
+type ModifierGroup struct {
+	Id       int64            `json:"id"`
+	Name     string           `json:"name"`
+	Required bool             `json:"required"`
+	Min      int              `json:"min"`
+	Max      int              `json:"max"`
+	Options  []ModifierOption `json:"options"`
+}
+
+// ModifierOption priced on top of the item, with the allergens it adds.
+type ModifierOption struct {
+	Id        int64    `json:"id"`
+	Name      string   `json:"name"`
+	Price     float64  `json:"price"`
+	Allergens []string `json:"allergens"`
+}
+
+// ItemOptions are the variants and modifier groups of an item.
+type ItemOptions struct {
+	Variants  []Variant       `json:"variants"`
+	Modifiers []ModifierGroup `json:"modifiers"`
+}
+
+// priceModifiers checks the chosen options against the item's modifier
+// groups and returns them with their summed price.
+func priceModifiers(groups []ModifierGroup, selected []int64) (float64, []ModifierOption, error) {
+	chosen := make(map[int64]bool, len(selected))
+	for _, id := range selected {
+		if chosen[id] {
+			return 0, nil, ErrInvalidModifiers
+		}
+		chosen[id] = true
+	}
+
+	var total float64
+	oo := make([]ModifierOption, 0, len(selected))
+	for _, g := range groups {
+		n := 0
+		for _, o := range g.Options {
+			if chosen[o.Id] {
+				delete(chosen, o.Id)
+				total += o.Price
+				oo = append(oo, o)
+				n++
+			}
+		}
+
+		if n < g.Min || n > g.Max {
+			return 0, nil, ErrInvalidModifiers
+		}
+	}
+
+	// Anything left is not an option of this item.
+	if len(chosen) != 0 {
+		return 0, nil, ErrInvalidModifiers
+	}
+
+	return roundMoney(total), oo, nil
+}
+
+// itemOptions loads the variants and modifier groups of the restaurant's
+// items. When iid is not zero only that item is loaded.
+func itemOptions(ctx context.Context, q queryer, rid string, iid int64) (map[int64]*ItemOptions, error) {
+	opts := make(map[int64]*ItemOptions)
+	get := func(iid int64) *ItemOptions {
+		o, ok := opts[iid]
+		if !ok {
+			o = &ItemOptions{Variants: make([]Variant, 0), Modifiers: make([]ModifierGroup, 0)}
+			opts[iid] = o
+		}
+		return o
+	}
+
+	query := `
+		SELECT v.item_id, v.id, v.name, v.price
+		FROM item_variant v INNER JOIN item i ON v.item_id = i.id
+		WHERE i.restaurant_id = $1 AND ($2 = 0 OR i.id = $2) AND v.archived = false
+		ORDER BY v.price, v.id`
+	rows, err := q.QueryContext(ctx, query, rid, iid)
+	if err != nil {
+		return nil, fmt.Errorf("could not query item variants: %v", err)
+	}
+
+	defer rows.Close()
+	for rows.Next() {
+		var iid int64
+		var v Variant
+		if err = rows.Scan(&iid, &v.Id, &v.Name, &v.Price); err != nil {
+			return nil, fmt.Errorf("could not scan item variant: %v", err)
+		}
+
+		o := get(iid)
+		o.Variants = append(o.Variants, v)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("could not iterate item variants: %v", err)
+	}
+
+	query = `
+		SELECT g.item_id, g.id, g.name, g.min_select, g.max_select, o.id, o.name, o.price
+		FROM modifier_group g
+			INNER JOIN item i ON g.item_id = i.id
+			INNER JOIN modifier_option o ON o.group_id = g.id
+		WHERE i.restaurant_id = $1 AND ($2 = 0 OR i.id = $2) AND g.archived = false
+		ORDER BY g.item_id, g.id, o.id`
+	rows, err = q.QueryContext(ctx, query, rid, iid)
+	if err != nil {
+		return nil, fmt.Errorf("could not query modifier groups: %v", err)
+	}
+
+	defer rows.Close()
+	for rows.Next() {
+		var iid int64
+		var g ModifierGroup
+		var m ModifierOption
+		if err = rows.Scan(&iid, &g.Id, &g.Name, &g.Min, &g.Max, &m.Id, &m.Name, &m.Price); err != nil {
+			return nil, fmt.Errorf("could not scan modifier group: %v", err)
+		}
+
+		o := get(iid)
+		if n := len(o.Modifiers); n == 0 || o.Modifiers[n-1].Id != g.Id {
+			g.Required = g.Min > 0
+			g.Options = make([]ModifierOption, 0)
+			o.Modifiers = append(o.Modifiers, g)
+		}
+
+		m.Allergens = make([]string, 0)
+		last := &o.Modifiers[len(o.Modifiers)-1]
+		last.Options = append(last.Options, m)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("could not iterate modifier groups: %v", err)
+	}
+
+	query = `
+		SELECT g.item_id, a.option_id, a.allergen
+		FROM modifier_option_allergen a
+			INNER JOIN modifier_option o ON a.option_id = o.id
+			INNER JOIN modifier_group g ON o.group_id = g.id
+			INNER JOIN item i ON g.item_id = i.id
+		WHERE i.restaurant_id = $1 AND ($2 = 0 OR i.id = $2) AND g.archived = false
+		ORDER BY a.option_id, a.allergen`
+	rows, err = q.QueryContext(ctx, query, rid, iid)
+	if err != nil {
+		return nil, fmt.Errorf("could not query modifier option allergens: %v", err)
+	}
+
+	defer rows.Close()
+	for rows.Next() {
+		var iid, oid int64
+		var allergen string
+		if err = rows.Scan(&iid, &oid, &allergen); err != nil {
+			return nil, fmt.Errorf("could not scan modifier option allergen: %v", err)
+		}
+
+		for _, g := range get(iid).Modifiers {
+			for i, m := range g.Options {
+				if m.Id == oid {
+					g.Options[i].Allergens = append(m.Allergens, allergen)
+				}
+			}
+		}
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("could not iterate modifier option allergens: %v", err)
+	}
+
+	return opts, nil
+}
+
+// GetItemOptions of a menu item.
+func (s *Service) GetItemOptions(ctx context.Context, rid string, iid int64) (ItemOptions, error) {
+	var o ItemOptions
+	uid, auth := ctx.Value(KeyAuthFoodProviderID).(int64)
+	if !auth {
+		return o, ErrUnauthenticated
+	}
+
+	if !rxUUID.MatchString(rid) {
+		return o, ErrRestaurantNotFound
+	}
+
+	if _, err := s.checkPermission(ctx, Waiter, uid, rid); err != nil {
+		return o, err
+	}
+
+	opts, err := itemOptions(ctx, s.db, rid, iid)
+	if err != nil {
+		return o, err
+	}
+
+	if opt, ok := opts[iid]; ok {
+		return *opt, nil
+	}
+
+	return ItemOptions{Variants: make([]Variant, 0), Modifiers: make([]ModifierGroup, 0)}, nil
+}
+
+// CreateVariant of a menu item. Once an item has variants one of them has to
+// be chosen when ordering it and its price replaces the item's price.
+func (s *Service) CreateVariant(ctx context.Context, rid string, iid int64, name string, price float64) (int64, error) {
+	uid, auth := ctx.Value(KeyAuthFoodProviderID).(int64)
+	if !auth {
+		return 0, ErrUnauthenticated
+	}
+
+	if !rxUUID.MatchString(rid) {
+		return 0, ErrRestaurantNotFound
+	}
+
+	name = strings.TrimSpace(name)
+	if name == "" {
+		return 0, ErrEmptyValue
+	}
+
+	if price <= 0 {
+		return 0, ErrInvalidPrice
+	}
+
+	if _, err := s.checkPermission(ctx, Manager, uid, rid); err != nil {
+		return 0, err
+	}
+
+	var id int64
+	query := `
+		INSERT INTO item_variant (item_id, name, price)
+		SELECT id, $2, $3 FROM item WHERE id = $1 AND restaurant_id = $4 AND archived = false
+		RETURNING id`
+	err := s.db.QueryRowContext(ctx, query, iid, name, price, rid).Scan(&id)
+	if err == sql.ErrNoRows {
+		return 0, ErrItemNotFound
+	}
+
+	if isUniqueViolation(err) {
+		return 0, ErrItemAlreadyExists
+	}
+
+	if err != nil {
+		return 0, fmt.Errorf("could not create variant: %v", err)
+	}
+
+	return id, nil
+}
+
+// ArchiveVariant takes the variant off the menu, keeping it for past orders.
+func (s *Service) ArchiveVariant(ctx context.Context, rid string, iid, vid int64) error {
+	uid, auth := ctx.Value(KeyAuthFoodProviderID).(int64)
+	if !auth {
+		return ErrUnauthenticated
+	}
+
+	if !rxUUID.MatchString(rid) {
+		return ErrRestaurantNotFound
+	}
+
+	if _, err := s.checkPermission(ctx, Manager, uid, rid); err != nil {
+		return err
+	}
+
+	query := `
+		UPDATE item_variant SET archived = true
+		WHERE id = $1 AND item_id = $2 AND archived = false
+			AND EXISTS (SELECT 1 FROM item WHERE id = $2 AND restaurant_id = $3)`
+	res, err := s.db.ExecContext(ctx, query, vid, iid, rid)
+	if err != nil {
+		return fmt.Errorf("could not archive variant: %v", err)
+	}
+
+	if n, _ := res.RowsAffected(); n == 0 {
+		return ErrVariantNotFound
+	}
+
+	return nil
+}
+
+// CreateModifierGroup with its options for a menu item. A required group
+// needs at least one selection.
+func (s *Service) CreateModifierGroup(ctx context.Context, rid string, iid int64, g ModifierGroup) (int64, error) {
+	uid, auth := ctx.Value(KeyAuthFoodProviderID).(int64)
+	if !auth {
+		return 0, ErrUnauthenticated
+	}
+
+	if !rxUUID.MatchString(rid) {
+		return 0, ErrRestaurantNotFound
+	}
+
+	g.Name = strings.TrimSpace(g.Name)
+	if g.Name == "" {
+		return 0, ErrEmptyValue
+	}
+
+	if g.Required && g.Min == 0 {
+		g.Min = 1
+	}
+
+	if g.Max == 0 {
+		g.Max = len(g.Options)
+	}
+
+	if len(g.Options) == 0 || g.Min < 0 || g.Max < g.Min || g.Max > len(g.Options) {
+		return 0, ErrInvalidModifierGroup
+	}
+
+	for i, o := range g.Options {
+		g.Options[i].Name = strings.TrimSpace(o.Name)
+		if g.Options[i].Name == "" {
+			return 0, ErrEmptyValue
+		}
+
+		if o.Price < 0 {
+			return 0, ErrInvalidPrice
+		}
+
+		allergens, err := normalizeTags(o.Allergens, Allergens)
+		if err != nil {
+			return 0, err
+		}
+		g.Options[i].Allergens = allergens
+	}
+
+	if _, err := s.checkPermission(ctx, Manager, uid, rid); err != nil {
+		return 0, err
+	}
+
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return 0, fmt.Errorf("could not begin tx: %v", err)
+	}
+	defer func() { _ = tx.Rollback() }()
+
+	var id int64
+	query := `
+		INSERT INTO modifier_group (item_id, name, min_select, max_select)
+		SELECT id, $2, $3, $4 FROM item WHERE id = $1 AND restaurant_id = $5 AND archived = false
+		RETURNING id`
+	err = tx.QueryRowContext(ctx, query, iid, g.Name, g.Min, g.Max, rid).Scan(&id)
+	if err == sql.ErrNoRows {
+		return 0, ErrItemNotFound
+	}
+
+	if isUniqueViolation(err) {
+		return 0, ErrTitleTaken
+	}
+
+	if err != nil {
+		return 0, fmt.Errorf("could not create modifier group: %v", err)
+	}
+
+	for _, o := range g.Options {
+		var oid int64
+		query = "INSERT INTO modifier_option (group_id, name, price) VALUES ($1, $2, $3) RETURNING id"
+		err = tx.QueryRowContext(ctx, query, id, o.Name, o.Price).Scan(&oid)
+		if isUniqueViolation(err) {
+			return 0, ErrInvalidModifierGroup
+		}
+
+		if err != nil {
+			return 0, fmt.Errorf("could not create modifier option: %v", err)
+		}
+
+		query = "INSERT INTO modifier_option_allergen (option_id, allergen) VALUES ($1, $2)"
+		for _, a := range o.Allergens {
+			if _, err = tx.ExecContext(ctx, query, oid, a); err != nil {
+				return 0, fmt.Errorf("could not add modifier option allergen: %v", err)
+			}
+		}
+	}
+
+	if err = tx.Commit(); err != nil {
+		return 0, fmt.Errorf("could not create modifier group: could not commit transaction: %v", err)
+	}
+
+	return id, nil
+}
+
+// ArchiveModifierGroup takes the group off the menu, keeping its options for
+// past orders.
+func (s *Service) ArchiveModifierGroup(ctx context.Context, rid string, iid, gid int64) error {
+	uid, auth := ctx.Value(KeyAuthFoodProviderID).(int64)
+	if !auth {
+		return ErrUnauthenticated
+	}
+
+	if !rxUUID.MatchString(rid) {
+		return ErrRestaurantNotFound
+	}
+
+	if _, err := s.checkPermission(ctx, Manager, uid, rid); err != nil {
+		return err
+	}
+
+	query := `
+		UPDATE modifier_group SET archived = true
+		WHERE id = $1 AND item_id = $2 AND archived = false
+			AND EXISTS (SELECT 1 FROM item WHERE id = $2 AND restaurant_id = $3)`
+	res, err := s.db.ExecContext(ctx, query, gid, iid, rid)
+	if err != nil {
+		return fmt.Errorf("could not archive modifier group: %v", err)
+	}
+
+	if n, _ := res.RowsAffected(); n == 0 {
+		return ErrModifierGroupNotFound
+	}
+
+	return nil
+}
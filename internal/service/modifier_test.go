@@ -0,0 +1,52 @@
+package service
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestPriceModifiers(t *testing.T) {
+	groups := []ModifierGroup{
+		{Id: 1, Name: "Sauce", Required: true, Min: 1, Max: 1, Options: []ModifierOption{
+			{Id: 1, Name: "Mayo", Price: 0},
+			{Id: 2, Name: "Chipotle", Price: 20},
+		}},
+		{Id: 2, Name: "Add-ons", Min: 0, Max: 2, Options: []ModifierOption{
+			{Id: 3, Name: "Extra cheese", Price: 40.50},
+			{Id: 4, Name: "Bacon", Price: 60},
+			{Id: 5, Name: "Egg", Price: 25},
+		}},
+	}
+
+	var tt = []struct {
+		Label    string
+		Selected []int64
+		Price    float64
+		Err      error
+	}{
+		{Label: "Test should price a required option", Selected: []int64{2}, Price: 20},
+		{Label: "Test should add optional add-ons", Selected: []int64{1, 3, 4}, Price: 100.50},
+		{Label: "Test should reject a missing required option", Selected: []int64{3}, Err: ErrInvalidModifiers},
+		{Label: "Test should reject too many selections", Selected: []int64{1, 3, 4, 5}, Err: ErrInvalidModifiers},
+		{Label: "Test should reject an option of another item", Selected: []int64{1, 9}, Err: ErrInvalidModifiers},
+		{Label: "Test should reject the same option twice", Selected: []int64{1, 3, 3}, Err: ErrInvalidModifiers},
+	}
+
+	for _, test := range tt {
+		t.Run(test.Label, func(t *testing.T) {
+			price, _, err := priceModifiers(groups, test.Selected)
+			if err != test.Err {
+				t.Error("Got:", err, "| Want:", test.Err)
+			}
+			if !cmp.Equal(price, test.Price) {
+				t.Error("Got:", price, "| Want:", test.Price)
+			}
+		})
+	}
+
+	price, _, err := priceModifiers(nil, nil)
+	if err != nil || price != 0 {
+		t.Error("Got:", price, err, "| Want:", 0, nil)
+	}
+}
@@ -3,7 +3,34 @@ package service
 import (
 	"context"
 	"database/sql"
+	"errors"
 	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Order statuses in the order they are expected to happen.
+const (
+	OrderPlaced int64 = iota + 1
+	OrderAccepted
+	OrderPreparing
+	OrderReady
+	OrderCompleted
+	OrderCancelled
+)
+
+var (
+	// ErrOrderNotFound denotes a not found order.
+	ErrOrderNotFound = errors.New("order not found")
+	// ErrEmptyOrder denotes an order without any items.
+	ErrEmptyOrder = errors.New("order has no items")
+	// ErrItemNotFound denotes an item not on the restaurant's menu or not available.
+	ErrItemNotFound = errors.New("item not found")
+	// ErrInvalidQuantity denotes an ordered quantity below one.
+	ErrInvalidQuantity = errors.New("invalid quantity")
+	// ErrInvalidOrderStatus denotes an unknown order status or a transition that is not allowed.
+	ErrInvalidOrderStatus = errors.New("invalid order status")
 )
 
 type Order struct {
@@ -19,7 +46,7 @@ type orderClient struct {
 	restaurantID string
 }
 
-func (s *Service) CreateOrder(ctx context.Context, rid string, cid, status int64, items map[string]int64) error {
+func (s *Service) CreateOrder(ctx context.Context, rid string, cid, status int64, items map[string]int64, lines []OrderLineInput) error {
 	uid, auth := ctx.Value(KeyAuthFoodProviderID).(int64)
 	if !auth {
 		return ErrUnauthenticated
@@ -29,7 +56,12 @@ func (s *Service) CreateOrder(ctx context.Context, rid string, cid, status int64
 		return ErrInvalidRestaurantId
 	}
 
-	if _, err := s.checkPermission(ctx, Waiter, uid, rid); err != nil {
+	ll, err := orderLines(items, lines)
+	if err != nil {
+		return err
+	}
+
+	if _, err := s.checkPermission(ctx, Supervisor, uid, rid); err != nil {
 		fmt.Println("[Permission Failed]:", err)
 		return err
 	}
@@ -42,31 +74,32 @@ func (s *Service) CreateOrder(ctx context.Context, rid string, cid, status int64
 
 	var orderId int64
 	query := "INSERT INTO orders(cust_id, restaurant_id, status) VALUES ($1, $2, $3) RETURNING id"
-	err = tx.QueryRowContext(ctx, query, cid, rid, 1).Scan(&orderId)
+	err = tx.QueryRowContext(ctx, query, cid, rid, OrderPlaced).Scan(&orderId)
 	fk := isForeignKeyViolation(err)
 	if fk {
 		fmt.Println("[FK] ", err)
 		return ErrRestaurantNotFound
 	}
 
-	fmt.Println("[ORDER ID] ", orderId)
-
-	for item, quantity := range items {
-		_, err = tx.ExecContext(ctx, "INSERT INTO order_item(order_id, item_id, quantity) VALUES ($1, $2, $3)", orderId, item, quantity)
-		if err != nil {
-			fmt.Println("[ORDER ITEMS] ", err)
-			return err
-		}
+	if err != nil {
+		return fmt.Errorf("failed to create order: %v", err)
 	}
 
+	fmt.Println("[ORDER ID] ", orderId)
+
+	repriced, err := insertOrderItems(ctx, tx, orderId, rid, ll)
 	if err != nil {
-		return fmt.Errorf("failed to create order: %v", err)
+		return err
 	}
 
 	if err := tx.Commit(); err != nil {
 		return fmt.Errorf("failed to create order: could not commit transaction: %v", err)
 	}
 
+	if repriced != 0 {
+		s.menuChanged(ctx, rid)
+	}
+
 	var o Order
 	o.Id = orderId
 	o.CId = cid
@@ -75,7 +108,10 @@ func (s *Service) CreateOrder(ctx context.Context, rid string, cid, status int64
 	return nil
 }
 
-func (s *Service) CreateUserOrder(ctx context.Context, rid string, status int64, items map[string]int64) error {
+// CreateUserOrder places a customer's order, at a table when it was placed
+// through the table's menu link.
+func (s *Service) CreateUserOrder(ctx context.Context, rid string, status int64, items map[string]int64, lines []OrderLineInput,
+	table TableLink) error {
 	uid, auth := ctx.Value(KeyAuthUserID).(int64)
 	if !auth {
 		return ErrUnauthenticated
@@ -85,17 +121,59 @@ func (s *Service) CreateUserOrder(ctx context.Context, rid string, status int64,
 		return ErrInvalidRestaurantId
 	}
 
+	ll, err := orderLines(items, lines)
+	if err != nil {
+		return err
+	}
+
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("could not begin tx: %v", err)
+	}
+	defer func() { _ = tx.Rollback() }()
+
+	h, err := restaurantHours(ctx, tx, rid)
+	if err != nil {
+		return err
+	}
+
+	if !h.AcceptingOrders {
+		return ErrRestaurantClosed
+	}
+
+	tid, err := s.orderTable(ctx, tx, rid, table)
+	if err != nil {
+		return err
+	}
+
 	var orderId int64
-	query := "INSERT INTO orders(cust_id, restaurant_id, status) VALUES ($1, $2, $3) RETURNING id"
-	err := s.db.QueryRowContext(ctx, query, uid, rid, 1).Scan(&orderId)
+	query := "INSERT INTO orders(cust_id, restaurant_id, table_id, status) VALUES ($1, $2, $3, $4) RETURNING id"
+	err = tx.QueryRowContext(ctx, query, uid, rid, tid, OrderPlaced).Scan(&orderId)
 	fk := isForeignKeyViolation(err)
 	if fk {
 		fmt.Println("[FK] ", err)
 		return ErrRestaurantNotFound
 	}
 
+	if err != nil {
+		return fmt.Errorf("failed to create order: %v", err)
+	}
+
 	fmt.Println("[ORDER ID] ", orderId)
 
+	repriced, err := insertOrderItems(ctx, tx, orderId, rid, ll)
+	if err != nil {
+		return err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to create order: could not commit transaction: %v", err)
+	}
+
+	if repriced != 0 {
+		s.menuChanged(ctx, rid)
+	}
+
 	var o Order
 	o.Id = orderId
 	o.CId = uid
@@ -106,6 +184,196 @@ func (s *Service) CreateUserOrder(ctx context.Context, rid string, status int64,
 	return nil
 }
 
+// UpdateOrderStatus moves an order of the restaurant forward. Completed and
+// cancelled orders can no longer be changed.
+func (s *Service) UpdateOrderStatus(ctx context.Context, rid string, oid, status int64) error {
+	uid, auth := ctx.Value(KeyAuthFoodProviderID).(int64)
+	if !auth {
+		return ErrUnauthenticated
+	}
+
+	if !rxUUID.MatchString(rid) {
+		return ErrInvalidRestaurantId
+	}
+
+	if status < OrderPlaced || status > OrderCancelled {
+		return ErrInvalidOrderStatus
+	}
+
+	if _, err := s.checkPermission(ctx, Waiter, uid, rid); err != nil {
+		return err
+	}
+
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("could not begin tx: %v", err)
+	}
+	defer func() { _ = tx.Rollback() }()
+
+	var current int64
+	query := "SELECT status FROM orders WHERE id = $1 AND restaurant_id = $2"
+	err = tx.QueryRowContext(ctx, query, oid, rid).Scan(&current)
+	if err == sql.ErrNoRows {
+		return ErrOrderNotFound
+	}
+
+	if err != nil {
+		return fmt.Errorf("could not query order: %v", err)
+	}
+
+	if current == OrderCompleted || current == OrderCancelled || (status <= current && status != OrderCancelled) {
+		return ErrInvalidOrderStatus
+	}
+
+	// Ingredients are used up once the kitchen accepts the order and given
+	// back when an accepted order gets cancelled.
+	if current < OrderAccepted && status >= OrderAccepted && status != OrderCancelled {
+		err = useStock(ctx, tx, rid, oid, 1)
+	} else if current >= OrderAccepted && status == OrderCancelled {
+		err = useStock(ctx, tx, rid, oid, -1)
+	}
+	if err != nil {
+		return err
+	}
+
+	query = "UPDATE orders SET status = $1 WHERE id = $2"
+	if _, err = tx.ExecContext(ctx, query, status, oid); err != nil {
+		return fmt.Errorf("failed to update order status: %v", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to update order status: could not commit transaction: %v", err)
+	}
+
+	return nil
+}
+
+// OrderLineInput is an item to order with its chosen variant, modifier
+// options and, for combos, the item chosen for each slot.
+type OrderLineInput struct {
+	ItemId    int64           `json:"item_id"`
+	VariantId int64           `json:"variant_id"`
+	Options   []int64         `json:"options"`
+	Choices   map[int64]int64 `json:"choices"`
+	Quantity  int64           `json:"quantity"`
+}
+
+// orderLines merges the plain item quantities of an order with its lines.
+func orderLines(items map[string]int64, lines []OrderLineInput) ([]OrderLineInput, error) {
+	ll := make([]OrderLineInput, 0, len(items)+len(lines))
+	for item, quantity := range items {
+		iid, err := strconv.ParseInt(item, 10, 64)
+		if err != nil {
+			return nil, ErrItemNotFound
+		}
+
+		ll = append(ll, OrderLineInput{ItemId: iid, Quantity: quantity})
+	}
+
+	return append(ll, lines...), nil
+}
+
+// insertOrderItems adds the ordered items to the order, keeping the price
+// each item had at the time of ordering. Variants replace the item's price
+// and chosen modifier options are added on top of it. It tells how many due
+// price changes were applied first, so the menu can be reindexed.
+func insertOrderItems(ctx context.Context, tx *sql.Tx, oid int64, rid string, lines []OrderLineInput) (int, error) {
+	if len(lines) == 0 {
+		return 0, ErrEmptyOrder
+	}
+
+	repriced, err := applyDuePrices(ctx, tx, rid)
+	if err != nil {
+		return 0, err
+	}
+
+	offCategories, offItems, err := offSchedule(ctx, tx, rid)
+	if err != nil {
+		return 0, err
+	}
+
+	pricing, err := activePricing(ctx, tx, rid)
+	if err != nil {
+		return 0, err
+	}
+
+	for _, l := range lines {
+		if l.Quantity < 1 {
+			return 0, ErrInvalidQuantity
+		}
+
+		var cid int64
+		var price float64
+		query := `
+			SELECT category_id, price FROM item
+			WHERE id = $1 AND restaurant_id = $2 AND availability = true AND sold_out = false AND archived = false
+				AND EXISTS (SELECT 1 FROM category c WHERE c.id = item.category_id AND c.availability = true)`
+		err := tx.QueryRowContext(ctx, query, l.ItemId, rid).Scan(&cid, &price)
+		if err == sql.ErrNoRows || offCategories[cid] || offItems[l.ItemId] {
+			return 0, ErrItemNotFound
+		}
+
+		if err != nil {
+			return 0, fmt.Errorf("could not query order item: %v", err)
+		}
+
+		opts, err := itemOptions(ctx, tx, rid, l.ItemId)
+		if err != nil {
+			return 0, err
+		}
+
+		var variant sql.NullInt64
+		var groups []ModifierGroup
+		if o, ok := opts[l.ItemId]; ok {
+			groups = o.Modifiers
+			for _, v := range o.Variants {
+				if v.Id == l.VariantId {
+					variant = sql.NullInt64{Int64: v.Id, Valid: true}
+					price = v.Price
+				}
+			}
+
+			if len(o.Variants) != 0 && !variant.Valid {
+				return 0, ErrInvalidVariant
+			}
+		}
+
+		if l.VariantId != 0 && !variant.Valid {
+			return 0, ErrInvalidVariant
+		}
+
+		// Deals apply to the item or variant, not to its options.
+		price, _ = pricing.price(l.ItemId, cid, price)
+		extra, chosen, err := priceModifiers(groups, l.Options)
+		if err != nil {
+			return 0, err
+		}
+
+		var id int64
+		query = `
+			INSERT INTO order_item (order_id, item_id, variant_id, quantity, price)
+			VALUES ($1, $2, $3, $4, $5)
+			RETURNING id`
+		err = tx.QueryRowContext(ctx, query, oid, l.ItemId, variant, l.Quantity, price+extra).Scan(&id)
+		if err != nil {
+			return 0, fmt.Errorf("could not add order item: %v", err)
+		}
+
+		query = "INSERT INTO order_item_option (order_item_id, option_id, name, price) VALUES ($1, $2, $3, $4)"
+		for _, o := range chosen {
+			if _, err = tx.ExecContext(ctx, query, id, o.Id, o.Name, o.Price); err != nil {
+				return 0, fmt.Errorf("could not add order item option: %v", err)
+			}
+		}
+
+		if err = insertComboComponents(ctx, tx, rid, id, l, offCategories, offItems); err != nil {
+			return 0, err
+		}
+	}
+
+	return repriced, nil
+}
+
 func (s *Service) orderCreated(o Order) {
 	//u, err := s.userByID(context.Background(), o.CId)
 	//if err != nil {
@@ -148,7 +416,7 @@ func (s *Service) GetOrders(ctx context.Context, rid string) (*[]Order, error) {
 		return nil, ErrInvalidRestaurantId
 	}
 
-	if _, err := s.checkPermission(ctx, Waiter, uid, rid); err != nil {
+	if _, err := s.checkPermission(ctx, Supervisor, uid, rid); err != nil {
 		fmt.Println("[Permission Failed]:", err)
 		return nil, err
 	}
@@ -196,3 +464,420 @@ func (s *Service) broadcastOrder(o Order) {
 		return true
 	})
 }
+
+func getOrderStatus(status int64) string {
+	switch status {
+	case OrderPlaced:
+		return "Placed"
+	case OrderAccepted:
+		return "Accepted"
+	case OrderPreparing:
+		return "Preparing"
+	case OrderReady:
+		return "Ready"
+	case OrderCompleted:
+		return "Completed"
+	case OrderCancelled:
+		return "Cancelled"
+	}
+
+	return "Unknown"
+}
+
+// OrderSearch filters the orders of a restaurant. Zero values are ignored.
+type OrderSearch struct {
+	Id        int64
+	Phone     string
+	Name      string
+	From      time.Time
+	To        time.Time
+	MinAmount float64
+	MaxAmount float64
+	Status    int64
+	Last      int64
+	First     int
+}
+
+// OrderSummary is an order as support staff see it.
+type OrderSummary struct {
+	Id         int64       `json:"id"`
+	CustomerId int64       `json:"customer_id"`
+	Customer   string      `json:"customer"`
+	Phone      string      `json:"phone"`
+	Status     string      `json:"status"`
+	Total      float64     `json:"total"`
+	CreatedAt  time.Time   `json:"created_at"`
+	Items      []OrderLine `json:"items,omitempty"`
+}
+
+// OrderLine is an item of an order with the price it was ordered at.
+type OrderLine struct {
+	ItemId   int64   `json:"item_id"`
+	Name     string  `json:"name"`
+	Quantity int64   `json:"quantity"`
+	Price    float64 `json:"price"`
+}
+
+const orderSearchQuery = `
+	SELECT s.id, s.cust_id, s.fullname, s.phone, s.status, s.created_at, s.total
+	{{if .lines}}
+	, oi.item_id, i.name, oi.quantity, oi.price
+	{{end}}
+	FROM (
+		SELECT o.id, o.cust_id, u.fullname, COALESCE(u.phone, '') AS phone, o.status, o.created_at,
+			(SELECT COALESCE(SUM(oi.price * oi.quantity), 0) FROM order_item oi WHERE oi.order_id = o.id) AS total
+		FROM orders o INNER JOIN users u ON o.cust_id = u.id
+		WHERE o.restaurant_id = @rid
+		{{if .id}}
+		AND o.id = @id
+		{{end}}
+		{{if .phone}}
+		AND u.phone LIKE @phone
+		{{end}}
+		{{if .name}}
+		AND u.fullname ILIKE @name
+		{{end}}
+		{{if .from}}
+		AND o.created_at >= @from
+		{{end}}
+		{{if .to}}
+		AND o.created_at < @to
+		{{end}}
+		{{if .status}}
+		AND o.status = @status
+		{{end}}
+		{{if .last}}
+		AND o.id < @last
+		{{end}}
+	) s
+	{{if .lines}}
+	LEFT JOIN order_item oi ON oi.order_id = s.id
+	LEFT JOIN item i ON oi.item_id = i.id
+	{{end}}
+	WHERE true
+	{{if .min}}
+	AND s.total >= @min
+	{{end}}
+	{{if .max}}
+	AND s.total <= @max
+	{{end}}
+	ORDER BY s.id DESC
+	{{if .lines}}
+	, oi.item_id
+	{{end}}
+	{{if .first}}
+	LIMIT @first
+	{{end}}`
+
+// buildOrderSearch turns the search into the sql query. Export queries join
+// the order items and are not paginated.
+func buildOrderSearch(rid string, search OrderSearch, export bool) (string, []interface{}, error) {
+	data := map[string]interface{}{
+		"rid":   rid,
+		"lines": export,
+	}
+	if search.Id > 0 {
+		data["id"] = search.Id
+	}
+	if phone := strings.Map(func(r rune) rune {
+		if r == '+' || (r >= '0' && r <= '9') {
+			return r
+		}
+		return -1
+	}, search.Phone); phone != "" {
+		data["phone"] = "%" + phone + "%"
+	}
+	if name := strings.TrimSpace(search.Name); name != "" {
+		name = strings.NewReplacer(`\`, `\\`, "%", `\%`, "_", `\_`).Replace(name)
+		data["name"] = "%" + name + "%"
+	}
+	if !search.From.IsZero() {
+		data["from"] = search.From
+	}
+	if !search.To.IsZero() {
+		data["to"] = search.To
+	}
+	if search.MinAmount > 0 {
+		data["min"] = search.MinAmount
+	}
+	if search.MaxAmount > 0 {
+		data["max"] = search.MaxAmount
+	}
+	if search.Status > 0 {
+		data["status"] = search.Status
+	}
+	if !export {
+		if search.Last > 0 {
+			data["last"] = search.Last
+		}
+		data["first"] = normalizePageSize(search.First)
+	}
+
+	return buildQuery(orderSearchQuery, data)
+}
+
+func (s *Service) checkOrderSearch(ctx context.Context, rid string, search OrderSearch) error {
+	uid, auth := ctx.Value(KeyAuthFoodProviderID).(int64)
+	if !auth {
+		return ErrUnauthenticated
+	}
+
+	if !rxUUID.MatchString(rid) {
+		return ErrInvalidRestaurantId
+	}
+
+	if !search.From.IsZero() && !search.To.IsZero() && !search.To.After(search.From) {
+		return ErrInvalidDateRange
+	}
+
+	if search.MinAmount < 0 || search.MaxAmount < 0 || (search.MaxAmount > 0 && search.MaxAmount < search.MinAmount) {
+		return ErrInvalidAmount
+	}
+
+	_, err := s.checkPermission(ctx, Waiter, uid, rid)
+	return err
+}
+
+// SearchOrders of the restaurant, newest first.
+func (s *Service) SearchOrders(ctx context.Context, rid string, search OrderSearch) ([]OrderSummary, error) {
+	if err := s.checkOrderSearch(ctx, rid, search); err != nil {
+		return nil, err
+	}
+
+	query, args, err := buildOrderSearch(rid, search, false)
+	if err != nil {
+		return nil, fmt.Errorf("could not build order search sql query: %v", err)
+	}
+
+	rows, err := s.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("could not search orders: %v", err)
+	}
+
+	defer rows.Close()
+	oo := make([]OrderSummary, 0)
+	for rows.Next() {
+		var o OrderSummary
+		var status int64
+		if err = rows.Scan(&o.Id, &o.CustomerId, &o.Customer, &o.Phone, &status, &o.CreatedAt, &o.Total); err != nil {
+			return nil, fmt.Errorf("could not scan order: %v", err)
+		}
+
+		o.Status = getOrderStatus(status)
+		oo = append(oo, o)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("could not iterate orders: %v", err)
+	}
+
+	return oo, nil
+}
+
+// ExportOrders streams every order matching the search together with its
+// items to fn, one order at a time, so large exports are never held in
+// memory.
+func (s *Service) ExportOrders(ctx context.Context, rid string, search OrderSearch, fn func(OrderSummary) error) error {
+	if err := s.checkOrderSearch(ctx, rid, search); err != nil {
+		return err
+	}
+
+	query, args, err := buildOrderSearch(rid, search, true)
+	if err != nil {
+		return fmt.Errorf("could not build order export sql query: %v", err)
+	}
+
+	rows, err := s.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return fmt.Errorf("could not export orders: %v", err)
+	}
+
+	defer rows.Close()
+	var current *OrderSummary
+	for rows.Next() {
+		var o OrderSummary
+		var status int64
+		var itemId, quantity sql.NullInt64
+		var name sql.NullString
+		var price sql.NullFloat64
+		if err = rows.Scan(&o.Id, &o.CustomerId, &o.Customer, &o.Phone, &status, &o.CreatedAt, &o.Total,
+			&itemId, &name, &quantity, &price); err != nil {
+			return fmt.Errorf("could not scan order: %v", err)
+		}
+
+		if current == nil || current.Id != o.Id {
+			if current != nil {
+				if err = fn(*current); err != nil {
+					return err
+				}
+			}
+			o.Status = getOrderStatus(status)
+			o.Items = make([]OrderLine, 0)
+			current = &o
+		}
+
+		if itemId.Valid {
+			current.Items = append(current.Items, OrderLine{
+				ItemId:   itemId.Int64,
+				Name:     name.String,
+				Quantity: quantity.Int64,
+				Price:    price.Float64,
+			})
+		}
+	}
+
+	if err = rows.Err(); err != nil {
+		return fmt.Errorf("could not iterate orders: %v", err)
+	}
+
+	if current != nil {
+		return fn(*current)
+	}
+
+	return nil
+}
+
+// Ticket is an order as the kitchen prepares it, with combos expanded into
+// their components.
+type Ticket struct {
+	OrderId   int64        `json:"order_id"`
+	Table     string       `json:"table,omitempty"`
+	Status    string       `json:"status"`
+	CreatedAt time.Time    `json:"created_at"`
+	Lines     []TicketLine `json:"lines"`
+}
+
+// TicketLine is an ordered item to prepare.
+type TicketLine struct {
+	Name       string            `json:"name"`
+	Variant    string            `json:"variant,omitempty"`
+	Quantity   int64             `json:"quantity"`
+	Options    []string          `json:"options,omitempty"`
+	Components []TicketComponent `json:"components,omitempty"`
+}
+
+// TicketComponent is an item to prepare for a combo.
+type TicketComponent struct {
+	Slot     string `json:"slot"`
+	Name     string `json:"name"`
+	Quantity int64  `json:"quantity"`
+}
+
+// GetKitchenTicket of an order of the restaurant.
+func (s *Service) GetKitchenTicket(ctx context.Context, rid string, oid int64) (Ticket, error) {
+	var t Ticket
+	uid, auth := ctx.Value(KeyAuthFoodProviderID).(int64)
+	if !auth {
+		return t, ErrUnauthenticated
+	}
+
+	if !rxUUID.MatchString(rid) {
+		return t, ErrInvalidRestaurantId
+	}
+
+	if _, err := s.checkPermission(ctx, Waiter, uid, rid); err != nil {
+		return t, err
+	}
+
+	var status int64
+	query := `
+		SELECT o.id, COALESCE(t.label, ''), o.status, o.created_at
+		FROM orders o LEFT JOIN dining_table t ON o.table_id = t.id
+		WHERE o.id = $1 AND o.restaurant_id = $2`
+	err := s.db.QueryRowContext(ctx, query, oid, rid).Scan(&t.OrderId, &t.Table, &status, &t.CreatedAt)
+	if err == sql.ErrNoRows {
+		return t, ErrOrderNotFound
+	}
+
+	if err != nil {
+		return t, fmt.Errorf("could not query order: %v", err)
+	}
+
+	t.Status = getOrderStatus(status)
+
+	query = `
+		SELECT oi.id, i.name, COALESCE(v.name, ''), oi.quantity
+		FROM order_item oi
+			INNER JOIN item i ON oi.item_id = i.id
+			LEFT JOIN item_variant v ON oi.variant_id = v.id
+		WHERE oi.order_id = $1
+		ORDER BY oi.id`
+	rows, err := s.db.QueryContext(ctx, query, oid)
+	if err != nil {
+		return t, fmt.Errorf("could not query order items: %v", err)
+	}
+
+	defer rows.Close()
+	lines := make(map[int64]int)
+	t.Lines = make([]TicketLine, 0)
+	for rows.Next() {
+		var id int64
+		var l TicketLine
+		if err = rows.Scan(&id, &l.Name, &l.Variant, &l.Quantity); err != nil {
+			return t, fmt.Errorf("could not scan order item: %v", err)
+		}
+
+		lines[id] = len(t.Lines)
+		t.Lines = append(t.Lines, l)
+	}
+
+	if err = rows.Err(); err != nil {
+		return t, fmt.Errorf("could not iterate order items: %v", err)
+	}
+
+	query = `
+		SELECT o.order_item_id, o.name
+		FROM order_item_option o INNER JOIN order_item oi ON o.order_item_id = oi.id
+		WHERE oi.order_id = $1
+		ORDER BY o.option_id`
+	rows, err = s.db.QueryContext(ctx, query, oid)
+	if err != nil {
+		return t, fmt.Errorf("could not query order item options: %v", err)
+	}
+
+	defer rows.Close()
+	for rows.Next() {
+		var id int64
+		var name string
+		if err = rows.Scan(&id, &name); err != nil {
+			return t, fmt.Errorf("could not scan order item option: %v", err)
+		}
+
+		l := &t.Lines[lines[id]]
+		l.Options = append(l.Options, name)
+	}
+
+	if err = rows.Err(); err != nil {
+		return t, fmt.Errorf("could not iterate order item options: %v", err)
+	}
+
+	query = `
+		SELECT c.order_item_id, c.slot, i.name, c.quantity * oi.quantity
+		FROM order_item_component c
+			INNER JOIN order_item oi ON c.order_item_id = oi.id
+			INNER JOIN item i ON c.item_id = i.id
+		WHERE oi.order_id = $1
+		ORDER BY c.order_item_id, c.position`
+	rows, err = s.db.QueryContext(ctx, query, oid)
+	if err != nil {
+		return t, fmt.Errorf("could not query combo components: %v", err)
+	}
+
+	defer rows.Close()
+	for rows.Next() {
+		var id int64
+		var c TicketComponent
+		if err = rows.Scan(&id, &c.Slot, &c.Name, &c.Quantity); err != nil {
+			return t, fmt.Errorf("could not scan combo component: %v", err)
+		}
+
+		l := &t.Lines[lines[id]]
+		l.Components = append(l.Components, c)
+	}
+
+	if err = rows.Err(); err != nil {
+		return t, fmt.Errorf("could not iterate combo components: %v", err)
+	}
+
+	return t, nil
+}
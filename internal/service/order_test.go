@@ -0,0 +1,224 @@
+package service
+
+import (
+	"context"
+	"net/url"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/hako/branca"
+	"github.com/jackc/pgx"
+	"github.com/jackc/pgx/stdlib"
+)
+
+func TestBuildOrderSearch(t *testing.T) {
+	rid := "2cdbd2ad-06e2-4a77-a9a1-0fd8f1a1c4b5"
+	from := time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)
+	to := from.AddDate(0, 1, 0)
+	all := []string{"o.id = $", "u.phone LIKE", "u.fullname ILIKE", "o.created_at >=", "o.created_at <", "o.status =",
+		"o.id <", "s.total >=", "s.total <=", "LEFT JOIN order_item", "LIMIT"}
+
+	var tt = []struct {
+		Label  string
+		Search OrderSearch
+		Export bool
+		Want   []string
+		Args   []interface{}
+	}{
+		{Label: "Test should only page the restaurant's orders without filters", Want: []string{"LIMIT"},
+			Args: []interface{}{rid, defaultPageSize}},
+		{Label: "Test should filter by id and status", Search: OrderSearch{Id: 42, Status: OrderReady},
+			Want: []string{"o.id = $", "o.status =", "LIMIT"}, Args: []interface{}{rid, int64(42), OrderReady, defaultPageSize}},
+		{Label: "Test should match the digits of a phone", Search: OrderSearch{Phone: "+880 1711-000"},
+			Want: []string{"u.phone LIKE", "LIMIT"}, Args: []interface{}{rid, "%+8801711000%", defaultPageSize}},
+		{Label: "Test should ignore a phone without digits", Search: OrderSearch{Phone: "abc"},
+			Want: []string{"LIMIT"}, Args: []interface{}{rid, defaultPageSize}},
+		{Label: "Test should escape wildcards in a name", Search: OrderSearch{Name: " 50%_off "},
+			Want: []string{"u.fullname ILIKE", "LIMIT"}, Args: []interface{}{rid, `%50\%\_off%`, defaultPageSize}},
+		{Label: "Test should filter by date range", Search: OrderSearch{From: from, To: to},
+			Want: []string{"o.created_at >=", "o.created_at <", "LIMIT"}, Args: []interface{}{rid, from, to, defaultPageSize}},
+		{Label: "Test should filter by amount range", Search: OrderSearch{MinAmount: 100, MaxAmount: 500},
+			Want: []string{"s.total >=", "s.total <=", "LIMIT"}, Args: []interface{}{rid, 100.0, 500.0, defaultPageSize}},
+		{Label: "Test should page after the cursor", Search: OrderSearch{Last: 10, First: 5},
+			Want: []string{"o.id <", "LIMIT"}, Args: []interface{}{rid, int64(10), 5}},
+		{Label: "Test should export every order with its items", Search: OrderSearch{Last: 10, First: 5, Status: OrderCompleted},
+			Export: true, Want: []string{"o.status =", "LEFT JOIN order_item"}, Args: []interface{}{rid, OrderCompleted}},
+	}
+
+	for _, test := range tt {
+		t.Run(test.Label, func(t *testing.T) {
+			query, args, err := buildOrderSearch(rid, test.Search, test.Export)
+			if err != nil {
+				t.Fatal("Got:", err, "| Want:", nil)
+			}
+
+			want := make(map[string]bool)
+			for _, w := range test.Want {
+				want[w] = true
+			}
+
+			for _, clause := range all {
+				if strings.Contains(query, clause) != want[clause] {
+					t.Error("Got:", strings.Contains(query, clause), "| Want:", want[clause], "for", clause)
+				}
+			}
+
+			if len(args) != len(test.Args) {
+				t.Fatal("Got:", args, "| Want:", test.Args)
+			}
+
+			for _, a := range test.Args {
+				found := false
+				for _, arg := range args {
+					if arg == a {
+						found = true
+					}
+				}
+
+				if !found {
+					t.Error("Got:", args, "| Want:", a)
+				}
+			}
+		})
+	}
+}
+
+func TestSearchOrders(t *testing.T) {
+	tearDown := SetupTest()
+	defer tearDown()
+
+	ctx := context.TODO()
+
+	codec := branca.NewBranca("supersecretkeyyoushouldnotcommit")
+	codec.SetTTL(uint32(TokenLifeSpan.Seconds()))
+
+	fpCodec := branca.NewBranca("supersecretkeyyoushouldcommitnot")
+	fpCodec.SetTTL(uint32(TokenLifeSpan.Seconds()))
+
+	c, err := pgx.ParseURI(pgURL.String())
+	if err != nil {
+		log.Fatalf(err.Error())
+	}
+
+	db := stdlib.OpenDB(c)
+
+	if err := ValidateSchema(db); err != nil {
+		log.Fatalf("failed to validate schema: %v\n", err)
+	}
+
+	s := New(db, codec, fpCodec, nil, url.URL{})
+
+	_ = s.CreateFoodProvider(ctx, "johndoe@gmail.com", "John Snow", "01867584576", "ilovegolang")
+	user, _ := s.FoodProviderLogin(ctx, "01867584576", "ilovegolang")
+	ctx = context.WithValue(ctx, KeyAuthFoodProviderID, user.AuthUser.ID)
+	_ = s.CreateRestaurant(ctx, "test.Title", "test.About", "01616534596", "test.Location", "test.City", "test.Area", "test.Country")
+	user, _ = s.FoodProviderLogin(ctx, "01867584576", "ilovegolang")
+	rid := (*user.Restaurants)[0].Id
+
+	_ = s.CreateCategory(ctx, rid, "Rice", true)
+	cc, _ := s.GetCategoriesByRestaurant(ctx, rid)
+	_ = s.CreateItem(ctx, rid, cc[0].Id, "Biryani", "Mutton", 300, true)
+	_ = s.CreateItem(ctx, rid, cc[0].Id, "Borhani", "Yogurt", 50, true)
+	menu, _ := s.GetMenuForFp(ctx, rid)
+	iids := make(map[string]int64)
+	for _, it := range *menu {
+		iids[it.Name], _ = strconv.ParseInt(it.Id, 10, 64)
+	}
+
+	customer := func(email, name, phone string) int64 {
+		var id int64
+		query := "INSERT INTO users (email, fullname, phone) VALUES ($1, $2, $3) RETURNING id"
+		if err := db.QueryRow(query, email, name, phone).Scan(&id); err != nil {
+			t.Fatal(err)
+		}
+		return id
+	}
+
+	order := func(cust, status int64, items map[string]int64) int64 {
+		var id int64
+		query := "INSERT INTO orders (cust_id, restaurant_id, status) VALUES ($1, $2, $3) RETURNING id"
+		if err := db.QueryRow(query, cust, rid, status).Scan(&id); err != nil {
+			t.Fatal(err)
+		}
+
+		for name, quantity := range items {
+			price := 300
+			if name == "Borhani" {
+				price = 50
+			}
+
+			query = "INSERT INTO order_item (order_id, item_id, quantity, price) VALUES ($1, $2, $3, $4)"
+			if _, err := db.Exec(query, id, iids[name], quantity, price); err != nil {
+				t.Fatal(err)
+			}
+		}
+		return id
+	}
+
+	jane := customer("jane@gmail.com", "Jane Doe", "01711000001")
+	june := customer("june@gmail.com", "June Roe", "01811000002")
+	first := order(jane, OrderCompleted, map[string]int64{"Biryani": 1, "Borhani": 2})
+	second := order(june, OrderCompleted, map[string]int64{"Biryani": 2})
+	third := order(jane, OrderPlaced, map[string]int64{"Borhani": 1})
+
+	ids := func(oo []OrderSummary) []int64 {
+		ii := make([]int64, 0, len(oo))
+		for _, o := range oo {
+			ii = append(ii, o.Id)
+		}
+		return ii
+	}
+
+	var tt = []struct {
+		Label  string
+		Search OrderSearch
+		Want   []int64
+	}{
+		{Label: "Test should list the newest orders first", Search: OrderSearch{}, Want: []int64{third, second, first}},
+		{Label: "Test should page the first orders", Search: OrderSearch{First: 2}, Want: []int64{third, second}},
+		{Label: "Test should page after the cursor", Search: OrderSearch{First: 2, Last: second}, Want: []int64{first}},
+		{Label: "Test should filter by phone", Search: OrderSearch{Phone: "0171"}, Want: []int64{third, first}},
+		{Label: "Test should filter by name and status", Search: OrderSearch{Name: "jane", Status: OrderCompleted}, Want: []int64{first}},
+		{Label: "Test should filter by amount", Search: OrderSearch{MinAmount: 400, MaxAmount: 600}, Want: []int64{second, first}},
+		{Label: "Test should find nothing past the last order", Search: OrderSearch{Last: first}, Want: []int64{}},
+	}
+
+	for _, test := range tt {
+		t.Run(test.Label, func(t *testing.T) {
+			oo, err := s.SearchOrders(ctx, rid, test.Search)
+			if err != nil {
+				t.Fatal("Got:", err, "| Want:", nil)
+			}
+
+			if got := ids(oo); !cmp.Equal(got, test.Want) {
+				t.Error("Got:", got, "| Want:", test.Want)
+			}
+		})
+	}
+
+	t.Run("Test should group the export lines by order", func(t *testing.T) {
+		var oo []OrderSummary
+		err := s.ExportOrders(ctx, rid, OrderSearch{Status: OrderCompleted}, func(o OrderSummary) error {
+			oo = append(oo, o)
+			return nil
+		})
+		if err != nil {
+			t.Fatal("Got:", err, "| Want:", nil)
+		}
+
+		if got := ids(oo); !cmp.Equal(got, []int64{second, first}) {
+			t.Fatal("Got:", got, "| Want:", []int64{second, first})
+		}
+
+		if len(oo[0].Items) != 1 || len(oo[1].Items) != 2 {
+			t.Error("Got:", len(oo[0].Items), len(oo[1].Items), "| Want: 1 2")
+		}
+
+		if oo[1].Total != 400 {
+			t.Error("Got:", oo[1].Total, "| Want:", 400)
+		}
+	})
+}
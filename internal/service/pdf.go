@@ -0,0 +1,141 @@
+package service
+
+import (
+	"bytes"
+	"fmt"
+	"image"
+	"image/jpeg"
+	"strings"
+
+	"github.com/disintegration/imaging"
+	"github.com/jung-kurt/gofpdf"
+	"golang.org/x/image/font/gofont/gobold"
+	"golang.org/x/image/font/gofont/goregular"
+)
+
+const (
+	pdfMargin = 50.0
+	pdfFont   = "menu"
+)
+
+// menuFonts are the TrueType fonts printable PDF menus are set in, embedded
+// as Unicode so any script the fonts have glyphs for prints.
+type menuFonts struct {
+	regular, bold []byte
+}
+
+// defaultMenuFonts are the Go fonts, which cover Latin, Greek and Cyrillic.
+var defaultMenuFonts = menuFonts{regular: goregular.TTF, bold: gobold.TTF}
+
+// SetMenuFonts sets the TrueType fonts of printable PDF menus, e.g. to print
+// names in scripts the Go fonts lack. The regular font is used for bold text
+// too when bold is nil.
+func (s *Service) SetMenuFonts(regular, bold []byte) {
+	if bold == nil {
+		bold = regular
+	}
+	s.fonts = menuFonts{regular: regular, bold: bold}
+}
+
+// pdfImage registers the image as a JPEG under the name. Images that can't
+// be encoded are left out.
+func pdfImage(pdf *gofpdf.Fpdf, name string, img image.Image) bool {
+	var b bytes.Buffer
+	if err := jpeg.Encode(&b, img, &jpeg.Options{Quality: 85}); err != nil {
+		return false
+	}
+
+	pdf.RegisterImageOptionsReader(name, gofpdf.ImageOptions{ImageType: "JPG"}, &b)
+	return pdf.Ok()
+}
+
+// pdf of the printable menu in the fonts, with its pictures read from files.
+// Pictures that can't be read are left out. Text is set glyph by glyph, so
+// scripts that need shaping print without their ligatures; PrintableMenu
+// refuses the locales written in them.
+func (m PrintMenu) pdf(fonts menuFonts, avatarFile, coverFile string) ([]byte, error) {
+	pdf := gofpdf.New("P", "pt", "A4", "")
+	pdf.SetMargins(pdfMargin, pdfMargin, pdfMargin)
+	pdf.SetAutoPageBreak(true, pdfMargin)
+	pdf.AddUTF8FontFromBytes(pdfFont, "", fonts.regular)
+	pdf.AddUTF8FontFromBytes(pdfFont, "B", fonts.bold)
+	pdf.AddPage()
+
+	pageWidth, pageHeight := pdf.GetPageSize()
+	width := pageWidth - 2*pdfMargin
+	if coverFile != "" {
+		if img, err := imaging.Open(coverFile); err == nil &&
+			pdfImage(pdf, "cover", imaging.Fill(img, 990, 330, imaging.Center, imaging.Lanczos)) {
+			pdf.ImageOptions("cover", pdfMargin, pdf.GetY(), width, width/3, true, gofpdf.ImageOptions{}, 0, "")
+			pdf.Ln(20)
+		}
+	}
+
+	top, x := pdf.GetY(), pdfMargin
+	if avatarFile != "" {
+		if img, err := imaging.Open(avatarFile); err == nil &&
+			pdfImage(pdf, "avatar", imaging.Fill(img, 200, 200, imaging.Center, imaging.Lanczos)) {
+			pdf.ImageOptions("avatar", pdfMargin, top, 48, 48, false, gofpdf.ImageOptions{}, 0, "")
+			x += 60
+		}
+	}
+
+	pdf.SetFont(pdfFont, "B", 24)
+	pdf.SetXY(x, top+10)
+	pdf.CellFormat(0, 28, m.Title, "", 1, "L", false, 0, "")
+	pdf.SetY(top + 60)
+	if m.About != "" {
+		pdf.SetFont(pdfFont, "", 11)
+		pdf.SetTextColor(102, 102, 102)
+		pdf.MultiCell(0, 15, m.About, "", "L", false)
+	}
+
+	pdf.SetDrawColor(204, 204, 204)
+	pdf.SetLineWidth(0.5)
+	for _, c := range m.Categories {
+		if pdf.GetY()+60 > pageHeight-pdfMargin {
+			pdf.AddPage()
+		}
+
+		pdf.Ln(16)
+		pdf.SetFont(pdfFont, "B", 15)
+		pdf.SetTextColor(0, 0, 0)
+		pdf.CellFormat(0, 24, strings.ToUpper(c.Label), "B", 1, "L", false, 0, "")
+		pdf.Ln(8)
+		for _, i := range c.Items {
+			if pdf.GetY()+30 > pageHeight-pdfMargin {
+				pdf.AddPage()
+			}
+
+			price := formatPrice(i.Price)
+			pdf.SetFont(pdfFont, "", 12)
+			pdf.SetTextColor(0, 0, 0)
+			priceWidth := pdf.GetStringWidth(price) + 4
+			y := pdf.GetY()
+			pdf.SetXY(pageWidth-pdfMargin-priceWidth, y)
+			pdf.CellFormat(priceWidth, 16, price, "", 0, "R", false, 0, "")
+			pdf.SetXY(pdfMargin, y)
+			pdf.SetFont(pdfFont, "B", 12)
+			pdf.MultiCell(width-priceWidth-16, 16, i.Name, "", "L", false)
+			if i.Description != "" {
+				pdf.SetFont(pdfFont, "", 10)
+				pdf.SetTextColor(89, 89, 89)
+				pdf.MultiCell(0, 14, i.Description, "", "L", false)
+			}
+
+			if notes := printNotes(i.Dietary); notes != "" {
+				pdf.SetFont(pdfFont, "", 9)
+				pdf.SetTextColor(128, 128, 128)
+				pdf.MultiCell(0, 13, notes, "", "L", false)
+			}
+			pdf.Ln(8)
+		}
+	}
+
+	var b bytes.Buffer
+	if err := pdf.Output(&b); err != nil {
+		return nil, fmt.Errorf("could not render printable menu pdf: %v", err)
+	}
+
+	return b.Bytes(), nil
+}
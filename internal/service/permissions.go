@@ -16,6 +16,8 @@ const (
 	Waiter
 )
 
+// checkPermission admits food providers whose role on the restaurant is the
+// level or above, and returns the restaurant's title.
 func (s *Service) checkPermission(ctx context.Context, level permission, userId int64, RestaurantId string) (string, error) {
 	var role permission
 	var title string
@@ -30,13 +32,20 @@ func (s *Service) checkPermission(ctx context.Context, level permission, userId
 
 	fmt.Println("ROLE:", role)
 	fmt.Println("LEVEL:", level)
-	if role >= level {
+	if !role.admits(level) {
 		return "", ErrUnauthenticated
 	}
 
 	return title, nil
 }
 
+// admits reports whether a food provider holding role may act at level.
+// Lower values are more privileged, so the level itself and every role
+// above it are let in.
+func (role permission) admits(level permission) bool {
+	return role <= level
+}
+
 func getRole(role int) string {
 	switch role {
 	case 0:
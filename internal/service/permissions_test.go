@@ -0,0 +1,37 @@
+package service
+
+import "testing"
+
+func TestPermissionAdmits(t *testing.T) {
+	type testdata struct {
+		Label string
+		Role  permission
+		Level permission
+		Want  bool
+	}
+
+	var tt = []testdata{
+		{Label: "Test should admit Admin at Admin", Role: Admin, Level: Admin, Want: true},
+		{Label: "Test should not admit Owner at Admin", Role: Owner, Level: Admin, Want: false},
+		{Label: "Test should admit Admin at Owner", Role: Admin, Level: Owner, Want: true},
+		{Label: "Test should admit Owner at Owner", Role: Owner, Level: Owner, Want: true},
+		{Label: "Test should not admit Manager at Owner", Role: Manager, Level: Owner, Want: false},
+		{Label: "Test should admit Owner at Manager", Role: Owner, Level: Manager, Want: true},
+		{Label: "Test should admit Manager at Manager", Role: Manager, Level: Manager, Want: true},
+		{Label: "Test should not admit Supervisor at Manager", Role: Supervisor, Level: Manager, Want: false},
+		{Label: "Test should admit Manager at Supervisor", Role: Manager, Level: Supervisor, Want: true},
+		{Label: "Test should admit Supervisor at Supervisor", Role: Supervisor, Level: Supervisor, Want: true},
+		{Label: "Test should not admit Waiter at Supervisor", Role: Waiter, Level: Supervisor, Want: false},
+		{Label: "Test should admit Supervisor at Waiter", Role: Supervisor, Level: Waiter, Want: true},
+		{Label: "Test should admit Waiter at Waiter", Role: Waiter, Level: Waiter, Want: true},
+		{Label: "Test should admit Admin at Waiter", Role: Admin, Level: Waiter, Want: true},
+	}
+
+	for _, tc := range tt {
+		t.Run(tc.Label, func(t *testing.T) {
+			if got := tc.Role.admits(tc.Level); got != tc.Want {
+				t.Error("Got:", got, "| Want:", tc.Want)
+			}
+		})
+	}
+}
@@ -0,0 +1,303 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+)
+
+var (
+	// ErrInvalidPriceChange denotes a scheduled price change that doesn't take effect in the future.
+	ErrInvalidPriceChange = errors.New("invalid price change")
+	// ErrPriceChangeNotFound denotes a not found or already applied price change.
+	ErrPriceChangeNotFound = errors.New("price change not found")
+)
+
+// PriceChange of a menu item. Scheduled changes are pending until they take
+// effect; their previous price is only known then.
+type PriceChange struct {
+	Id            int64     `json:"id"`
+	Price         float64   `json:"price"`
+	PreviousPrice *float64  `json:"previous_price"`
+	ChangedBy     int64     `json:"changed_by"`
+	ChangedByName string    `json:"changed_by_name"`
+	EffectiveAt   time.Time `json:"effective_at"`
+	Pending       bool      `json:"pending"`
+	CreatedAt     time.Time `json:"created_at"`
+}
+
+// recordPriceChange of an item that took effect right away.
+func recordPriceChange(ctx context.Context, tx *sql.Tx, iid, uid int64, previous, price float64) error {
+	query := `
+		INSERT INTO item_price (item_id, price, previous_price, changed_by, effective_at, applied)
+		VALUES ($1, $2, $3, $4, now(), true)`
+	if _, err := tx.ExecContext(ctx, query, iid, price, previous, uid); err != nil {
+		return fmt.Errorf("could not record price change: %v", err)
+	}
+
+	return nil
+}
+
+// applyDuePrices sets the price of the restaurant's items whose scheduled
+// changes are due, oldest first, so the latest one wins. It tells how many
+// were applied.
+func applyDuePrices(ctx context.Context, tx *sql.Tx, rid string) (int, error) {
+	query := `
+		SELECT p.id, p.item_id, p.price
+		FROM item_price p INNER JOIN item i ON p.item_id = i.id
+		WHERE i.restaurant_id = $1 AND p.applied = false AND p.effective_at <= now()
+		ORDER BY p.effective_at, p.id`
+	rows, err := tx.QueryContext(ctx, query, rid)
+	if err != nil {
+		return 0, fmt.Errorf("could not query due price changes: %v", err)
+	}
+
+	type due struct {
+		id, iid int64
+		price   float64
+	}
+
+	dd := make([]due, 0)
+	for rows.Next() {
+		var d due
+		if err = rows.Scan(&d.id, &d.iid, &d.price); err != nil {
+			rows.Close()
+			return 0, fmt.Errorf("could not scan due price change: %v", err)
+		}
+
+		dd = append(dd, d)
+	}
+
+	rows.Close()
+	if err = rows.Err(); err != nil {
+		return 0, fmt.Errorf("could not iterate due price changes: %v", err)
+	}
+
+	for _, d := range dd {
+		query = "UPDATE item_price SET applied = true, previous_price = (SELECT price FROM item WHERE id = $1) WHERE id = $2"
+		if _, err = tx.ExecContext(ctx, query, d.iid, d.id); err != nil {
+			return 0, fmt.Errorf("could not apply price change: %v", err)
+		}
+
+		if _, err = tx.ExecContext(ctx, "UPDATE item SET price = $1 WHERE id = $2", d.price, d.iid); err != nil {
+			return 0, fmt.Errorf("could not apply item price: %v", err)
+		}
+	}
+
+	return len(dd), nil
+}
+
+// settlePrices applies the restaurant's due price changes, so they're in
+// place before its menu is copied or linked.
+func (s *Service) settlePrices(ctx context.Context, rid string) error {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("could not begin tx: %v", err)
+	}
+	defer func() { _ = tx.Rollback() }()
+
+	n, err := applyDuePrices(ctx, tx, rid)
+	if err != nil || n == 0 {
+		return err
+	}
+
+	if err = tx.Commit(); err != nil {
+		return fmt.Errorf("could not apply price changes: could not commit transaction: %v", err)
+	}
+
+	s.menuChanged(ctx, rid)
+	return nil
+}
+
+// applyScheduledPrices of every restaurant with price changes due.
+func (s *Service) applyScheduledPrices(ctx context.Context) error {
+	query := `
+		SELECT DISTINCT i.restaurant_id
+		FROM item_price p INNER JOIN item i ON p.item_id = i.id
+		WHERE p.applied = false AND p.effective_at <= now()`
+	rows, err := s.db.QueryContext(ctx, query)
+	if err != nil {
+		return fmt.Errorf("could not query restaurants with due price changes: %v", err)
+	}
+
+	defer rows.Close()
+	ids := make([]string, 0)
+	for rows.Next() {
+		var id string
+		if err = rows.Scan(&id); err != nil {
+			return fmt.Errorf("could not scan restaurant: %v", err)
+		}
+
+		ids = append(ids, id)
+	}
+
+	if err = rows.Err(); err != nil {
+		return fmt.Errorf("could not iterate restaurants: %v", err)
+	}
+
+	for _, rid := range ids {
+		if err = s.settlePrices(ctx, rid); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// ApplyScheduledPrices periodically applies the price changes that have come
+// due, so menus are read at their current prices without reads writing.
+// Orders apply due changes themselves in case they're placed in between.
+func (s *Service) ApplyScheduledPrices(ctx context.Context, every time.Duration) {
+	t := time.NewTicker(every)
+	defer t.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-t.C:
+			if err := s.applyScheduledPrices(ctx); err != nil {
+				s.logger.WithError(err).Error("could not apply scheduled prices")
+			}
+		}
+	}
+}
+
+// SchedulePriceChange of a menu item to take effect at a future time.
+func (s *Service) SchedulePriceChange(ctx context.Context, rid string, iid int64, price float64, at time.Time) (int64, error) {
+	uid, auth := ctx.Value(KeyAuthFoodProviderID).(int64)
+	if !auth {
+		return 0, ErrUnauthenticated
+	}
+
+	if !rxUUID.MatchString(rid) {
+		return 0, ErrRestaurantNotFound
+	}
+
+	if price <= 0 {
+		return 0, ErrInvalidPrice
+	}
+
+	if !at.After(time.Now()) {
+		return 0, ErrInvalidPriceChange
+	}
+
+	if _, err := s.checkPermission(ctx, Manager, uid, rid); err != nil {
+		return 0, err
+	}
+
+	if err := checkUnlinked(ctx, s.db, rid); err != nil {
+		return 0, err
+	}
+
+	var id int64
+	query := `
+		INSERT INTO item_price (item_id, price, changed_by, effective_at, applied)
+		SELECT id, $1, $2, $3, false FROM item WHERE id = $4 AND restaurant_id = $5 AND archived = false
+		RETURNING id`
+	err := s.db.QueryRowContext(ctx, query, price, uid, at, iid, rid).Scan(&id)
+	if err == sql.ErrNoRows {
+		return 0, ErrItemNotFound
+	}
+
+	if err != nil {
+		return 0, fmt.Errorf("could not schedule price change: %v", err)
+	}
+
+	return id, nil
+}
+
+// CancelPriceChange of a menu item that hasn't taken effect yet.
+func (s *Service) CancelPriceChange(ctx context.Context, rid string, iid, id int64) error {
+	uid, auth := ctx.Value(KeyAuthFoodProviderID).(int64)
+	if !auth {
+		return ErrUnauthenticated
+	}
+
+	if !rxUUID.MatchString(rid) {
+		return ErrRestaurantNotFound
+	}
+
+	if _, err := s.checkPermission(ctx, Manager, uid, rid); err != nil {
+		return err
+	}
+
+	query := `
+		DELETE FROM item_price
+		WHERE id = $1 AND item_id = $2 AND applied = false AND effective_at > now()
+			AND item_id IN (SELECT id FROM item WHERE restaurant_id = $3)`
+	res, err := s.db.ExecContext(ctx, query, id, iid, rid)
+	if err != nil {
+		return fmt.Errorf("could not cancel price change: %v", err)
+	}
+
+	if n, _ := res.RowsAffected(); n == 0 {
+		return ErrPriceChangeNotFound
+	}
+
+	return nil
+}
+
+// GetPriceHistory of a menu item, latest first, scheduled changes included.
+func (s *Service) GetPriceHistory(ctx context.Context, rid string, iid int64) ([]PriceChange, error) {
+	uid, auth := ctx.Value(KeyAuthFoodProviderID).(int64)
+	if !auth {
+		return nil, ErrUnauthenticated
+	}
+
+	if !rxUUID.MatchString(rid) {
+		return nil, ErrRestaurantNotFound
+	}
+
+	if _, err := s.checkPermission(ctx, Manager, uid, rid); err != nil {
+		return nil, err
+	}
+
+	if err := s.settlePrices(ctx, rid); err != nil {
+		return nil, err
+	}
+
+	var exists bool
+	query := "SELECT EXISTS (SELECT 1 FROM item WHERE id = $1 AND restaurant_id = $2)"
+	if err := s.db.QueryRowContext(ctx, query, iid, rid).Scan(&exists); err != nil {
+		return nil, fmt.Errorf("could not query item: %v", err)
+	}
+
+	if !exists {
+		return nil, ErrItemNotFound
+	}
+
+	query = `
+		SELECT p.id, p.price, p.previous_price, p.changed_by, f.fullname, p.effective_at, NOT p.applied, p.created_at
+		FROM item_price p INNER JOIN foodprovider f ON p.changed_by = f.id
+		WHERE p.item_id = $1
+		ORDER BY p.effective_at DESC, p.id DESC`
+	rows, err := s.db.QueryContext(ctx, query, iid)
+	if err != nil {
+		return nil, fmt.Errorf("could not query price history: %v", err)
+	}
+
+	defer rows.Close()
+	pp := make([]PriceChange, 0)
+	for rows.Next() {
+		var p PriceChange
+		var previous sql.NullFloat64
+		if err = rows.Scan(&p.Id, &p.Price, &previous, &p.ChangedBy, &p.ChangedByName, &p.EffectiveAt,
+			&p.Pending, &p.CreatedAt); err != nil {
+			return nil, fmt.Errorf("could not scan price change: %v", err)
+		}
+
+		if previous.Valid {
+			p.PreviousPrice = &previous.Float64
+		}
+
+		pp = append(pp, p)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("could not iterate price history: %v", err)
+	}
+
+	return pp, nil
+}
@@ -0,0 +1,260 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"strings"
+)
+
+// Kinds of pricing rule adjustments.
+const (
+	AdjustPercent = "percent"
+	AdjustFlat    = "flat"
+)
+
+var (
+	// ErrInvalidPricingRule denotes a pricing rule without a scope, with an unknown adjustment or an invalid window.
+	ErrInvalidPricingRule = errors.New("invalid pricing rule")
+	// ErrPricingRuleNotFound denotes a not found pricing rule.
+	ErrPricingRuleNotFound = errors.New("pricing rule not found")
+)
+
+// PricingRule discounts a category or an item during its window, e.g. 20%
+// off drinks from 15:00 to 17:00. Exactly one of CategoryId and ItemId is set.
+type PricingRule struct {
+	Id         int64    `json:"id"`
+	Name       string   `json:"name"`
+	CategoryId int64    `json:"category_id,omitempty"`
+	ItemId     int64    `json:"item_id,omitempty"`
+	Kind       string   `json:"kind"`
+	Amount     float64  `json:"amount"`
+	Window     Schedule `json:"window"`
+}
+
+func (r PricingRule) validate() error {
+	if strings.TrimSpace(r.Name) == "" || (r.CategoryId == 0) == (r.ItemId == 0) {
+		return ErrInvalidPricingRule
+	}
+
+	switch r.Kind {
+	case AdjustPercent:
+		if r.Amount <= 0 || r.Amount > 100 {
+			return ErrInvalidPricingRule
+		}
+	case AdjustFlat:
+		if r.Amount <= 0 {
+			return ErrInvalidPricingRule
+		}
+	default:
+		return ErrInvalidPricingRule
+	}
+
+	if err := r.Window.validate(); err != nil {
+		return ErrInvalidPricingRule
+	}
+
+	return nil
+}
+
+// adjust the price by the rule, never below zero.
+func (r PricingRule) adjust(price float64) float64 {
+	if r.Kind == AdjustPercent {
+		price -= price * r.Amount / 100
+	} else {
+		price -= r.Amount
+	}
+
+	return math.Max(0, math.Round(price*100)/100)
+}
+
+// activeRules of the restaurant now, by the category or item they apply to.
+type activeRules struct {
+	categories map[int64][]PricingRule
+	items      map[int64][]PricingRule
+}
+
+// price of an item in the category after the best of the rules that apply
+// to it. Rules don't stack, the customer gets the lowest price. The rule is
+// nil when none applies.
+func (a activeRules) price(iid, cid int64, price float64) (float64, *PricingRule) {
+	var best *PricingRule
+	lowest := price
+	for _, rr := range [][]PricingRule{a.items[iid], a.categories[cid]} {
+		for i := range rr {
+			if p := rr[i].adjust(price); p < lowest {
+				lowest, best = p, &rr[i]
+			}
+		}
+	}
+
+	return lowest, best
+}
+
+// pricingRules of the restaurant, all of them or only those active now.
+func pricingRules(ctx context.Context, q queryer, rid string, activeOnly bool) ([]PricingRule, error) {
+	query := `
+		SELECT id, name, COALESCE(category_id, 0), COALESCE(item_id, 0), kind, amount,
+			days, start_time, end_time, from_date, to_date
+		FROM pricing_rule
+		WHERE restaurant_id = $1
+		ORDER BY id`
+	rows, err := q.QueryContext(ctx, query, rid)
+	if err != nil {
+		return nil, fmt.Errorf("could not query pricing rules: %v", err)
+	}
+
+	defer rows.Close()
+	rr := make([]PricingRule, 0)
+	for rows.Next() {
+		var r PricingRule
+		var days int
+		if err = rows.Scan(&r.Id, &r.Name, &r.CategoryId, &r.ItemId, &r.Kind, &r.Amount,
+			&days, &r.Window.Start, &r.Window.End, &r.Window.From, &r.Window.To); err != nil {
+			return nil, fmt.Errorf("could not scan pricing rule: %v", err)
+		}
+
+		r.Window.Days = maskDays(days)
+		rr = append(rr, r)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("could not iterate pricing rules: %v", err)
+	}
+
+	if !activeOnly {
+		return rr, nil
+	}
+
+	now, err := restaurantNow(ctx, q, rid)
+	if err != nil {
+		return nil, err
+	}
+
+	active := rr[:0]
+	for _, r := range rr {
+		if r.Window.activeAt(now) {
+			active = append(active, r)
+		}
+	}
+
+	return active, nil
+}
+
+// activePricing loads the restaurant's pricing rules active now.
+func activePricing(ctx context.Context, q queryer, rid string) (activeRules, error) {
+	a := activeRules{categories: make(map[int64][]PricingRule), items: make(map[int64][]PricingRule)}
+	rr, err := pricingRules(ctx, q, rid, true)
+	if err != nil {
+		return a, err
+	}
+
+	for _, r := range rr {
+		if r.ItemId != 0 {
+			a.items[r.ItemId] = append(a.items[r.ItemId], r)
+		} else {
+			a.categories[r.CategoryId] = append(a.categories[r.CategoryId], r)
+		}
+	}
+
+	return a, nil
+}
+
+// GetPricingRules of a restaurant.
+func (s *Service) GetPricingRules(ctx context.Context, rid string) ([]PricingRule, error) {
+	uid, auth := ctx.Value(KeyAuthFoodProviderID).(int64)
+	if !auth {
+		return nil, ErrUnauthenticated
+	}
+
+	if !rxUUID.MatchString(rid) {
+		return nil, ErrRestaurantNotFound
+	}
+
+	if _, err := s.checkPermission(ctx, Waiter, uid, rid); err != nil {
+		return nil, err
+	}
+
+	return pricingRules(ctx, s.db, rid, false)
+}
+
+// CreatePricingRule for a category or an item of the restaurant.
+func (s *Service) CreatePricingRule(ctx context.Context, rid string, r PricingRule) (int64, error) {
+	uid, auth := ctx.Value(KeyAuthFoodProviderID).(int64)
+	if !auth {
+		return 0, ErrUnauthenticated
+	}
+
+	if !rxUUID.MatchString(rid) {
+		return 0, ErrRestaurantNotFound
+	}
+
+	r.Name = strings.TrimSpace(r.Name)
+	if err := r.validate(); err != nil {
+		return 0, err
+	}
+
+	if _, err := s.checkPermission(ctx, Manager, uid, rid); err != nil {
+		return 0, err
+	}
+
+	var exists bool
+	query := "SELECT EXISTS (SELECT 1 FROM item WHERE id = $1 AND restaurant_id = $2 AND archived = false)"
+	owner, notFound := r.ItemId, ErrItemNotFound
+	if r.CategoryId != 0 {
+		query = "SELECT EXISTS (SELECT 1 FROM category WHERE id = $1 AND restaurant = $2 AND archived = false)"
+		owner, notFound = r.CategoryId, ErrCategoryNotFound
+	}
+
+	if err := s.db.QueryRowContext(ctx, query, owner, rid).Scan(&exists); err != nil {
+		return 0, fmt.Errorf("could not query pricing rule scope: %v", err)
+	}
+
+	if !exists {
+		return 0, notFound
+	}
+
+	var id int64
+	query = `
+		INSERT INTO pricing_rule (restaurant_id, category_id, item_id, name, kind, amount,
+			days, start_time, end_time, from_date, to_date)
+		VALUES ($1, NULLIF($2, 0), NULLIF($3, 0), $4, $5, $6, $7, $8, $9, $10, $11)
+		RETURNING id`
+	w := r.Window
+	err := s.db.QueryRowContext(ctx, query, rid, r.CategoryId, r.ItemId, r.Name, r.Kind, r.Amount,
+		daysMask(w.Days), w.Start, w.End, w.From, w.To).Scan(&id)
+	if err != nil {
+		return 0, fmt.Errorf("could not create pricing rule: %v", err)
+	}
+
+	return id, nil
+}
+
+// DeletePricingRule of a restaurant. Orders keep the prices they were placed
+// at.
+func (s *Service) DeletePricingRule(ctx context.Context, rid string, id int64) error {
+	uid, auth := ctx.Value(KeyAuthFoodProviderID).(int64)
+	if !auth {
+		return ErrUnauthenticated
+	}
+
+	if !rxUUID.MatchString(rid) {
+		return ErrRestaurantNotFound
+	}
+
+	if _, err := s.checkPermission(ctx, Manager, uid, rid); err != nil {
+		return err
+	}
+
+	res, err := s.db.ExecContext(ctx, "DELETE FROM pricing_rule WHERE id = $1 AND restaurant_id = $2", id, rid)
+	if err != nil {
+		return fmt.Errorf("could not delete pricing rule: %v", err)
+	}
+
+	if n, _ := res.RowsAffected(); n == 0 {
+		return ErrPricingRuleNotFound
+	}
+
+	return nil
+}
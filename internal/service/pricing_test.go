@@ -0,0 +1,75 @@
+package service
+
+import "testing"
+
+func TestActiveRulesPrice(t *testing.T) {
+	a := activeRules{
+		categories: map[int64][]PricingRule{
+			1: {{Name: "Happy hour", Kind: AdjustPercent, Amount: 20}},
+		},
+		items: map[int64][]PricingRule{
+			7: {{Name: "Taka 50 off", Kind: AdjustFlat, Amount: 50}},
+			8: {{Name: "Free", Kind: AdjustFlat, Amount: 500}},
+		},
+	}
+
+	var tt = []struct {
+		Label    string
+		ItemId   int64
+		Category int64
+		Price    float64
+		Want     float64
+		Deal     string
+	}{
+		{Label: "Test should keep the price without rules", ItemId: 2, Category: 2, Price: 120, Want: 120},
+		{Label: "Test should discount by percent", ItemId: 2, Category: 1, Price: 120, Want: 96, Deal: "Happy hour"},
+		{Label: "Test should round to the paisa", ItemId: 2, Category: 1, Price: 99.99, Want: 79.99, Deal: "Happy hour"},
+		{Label: "Test should pick the best deal", ItemId: 7, Category: 1, Price: 300, Want: 240, Deal: "Happy hour"},
+		{Label: "Test should pick the best deal for cheap items", ItemId: 7, Category: 1, Price: 200, Want: 150, Deal: "Taka 50 off"},
+		{Label: "Test should not go below zero", ItemId: 8, Category: 2, Price: 200, Want: 0, Deal: "Free"},
+	}
+
+	for _, test := range tt {
+		t.Run(test.Label, func(t *testing.T) {
+			price, rule := a.price(test.ItemId, test.Category, test.Price)
+			if price != test.Want {
+				t.Error("Got:", price, "| Want:", test.Want)
+			}
+
+			deal := ""
+			if rule != nil {
+				deal = rule.Name
+			}
+			if deal != test.Deal {
+				t.Error("Got:", deal, "| Want:", test.Deal)
+			}
+		})
+	}
+}
+
+func TestPricingRuleValidate(t *testing.T) {
+	var tt = []struct {
+		Label string
+		Rule  PricingRule
+		Want  error
+	}{
+		{Label: "Test should accept a category rule", Rule: PricingRule{Name: "Happy hour", CategoryId: 1, Kind: AdjustPercent, Amount: 20,
+			Window: Schedule{Start: "15:00", End: "17:00"}}},
+		{Label: "Test should reject a rule for both scopes", Rule: PricingRule{Name: "Deal", CategoryId: 1, ItemId: 2, Kind: AdjustFlat, Amount: 5},
+			Want: ErrInvalidPricingRule},
+		{Label: "Test should reject a rule without scope", Rule: PricingRule{Name: "Deal", Kind: AdjustFlat, Amount: 5}, Want: ErrInvalidPricingRule},
+		{Label: "Test should reject more than 100 percent", Rule: PricingRule{Name: "Deal", ItemId: 2, Kind: AdjustPercent, Amount: 120},
+			Want: ErrInvalidPricingRule},
+		{Label: "Test should reject an unknown kind", Rule: PricingRule{Name: "Deal", ItemId: 2, Kind: "bogo", Amount: 1}, Want: ErrInvalidPricingRule},
+		{Label: "Test should reject an invalid window", Rule: PricingRule{Name: "Deal", ItemId: 2, Kind: AdjustFlat, Amount: 5,
+			Window: Schedule{Start: "15:00"}}, Want: ErrInvalidPricingRule},
+	}
+
+	for _, test := range tt {
+		t.Run(test.Label, func(t *testing.T) {
+			if err := test.Rule.validate(); err != test.Want {
+				t.Error("Got:", err, "| Want:", test.Want)
+			}
+		})
+	}
+}
@@ -0,0 +1,303 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"html/template"
+	"path"
+	"strconv"
+	"strings"
+)
+
+// Formats of printable menus.
+const (
+	PrintFormatHTML = "html"
+	PrintFormatPDF  = "pdf"
+)
+
+var (
+	// ErrInvalidPrintFormat denotes a printable menu format other than html or pdf.
+	ErrInvalidPrintFormat = errors.New("invalid printable menu format")
+	// ErrUnsupportedPDFLocale denotes a pdf menu in a locale whose script the pdf can't shape.
+	ErrUnsupportedPDFLocale = errors.New("pdf menus can't be printed in this locale, print the html menu instead")
+)
+
+// pdfUnshapedLocales are written in scripts that need complex shaping, which
+// the pdf renderer doesn't do: their conjuncts and vowel signs would print
+// broken. Their menus print as html, which browsers shape.
+var pdfUnshapedLocales = map[string]bool{"bn": true}
+
+var spiceLabels = map[int]string{SpiceMild: "Mild", SpiceMedium: "Medium", SpiceHot: "Hot"}
+
+// PrintMenu is what a printed menu shows: the restaurant and its categories
+// and items at their regular prices. Time-window deals are left out since
+// the print outlives them.
+type PrintMenu struct {
+	Title      string          `json:"title"`
+	About      string          `json:"about"`
+	Avatar     string          `json:"avatar"`
+	Cover      string          `json:"cover"`
+	Locale     string          `json:"locale"`
+	Categories []PrintCategory `json:"categories"`
+}
+
+// PrintCategory of a printed menu.
+type PrintCategory struct {
+	Label string      `json:"label"`
+	Items []PrintItem `json:"items"`
+}
+
+// PrintItem of a printed menu.
+type PrintItem struct {
+	Name        string  `json:"name"`
+	Description string  `json:"description"`
+	Price       float64 `json:"price"`
+	Dietary     Dietary `json:"dietary"`
+}
+
+// version of the menu, which changes whenever anything printed on it does.
+func (m PrintMenu) version() (string, error) {
+	b, err := json.Marshal(m)
+	if err != nil {
+		return "", fmt.Errorf("could not marshal printable menu: %v", err)
+	}
+
+	sum := sha256.Sum256(b)
+	return hex.EncodeToString(sum[:16]), nil
+}
+
+func formatPrice(p float64) string {
+	return "Tk " + strconv.FormatFloat(p, 'f', 2, 64)
+}
+
+// tagLabel turns a dietary tag like gluten_free into gluten free.
+func tagLabel(tag string) string {
+	return strings.Replace(tag, "_", " ", -1)
+}
+
+// printNotes of an item: its tags, spice and allergens.
+func printNotes(d Dietary) string {
+	notes := make([]string, 0, len(d.Tags)+2)
+	for _, t := range d.Tags {
+		notes = append(notes, tagLabel(t))
+	}
+
+	if l, ok := spiceLabels[d.SpiceLevel]; ok {
+		notes = append(notes, l+" spicy")
+	}
+
+	if len(d.Allergens) != 0 {
+		aa := make([]string, len(d.Allergens))
+		for i, a := range d.Allergens {
+			aa[i] = tagLabel(a)
+		}
+		notes = append(notes, "Contains "+strings.Join(aa, ", "))
+	}
+
+	return strings.Join(notes, " · ")
+}
+
+var printTemplate = template.Must(template.New("menu").Funcs(template.FuncMap{
+	"price": formatPrice,
+	"tag":   tagLabel,
+	"spice": func(level int) string { return spiceLabels[level] },
+}).Parse(`<!DOCTYPE html>
+<html lang="{{.Locale}}">
+<head>
+<meta charset="utf-8">
+<title>{{.Title}}</title>
+<style>
+body { margin: 0; font-family: "Helvetica Neue", Helvetica, Arial, sans-serif; color: #222; }
+main { max-width: 720px; margin: 0 auto; padding: 32px; }
+.cover { display: block; width: 100%; max-height: 240px; object-fit: cover; }
+header { display: flex; align-items: center; gap: 16px; margin-bottom: 24px; }
+.avatar { width: 72px; height: 72px; border-radius: 50%; object-fit: cover; }
+h1 { margin: 0; font-size: 28px; }
+.about { margin: 4px 0 0; color: #666; }
+h2 { margin: 32px 0 8px; padding-bottom: 4px; border-bottom: 1px solid #ccc; font-size: 20px; text-transform: uppercase; letter-spacing: 1px; }
+.item { margin: 12px 0; page-break-inside: avoid; }
+.line { display: flex; justify-content: space-between; gap: 16px; font-weight: bold; }
+.description { margin: 2px 0; color: #555; }
+.tags { margin: 4px 0 0; padding: 0; list-style: none; display: flex; flex-wrap: wrap; gap: 4px; }
+.tags li { padding: 1px 6px; border-radius: 8px; background: #eee; font-size: 12px; }
+.tags .spice { background: #fde2dd; }
+.tags .allergen { background: #fff3cd; }
+@media print { main { padding: 0; } }
+</style>
+</head>
+<body>
+{{if .Cover}}<img class="cover" src="{{.Cover}}" alt="">{{end}}
+<main>
+<header>
+{{if .Avatar}}<img class="avatar" src="{{.Avatar}}" alt="">{{end}}
+<div>
+<h1>{{.Title}}</h1>
+{{if .About}}<p class="about">{{.About}}</p>{{end}}
+</div>
+</header>
+{{range .Categories}}<section>
+<h2>{{.Label}}</h2>
+{{range .Items}}<div class="item">
+<div class="line"><span>{{.Name}}</span><span>{{price .Price}}</span></div>
+{{if .Description}}<p class="description">{{.Description}}</p>{{end}}
+<ul class="tags">{{range .Dietary.Tags}}<li>{{tag .}}</li>{{end}}{{with spice .Dietary.SpiceLevel}}<li class="spice">{{.}} spicy</li>{{end}}{{range .Dietary.Allergens}}<li class="allergen">{{tag .}}</li>{{end}}</ul>
+</div>
+{{end}}</section>
+{{end}}</main>
+</body>
+</html>
+`))
+
+// html of the printable menu.
+func (m PrintMenu) html() ([]byte, error) {
+	var b bytes.Buffer
+	if err := printTemplate.Execute(&b, m); err != nil {
+		return nil, fmt.Errorf("could not render printable menu: %v", err)
+	}
+
+	return b.Bytes(), nil
+}
+
+// printHeader loads the restaurant part of its printed menu, in the
+// context's locale, with the files of its pictures.
+func (s *Service) printHeader(ctx context.Context, rid string) (PrintMenu, string, string, error) {
+	m := PrintMenu{Locale: localeOf(ctx), Categories: make([]PrintCategory, 0)}
+	var avatar, cover string
+	query := `
+		SELECT r.title, COALESCE(NULLIF(t.about, ''), r.about), COALESCE(r.avatar, ''), COALESCE(r.cover, '')
+		FROM restaurant r LEFT JOIN restaurant_translation t ON r.id = t.restaurant_id AND t.locale = $2
+		WHERE r.id = $1`
+	if err := s.db.QueryRowContext(ctx, query, rid, m.Locale).Scan(&m.Title, &m.About, &avatar, &cover); err != nil {
+		return m, "", "", fmt.Errorf("could not query restaurant: %v", err)
+	}
+
+	var avatarFile, coverFile string
+	if avatar != "" {
+		m.Avatar = s.restaurantPictureURL(rid, avatar)
+		avatarFile = path.Join(restaurantDir, rid, avatar)
+	}
+
+	if cover != "" {
+		m.Cover = s.restaurantPictureURL(rid, cover)
+		coverFile = path.Join(restaurantDir, rid, cover)
+	}
+
+	return m, avatarFile, coverFile, nil
+}
+
+// printMenu loads what the restaurant's printed menu shows, in the context's
+// locale.
+func (s *Service) printMenu(ctx context.Context, rid string) (PrintMenu, string, string, error) {
+	m, avatarFile, coverFile, err := s.printHeader(ctx, rid)
+	if err != nil {
+		return m, "", "", err
+	}
+
+	tags, err := itemTags(ctx, s.db, rid)
+	if err != nil {
+		return m, "", "", err
+	}
+
+	// Sold out and scheduled items are printed, they're only off for now.
+	query := `
+		SELECT c.id, COALESCE(NULLIF(ct.label, ''), c.label), i.id, COALESCE(NULLIF(it.name, ''), i.name),
+			COALESCE(NULLIF(it.description, ''), i.description), i.price, i.spice_level
+		FROM category c
+			INNER JOIN item i ON c.id = i.category_id
+			LEFT JOIN category_translation ct ON c.id = ct.category_id AND ct.locale = $2
+			LEFT JOIN item_translation it ON i.id = it.item_id AND it.locale = $2
+		WHERE i.restaurant_id = $1 AND c.archived = false AND c.availability = true AND i.availability = true
+			AND i.archived = false
+		ORDER BY c.position, c.id, i.position, i.id`
+	rows, err := s.db.QueryContext(ctx, query, rid, m.Locale)
+	if err != nil {
+		return m, "", "", fmt.Errorf("could not query menu: %v", err)
+	}
+
+	defer rows.Close()
+	var last int64
+	for rows.Next() {
+		var cid, iid int64
+		var label string
+		var spice int
+		var i PrintItem
+		if err = rows.Scan(&cid, &label, &iid, &i.Name, &i.Description, &i.Price, &spice); err != nil {
+			return m, "", "", fmt.Errorf("could not scan menu item: %v", err)
+		}
+
+		i.Dietary = dietaryOf(tags, iid, spice)
+		if len(m.Categories) == 0 || last != cid {
+			m.Categories = append(m.Categories, PrintCategory{Label: label, Items: make([]PrintItem, 0)})
+			last = cid
+		}
+
+		c := &m.Categories[len(m.Categories)-1]
+		c.Items = append(c.Items, i)
+	}
+
+	if err = rows.Err(); err != nil {
+		return m, "", "", fmt.Errorf("could not iterate menu: %v", err)
+	}
+
+	return m, avatarFile, coverFile, nil
+}
+
+func (s *Service) restaurantPictureURL(rid, image string) string {
+	u := s.origin
+	u.Path = "/img/restaurant/" + rid + "/" + image
+	return u.String()
+}
+
+// PrintableMenu of a restaurant as a styled HTML page or a PDF document, with
+// its version. Menus are rendered from the current menu on every call so a
+// change shows up on the next download; the version changes with it.
+func (s *Service) PrintableMenu(ctx context.Context, rid, format string) ([]byte, string, error) {
+	uid, auth := ctx.Value(KeyAuthFoodProviderID).(int64)
+	if !auth {
+		return nil, "", ErrUnauthenticated
+	}
+
+	if !rxUUID.MatchString(rid) {
+		return nil, "", ErrRestaurantNotFound
+	}
+
+	if format != PrintFormatHTML && format != PrintFormatPDF {
+		return nil, "", ErrInvalidPrintFormat
+	}
+
+	if format == PrintFormatPDF && pdfUnshapedLocales[localeOf(ctx)] {
+		return nil, "", ErrUnsupportedPDFLocale
+	}
+
+	if _, err := s.checkPermission(ctx, Waiter, uid, rid); err != nil {
+		return nil, "", err
+	}
+
+	m, avatarFile, coverFile, err := s.printMenu(ctx, rid)
+	if err != nil {
+		return nil, "", err
+	}
+
+	version, err := m.version()
+	if err != nil {
+		return nil, "", err
+	}
+
+	var b []byte
+	if format == PrintFormatPDF {
+		b, err = m.pdf(s.fonts, avatarFile, coverFile)
+	} else {
+		b, err = m.html()
+	}
+
+	if err != nil {
+		return nil, "", err
+	}
+
+	return b, format + "-" + version, nil
+}
@@ -0,0 +1,94 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"testing"
+)
+
+func TestPrintMenu(t *testing.T) {
+	m := PrintMenu{
+		Title:  "Kacchi <House>",
+		Locale: DefaultLocale,
+		Categories: []PrintCategory{{Label: "Rice", Items: []PrintItem{
+			{Name: "Biryani", Description: "Mutton (full)", Price: 320,
+				Dietary: Dietary{Tags: []string{"halal"}, Allergens: []string{"dairy"}, SpiceLevel: SpiceMedium}},
+		}}},
+	}
+
+	h, err := m.html()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, want := range []string{"Kacchi &lt;House&gt;", "Tk 320.00", "Medium spicy", "dairy"} {
+		if !bytes.Contains(h, []byte(want)) {
+			t.Errorf("Got html without %q", want)
+		}
+	}
+
+	p, err := m.pdf(defaultMenuFonts, "", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !bytes.HasPrefix(p, []byte("%PDF-")) || !bytes.Contains(p, []byte("%%EOF")) {
+		t.Error("Got a malformed pdf")
+	}
+
+	if !bytes.Contains(p, []byte("/FontFile2")) {
+		t.Error("Got pdf without an embedded TrueType font")
+	}
+
+	v1, _ := m.version()
+	m.Categories[0].Items[0].Price = 350
+	if v2, _ := m.version(); v1 == v2 {
+		t.Error("Got the same version after a price change")
+	}
+}
+
+func TestPrintMenuBangla(t *testing.T) {
+	m := PrintMenu{
+		Title:  "কাচ্চি হাউস",
+		Locale: "bn",
+		Categories: []PrintCategory{{Label: "ভাত", Items: []PrintItem{
+			{Name: "কাচ্চি বিরিয়ানি", Description: "খাসির মাংস", Price: 320},
+		}}},
+	}
+
+	// PrintableMenu refuses Bangla pdfs, but the renderer itself mustn't fail
+	// on a font without the glyphs.
+	if _, err := m.pdf(defaultMenuFonts, "", ""); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestPrintableMenuLocale(t *testing.T) {
+	var tt = []struct {
+		Label  string
+		Locale string
+		Format string
+		Want   error
+	}{
+		{Label: "Test should refuse a Bangla pdf", Locale: "bn", Format: PrintFormatPDF, Want: ErrUnsupportedPDFLocale},
+		{Label: "Test should refuse an unknown format before the locale", Locale: "bn", Format: "docx", Want: ErrInvalidPrintFormat},
+	}
+
+	s := &Service{}
+	for _, test := range tt {
+		t.Run(test.Label, func(t *testing.T) {
+			ctx := context.WithValue(context.Background(), KeyAuthFoodProviderID, int64(1))
+			ctx = context.WithValue(ctx, KeyLocale, test.Locale)
+			_, _, err := s.PrintableMenu(ctx, "2cdbd2ad-06e2-4a77-a9a1-0fd8f1a1c4b5", test.Format)
+			if err != test.Want {
+				t.Error("Got:", err, "| Want:", test.Want)
+			}
+		})
+	}
+
+	for _, l := range Locales {
+		if want := l == "bn"; pdfUnshapedLocales[l] != want {
+			t.Error("Got:", pdfUnshapedLocales[l], "| Want:", want, "for", l)
+		}
+	}
+}
@@ -0,0 +1,303 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"net/url"
+	"strconv"
+	"strings"
+
+	qrcode "github.com/skip2/go-qrcode"
+)
+
+// Formats of menu QR codes.
+const (
+	QRFormatPNG = "png"
+	QRFormatSVG = "svg"
+)
+
+const (
+	defaultQRSize = 512
+	maxQRSize     = 2048
+)
+
+var (
+	// ErrTableNotFound denotes a not found or archived dining table.
+	ErrTableNotFound = errors.New("table not found")
+	// ErrInvalidTableLink denotes a table reference whose signature doesn't match.
+	ErrInvalidTableLink = errors.New("invalid table link")
+	// ErrInvalidQRFormat denotes a QR code format other than png or svg.
+	ErrInvalidQRFormat = errors.New("invalid QR code format")
+	// ErrInvalidQRSize denotes a negative QR code size.
+	ErrInvalidQRSize = errors.New("invalid QR code size")
+)
+
+// DiningTable of a restaurant, with the signed menu link its sticker encodes.
+type DiningTable struct {
+	Id    int64  `json:"id"`
+	Label string `json:"label"`
+	Link  string `json:"link"`
+}
+
+// TableLink is the table reference a menu link carries into an order. The
+// zero value is an order not placed from a table.
+type TableLink struct {
+	Table int64  `json:"table"`
+	Sig   string `json:"sig"`
+}
+
+// SetLinkKey sets the key menu links are signed with.
+func (s *Service) SetLinkKey(key []byte) {
+	s.linkKey = key
+}
+
+// tableSig signs the restaurant and table of a menu link. Table zero is the
+// restaurant's own link.
+func tableSig(key []byte, rid string, tid int64) string {
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte(rid + "/" + strconv.FormatInt(tid, 10)))
+	return base64.RawURLEncoding.EncodeToString(mac.Sum(nil)[:16])
+}
+
+// verify the link was signed for the restaurant.
+func (l TableLink) verify(key []byte, rid string) error {
+	if !hmac.Equal([]byte(l.Sig), []byte(tableSig(key, rid, l.Table))) {
+		return ErrInvalidTableLink
+	}
+
+	return nil
+}
+
+// menuLink is the signed link to the restaurant's public menu, for a table
+// when tid is not zero.
+func (s *Service) menuLink(rid string, tid int64) string {
+	u := s.origin
+	u.Path = "/restaurants/" + rid + "/menu"
+	q := url.Values{}
+	if tid != 0 {
+		q.Set("table", strconv.FormatInt(tid, 10))
+	}
+	q.Set("sig", tableSig(s.linkKey, rid, tid))
+	u.RawQuery = q.Encode()
+	return u.String()
+}
+
+// qrSVG draws the QR code as an SVG of size pixels, one rect per dark module.
+func qrSVG(q *qrcode.QRCode, size int) []byte {
+	bitmap := q.Bitmap()
+	n := len(bitmap)
+	var b bytes.Buffer
+	fmt.Fprintf(&b, `<svg xmlns="http://www.w3.org/2000/svg" width="%d" height="%d" viewBox="0 0 %d %d" shape-rendering="crispEdges">`,
+		size, size, n, n)
+	fmt.Fprintf(&b, `<rect width="%d" height="%d" fill="#fff"/>`, n, n)
+	for y, row := range bitmap {
+		for x, dark := range row {
+			if dark {
+				fmt.Fprintf(&b, `<rect x="%d" y="%d" width="1" height="1"/>`, x, y)
+			}
+		}
+	}
+	b.WriteString("</svg>")
+	return b.Bytes()
+}
+
+// encodeQR of the content in the format, size pixels wide.
+func encodeQR(content, format string, size int) ([]byte, error) {
+	if size < 0 {
+		return nil, ErrInvalidQRSize
+	}
+
+	if size == 0 {
+		size = defaultQRSize
+	}
+
+	if size > maxQRSize {
+		size = maxQRSize
+	}
+
+	q, err := qrcode.New(content, qrcode.Medium)
+	if err != nil {
+		return nil, fmt.Errorf("could not encode QR code: %v", err)
+	}
+
+	switch format {
+	case QRFormatPNG:
+		b, err := q.PNG(size)
+		if err != nil {
+			return nil, fmt.Errorf("could not encode QR code png: %v", err)
+		}
+		return b, nil
+	case QRFormatSVG:
+		return qrSVG(q, size), nil
+	}
+
+	return nil, ErrInvalidQRFormat
+}
+
+// CreateTable for a restaurant's dine-in QR codes.
+func (s *Service) CreateTable(ctx context.Context, rid, label string) (int64, error) {
+	uid, auth := ctx.Value(KeyAuthFoodProviderID).(int64)
+	if !auth {
+		return 0, ErrUnauthenticated
+	}
+
+	if !rxUUID.MatchString(rid) {
+		return 0, ErrRestaurantNotFound
+	}
+
+	label = strings.TrimSpace(label)
+	if label == "" {
+		return 0, ErrEmptyValue
+	}
+
+	if _, err := s.checkPermission(ctx, Manager, uid, rid); err != nil {
+		return 0, err
+	}
+
+	var id int64
+	query := "INSERT INTO dining_table (restaurant_id, label) VALUES ($1, $2) RETURNING id"
+	err := s.db.QueryRowContext(ctx, query, rid, label).Scan(&id)
+	if isUniqueViolation(err) {
+		return 0, ErrTitleTaken
+	}
+
+	if err != nil {
+		return 0, fmt.Errorf("could not create table: %v", err)
+	}
+
+	return id, nil
+}
+
+// GetTables of a restaurant with their menu links.
+func (s *Service) GetTables(ctx context.Context, rid string) ([]DiningTable, error) {
+	uid, auth := ctx.Value(KeyAuthFoodProviderID).(int64)
+	if !auth {
+		return nil, ErrUnauthenticated
+	}
+
+	if !rxUUID.MatchString(rid) {
+		return nil, ErrRestaurantNotFound
+	}
+
+	if _, err := s.checkPermission(ctx, Waiter, uid, rid); err != nil {
+		return nil, err
+	}
+
+	query := "SELECT id, label FROM dining_table WHERE restaurant_id = $1 AND archived = false ORDER BY label"
+	rows, err := s.db.QueryContext(ctx, query, rid)
+	if err != nil {
+		return nil, fmt.Errorf("could not query tables: %v", err)
+	}
+
+	defer rows.Close()
+	tt := make([]DiningTable, 0)
+	for rows.Next() {
+		var t DiningTable
+		if err = rows.Scan(&t.Id, &t.Label); err != nil {
+			return nil, fmt.Errorf("could not scan table: %v", err)
+		}
+
+		t.Link = s.menuLink(rid, t.Id)
+		tt = append(tt, t)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("could not iterate tables: %v", err)
+	}
+
+	return tt, nil
+}
+
+// ArchiveTable so orders can no longer be placed from its QR code. Past
+// orders keep referring to it.
+func (s *Service) ArchiveTable(ctx context.Context, rid string, tid int64) error {
+	uid, auth := ctx.Value(KeyAuthFoodProviderID).(int64)
+	if !auth {
+		return ErrUnauthenticated
+	}
+
+	if !rxUUID.MatchString(rid) {
+		return ErrRestaurantNotFound
+	}
+
+	if _, err := s.checkPermission(ctx, Manager, uid, rid); err != nil {
+		return err
+	}
+
+	query := "UPDATE dining_table SET archived = true WHERE id = $1 AND restaurant_id = $2 AND archived = false"
+	res, err := s.db.ExecContext(ctx, query, tid, rid)
+	if err != nil {
+		return fmt.Errorf("could not archive table: %v", err)
+	}
+
+	if n, _ := res.RowsAffected(); n == 0 {
+		return ErrTableNotFound
+	}
+
+	return nil
+}
+
+// MenuQR is a printable QR code of the restaurant's signed menu link, or of
+// a table's when tid is not zero.
+func (s *Service) MenuQR(ctx context.Context, rid string, tid int64, format string, size int) ([]byte, error) {
+	uid, auth := ctx.Value(KeyAuthFoodProviderID).(int64)
+	if !auth {
+		return nil, ErrUnauthenticated
+	}
+
+	if !rxUUID.MatchString(rid) {
+		return nil, ErrRestaurantNotFound
+	}
+
+	if format != QRFormatPNG && format != QRFormatSVG {
+		return nil, ErrInvalidQRFormat
+	}
+
+	if _, err := s.checkPermission(ctx, Manager, uid, rid); err != nil {
+		return nil, err
+	}
+
+	if tid != 0 {
+		var exists bool
+		query := "SELECT EXISTS (SELECT 1 FROM dining_table WHERE id = $1 AND restaurant_id = $2 AND archived = false)"
+		if err := s.db.QueryRowContext(ctx, query, tid, rid).Scan(&exists); err != nil {
+			return nil, fmt.Errorf("could not query table: %v", err)
+		}
+
+		if !exists {
+			return nil, ErrTableNotFound
+		}
+	}
+
+	return encodeQR(s.menuLink(rid, tid), format, size)
+}
+
+// orderTable checks the table link an order was placed through. It's null
+// for orders without one.
+func (s *Service) orderTable(ctx context.Context, tx *sql.Tx, rid string, l TableLink) (sql.NullInt64, error) {
+	if l.Table == 0 {
+		return sql.NullInt64{}, nil
+	}
+
+	if err := l.verify(s.linkKey, rid); err != nil {
+		return sql.NullInt64{}, err
+	}
+
+	var exists bool
+	query := "SELECT EXISTS (SELECT 1 FROM dining_table WHERE id = $1 AND restaurant_id = $2 AND archived = false)"
+	if err := tx.QueryRowContext(ctx, query, l.Table, rid).Scan(&exists); err != nil {
+		return sql.NullInt64{}, fmt.Errorf("could not query table: %v", err)
+	}
+
+	if !exists {
+		return sql.NullInt64{}, ErrTableNotFound
+	}
+
+	return sql.NullInt64{Int64: l.Table, Valid: true}, nil
+}
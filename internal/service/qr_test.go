@@ -0,0 +1,55 @@
+package service
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestTableLinkVerify(t *testing.T) {
+	key := []byte("secret")
+	rid := "3f9a8c3e-8f6c-4d4b-9a63-0f5d2c1b7e41"
+	sig := tableSig(key, rid, 7)
+
+	var tt = []struct {
+		Label string
+		Link  TableLink
+		Rid   string
+		Key   []byte
+		Want  error
+	}{
+		{Label: "Test should accept a signed table", Link: TableLink{Table: 7, Sig: sig}, Rid: rid, Key: key},
+		{Label: "Test should reject another table", Link: TableLink{Table: 8, Sig: sig}, Rid: rid, Key: key, Want: ErrInvalidTableLink},
+		{Label: "Test should reject another restaurant", Link: TableLink{Table: 7, Sig: sig},
+			Rid: "00000000-0000-0000-0000-000000000000", Key: key, Want: ErrInvalidTableLink},
+		{Label: "Test should reject another key", Link: TableLink{Table: 7, Sig: sig}, Rid: rid, Key: []byte("other"), Want: ErrInvalidTableLink},
+		{Label: "Test should reject a missing signature", Link: TableLink{Table: 7}, Rid: rid, Key: key, Want: ErrInvalidTableLink},
+	}
+
+	for _, test := range tt {
+		t.Run(test.Label, func(t *testing.T) {
+			if err := test.Link.verify(test.Key, test.Rid); err != test.Want {
+				t.Error("Got:", err, "| Want:", test.Want)
+			}
+		})
+	}
+}
+
+func TestEncodeQR(t *testing.T) {
+	png, err := encodeQR("http://localhost:3000/restaurants/r/menu?sig=x", QRFormatPNG, 0)
+	if err != nil || !bytes.HasPrefix(png, []byte("\x89PNG")) {
+		t.Error("Got:", err, "| Want: a png")
+	}
+
+	svg, err := encodeQR("http://localhost:3000/restaurants/r/menu?sig=x", QRFormatSVG, 0)
+	if err != nil || !bytes.HasPrefix(svg, []byte("<svg")) {
+		t.Error("Got:", err, "| Want: an svg")
+	}
+
+	if _, err = encodeQR("http://localhost:3000", "gif", 0); err != ErrInvalidQRFormat {
+		t.Error("Got:", err, "| Want:", ErrInvalidQRFormat)
+	}
+
+	if _, err = encodeQR("http://localhost:3000", QRFormatPNG, -1); err != ErrInvalidQRSize {
+		t.Error("Got:", err, "| Want:", ErrInvalidQRSize)
+	}
+}
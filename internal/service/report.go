@@ -0,0 +1,293 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+)
+
+const maxReportItems = 50
+
+var (
+	// ErrInvalidPeriod denotes a report period other than daily, weekly or monthly.
+	ErrInvalidPeriod = errors.New("invalid period")
+	// ErrInvalidDateRange denotes a date range that ends before it starts.
+	ErrInvalidDateRange = errors.New("invalid date range")
+)
+
+// RevenueReport of the completed orders within one period.
+type RevenueReport struct {
+	Period            time.Time `json:"period"`
+	Orders            int64     `json:"orders"`
+	Revenue           float64   `json:"revenue"`
+	AverageOrderValue float64   `json:"average_order_value"`
+}
+
+// StatusReport counts the orders with the same status.
+type StatusReport struct {
+	Status string `json:"status"`
+	Orders int64  `json:"orders"`
+}
+
+// SalesReport of a single item or category.
+type SalesReport struct {
+	Id       int64   `json:"id"`
+	Name     string  `json:"name"`
+	Category string  `json:"category,omitempty"`
+	Quantity int64   `json:"quantity"`
+	Revenue  float64 `json:"revenue"`
+}
+
+// Heatmap holds the number of orders placed per weekday (Sunday first) and
+// hour in the restaurant's time zone.
+type Heatmap struct {
+	Orders [7][24]int64 `json:"orders"`
+}
+
+// GetRevenueReport groups the completed orders between from and to by day,
+// week or month.
+func (s *Service) GetRevenueReport(ctx context.Context, rid, period string, from, to time.Time) ([]RevenueReport, error) {
+	if err := s.checkReportAccess(ctx, rid, from, to); err != nil {
+		return nil, err
+	}
+
+	var trunc string
+	switch period {
+	case "", "daily":
+		trunc = "day"
+	case "weekly":
+		trunc = "week"
+	case "monthly":
+		trunc = "month"
+	default:
+		return nil, ErrInvalidPeriod
+	}
+
+	query := `
+		SELECT p.period, count(*), SUM(p.total)
+		FROM (
+			SELECT date_trunc($1, o.created_at AT TIME ZONE r.timezone) AS period,
+				(SELECT COALESCE(SUM(oi.price * oi.quantity), 0) FROM order_item oi WHERE oi.order_id = o.id) AS total
+			FROM orders o INNER JOIN restaurant r ON o.restaurant_id = r.id
+			WHERE o.restaurant_id = $2 AND o.status = $3 AND o.created_at >= $4 AND o.created_at < $5
+		) p
+		GROUP BY p.period
+		ORDER BY p.period`
+	rows, err := s.db.QueryContext(ctx, query, trunc, rid, OrderCompleted, from, to)
+	if err != nil {
+		return nil, fmt.Errorf("could not query revenue: %v", err)
+	}
+
+	defer rows.Close()
+	rr := make([]RevenueReport, 0)
+	for rows.Next() {
+		var r RevenueReport
+		if err = rows.Scan(&r.Period, &r.Orders, &r.Revenue); err != nil {
+			return nil, fmt.Errorf("could not scan revenue: %v", err)
+		}
+
+		if r.Orders > 0 {
+			r.AverageOrderValue = r.Revenue / float64(r.Orders)
+		}
+		rr = append(rr, r)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("could not iterate revenue: %v", err)
+	}
+
+	return rr, nil
+}
+
+// GetStatusReport counts the orders between from and to by status.
+func (s *Service) GetStatusReport(ctx context.Context, rid string, from, to time.Time) ([]StatusReport, error) {
+	if err := s.checkReportAccess(ctx, rid, from, to); err != nil {
+		return nil, err
+	}
+
+	query := `
+		SELECT status, count(*)
+		FROM orders
+		WHERE restaurant_id = $1 AND created_at >= $2 AND created_at < $3
+		GROUP BY status
+		ORDER BY status`
+	rows, err := s.db.QueryContext(ctx, query, rid, from, to)
+	if err != nil {
+		return nil, fmt.Errorf("could not query order status: %v", err)
+	}
+
+	defer rows.Close()
+	rr := make([]StatusReport, 0)
+	for rows.Next() {
+		var status int64
+		var r StatusReport
+		if err = rows.Scan(&status, &r.Orders); err != nil {
+			return nil, fmt.Errorf("could not scan order status: %v", err)
+		}
+
+		r.Status = getOrderStatus(status)
+		rr = append(rr, r)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("could not iterate order status: %v", err)
+	}
+
+	return rr, nil
+}
+
+// GetTopItems sold in completed orders between from and to.
+func (s *Service) GetTopItems(ctx context.Context, rid string, from, to time.Time, limit int) ([]SalesReport, error) {
+	return s.salesReport(ctx, `
+		SELECT i.id, i.name, c.label, SUM(oi.quantity), SUM(oi.price * oi.quantity)
+		FROM order_item oi
+			INNER JOIN orders o ON oi.order_id = o.id
+			INNER JOIN item i ON oi.item_id = i.id
+			INNER JOIN category c ON i.category_id = c.id
+		WHERE o.restaurant_id = $1 AND o.status = $2 AND o.created_at >= $3 AND o.created_at < $4
+		GROUP BY i.id, i.name, c.label
+		ORDER BY 4 DESC, 5 DESC
+		LIMIT $5`, rid, from, to, limit)
+}
+
+// GetTopCategories sold in completed orders between from and to.
+func (s *Service) GetTopCategories(ctx context.Context, rid string, from, to time.Time, limit int) ([]SalesReport, error) {
+	return s.salesReport(ctx, `
+		SELECT c.id, c.label, '', SUM(oi.quantity), SUM(oi.price * oi.quantity)
+		FROM order_item oi
+			INNER JOIN orders o ON oi.order_id = o.id
+			INNER JOIN item i ON oi.item_id = i.id
+			INNER JOIN category c ON i.category_id = c.id
+		WHERE o.restaurant_id = $1 AND o.status = $2 AND o.created_at >= $3 AND o.created_at < $4
+		GROUP BY c.id, c.label
+		ORDER BY 4 DESC, 5 DESC
+		LIMIT $5`, rid, from, to, limit)
+}
+
+func (s *Service) salesReport(ctx context.Context, query, rid string, from, to time.Time, limit int) ([]SalesReport, error) {
+	if err := s.checkReportAccess(ctx, rid, from, to); err != nil {
+		return nil, err
+	}
+
+	if limit < 1 || limit > maxReportItems {
+		limit = defaultPageSize
+	}
+
+	rows, err := s.db.QueryContext(ctx, query, rid, OrderCompleted, from, to, limit)
+	if err != nil {
+		return nil, fmt.Errorf("could not query sales: %v", err)
+	}
+
+	defer rows.Close()
+	rr := make([]SalesReport, 0, limit)
+	for rows.Next() {
+		var r SalesReport
+		if err = rows.Scan(&r.Id, &r.Name, &r.Category, &r.Quantity, &r.Revenue); err != nil {
+			return nil, fmt.Errorf("could not scan sales: %v", err)
+		}
+
+		rr = append(rr, r)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("could not iterate sales: %v", err)
+	}
+
+	return rr, nil
+}
+
+// GetHeatmap of the orders placed between from and to.
+func (s *Service) GetHeatmap(ctx context.Context, rid string, from, to time.Time) (*Heatmap, error) {
+	if err := s.checkReportAccess(ctx, rid, from, to); err != nil {
+		return nil, err
+	}
+
+	query := `
+		SELECT h.day, h.hour, count(*)
+		FROM (
+			SELECT extract(dow FROM o.created_at AT TIME ZONE r.timezone)::INT AS day,
+				extract(hour FROM o.created_at AT TIME ZONE r.timezone)::INT AS hour
+			FROM orders o INNER JOIN restaurant r ON o.restaurant_id = r.id
+			WHERE o.restaurant_id = $1 AND o.status != $2 AND o.created_at >= $3 AND o.created_at < $4
+		) h
+		GROUP BY h.day, h.hour`
+	rows, err := s.db.QueryContext(ctx, query, rid, OrderCancelled, from, to)
+	if err != nil {
+		return nil, fmt.Errorf("could not query heatmap: %v", err)
+	}
+
+	defer rows.Close()
+	var m Heatmap
+	for rows.Next() {
+		var day, hour int
+		var count int64
+		if err = rows.Scan(&day, &hour, &count); err != nil {
+			return nil, fmt.Errorf("could not scan heatmap: %v", err)
+		}
+
+		if day >= 0 && day < 7 && hour >= 0 && hour < 24 {
+			m.Orders[day][hour] = count
+		}
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("could not iterate heatmap: %v", err)
+	}
+
+	return &m, nil
+}
+
+// ReportRange turns the from and to dates of a report, both inclusive, into
+// the instants it runs between: midnights in the restaurant's time zone.
+// Without dates it covers the last 30 days up to today.
+func (s *Service) ReportRange(ctx context.Context, rid, from, to string) (time.Time, time.Time, error) {
+	if _, auth := ctx.Value(KeyAuthFoodProviderID).(int64); !auth {
+		return time.Time{}, time.Time{}, ErrUnauthenticated
+	}
+
+	if !rxUUID.MatchString(rid) {
+		return time.Time{}, time.Time{}, ErrInvalidRestaurantId
+	}
+
+	now, err := restaurantNow(ctx, s.db, rid)
+	if err != nil {
+		return time.Time{}, time.Time{}, err
+	}
+
+	end := time.Date(now.Year(), now.Month(), now.Day()+1, 0, 0, 0, 0, now.Location())
+	if to != "" {
+		t, err := time.ParseInLocation(scheduleDateLayout, to, now.Location())
+		if err != nil {
+			return time.Time{}, time.Time{}, ErrInvalidDateRange
+		}
+		end = t.AddDate(0, 0, 1)
+	}
+
+	start := end.AddDate(0, 0, -30)
+	if from != "" {
+		if start, err = time.ParseInLocation(scheduleDateLayout, from, now.Location()); err != nil {
+			return time.Time{}, time.Time{}, ErrInvalidDateRange
+		}
+	}
+
+	return start, end, nil
+}
+
+func (s *Service) checkReportAccess(ctx context.Context, rid string, from, to time.Time) error {
+	uid, auth := ctx.Value(KeyAuthFoodProviderID).(int64)
+	if !auth {
+		return ErrUnauthenticated
+	}
+
+	if !rxUUID.MatchString(rid) {
+		return ErrInvalidRestaurantId
+	}
+
+	if !to.After(from) {
+		return ErrInvalidDateRange
+	}
+
+	_, err := s.checkPermission(ctx, Manager, uid, rid)
+	return err
+}
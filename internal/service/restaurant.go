@@ -17,11 +17,12 @@ import (
 )
 
 type Restaurant struct {
-	Id     string  `json:"id"`
-	Title  string  `json:"title"`
-	About  string  `json:"about, omitempty"`
-	Role   string  `json:"role, omitempty"`
-	Rating float64 `json:"rating, omitempty"`
+	Id      string  `json:"id"`
+	Title   string  `json:"title"`
+	About   string  `json:"about, omitempty"`
+	Role    string  `json:"role, omitempty"`
+	Rating  float64 `json:"rating, omitempty"`
+	Reviews int     `json:"review_count"`
 }
 
 type RestaurantDetails struct {
@@ -36,15 +37,15 @@ type RestaurantDetails struct {
 	Area           string   `json:"area, omitempty"`
 	Country        string   `json:"country, omitempty"`
 	Phone          string   `json:"phone, omitempty"`
-	OpeningTime    string   `json:"opening_time, omitempty"`
-	ClosingTime    string   `json:"closing_time, omitempty"`
 	AmbassadorCode string   `json:"ambassador_code, omitempty"`
 	VatRegNo       string   `json:"vat_reg_no, omitempty"`
 	Active         string   `json:"active, omitempty"`
-	CloseStatus    bool     `json:"close_status, omitempty"`
+	OpenNow        bool     `json:"open_now"`
+	OrderingPaused bool     `json:"ordering_paused"`
 	CreatedAt      string   `json:"created_at, omitempty"`
 	Role           string   `json:"role, omitempty"`
 	Rating         float64  `json:"rating, omitempty"`
+	Reviews        int      `json:"review_count"`
 	Pictures       *Gallery `json:"pictures, omitempty"`
 }
 
@@ -57,7 +58,7 @@ type Offers struct {
 	Pictures []string `json:"offers"`
 }
 
-func (s *Service) CreateRestaurant(ctx context.Context, title, about, phone, location, city, area, country, openingTime, closingTime string) error {
+func (s *Service) CreateRestaurant(ctx context.Context, title, about, phone, location, city, area, country string) error {
 	uid, ok := ctx.Value(KeyAuthFoodProviderID).(int64)
 	println("Finally:", uid)
 	if !ok {
@@ -71,8 +72,7 @@ func (s *Service) CreateRestaurant(ctx context.Context, title, about, phone, loc
 	area = strings.TrimSpace(area)
 	location = strings.TrimSpace(location)
 	country = "Bangladesh"
-	openingTime = strings.TrimSpace(openingTime)
-	closingTime = strings.TrimSpace(closingTime)
+	phone = normalizePhone(phone)
 	if !rxPhone.MatchString(phone) {
 		return ErrInvalidPhone
 	}
@@ -91,8 +91,8 @@ func (s *Service) CreateRestaurant(ctx context.Context, title, about, phone, loc
 	defer func() { _ = tx.Rollback() }()
 
 	//query, args, err := buildQuery(`
-	//	INSERT INTO restaurant (title, owner_id, about, location, city, area, country, phone, opening_time, closing_time)
-	//	VALUES (@1, @2, @3, @4, @5, @6, @7, @8, @9, @10)
+	//	INSERT INTO restaurant (title, owner_id, about, location, city, area, country, phone)
+	//	VALUES (@1, @2, @3, @4, @5, @6, @7, @8)
 	//	RETURNING id`, map[string]interface{}{
 	//	"1":               title,
 	//	"2":               uid,
@@ -102,18 +102,16 @@ func (s *Service) CreateRestaurant(ctx context.Context, title, about, phone, loc
 	//	"6":               area,
 	//	"7":               country,
 	//	"8":               phone,
-	//	"9":               openingTime,
-	//	"10":              closingTime,
 	//})
 	//if err != nil {
 	//	return fmt.Errorf("could not build sql query: %v", err)
 	//}
 	query := `
-		INSERT INTO restaurant (title, owner_id, about, location, city, area, country, phone, opening_time, closing_time)
-		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10)
+		INSERT INTO restaurant (title, owner_id, about, location, city, area, country, phone)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
 		RETURNING id`
 	var id string
-	err = tx.QueryRowContext(ctx, query, title, uid, about, location, city, area, country, phone, openingTime, closingTime).Scan(&id)
+	err = tx.QueryRowContext(ctx, query, title, uid, about, location, city, area, country, phone).Scan(&id)
 	fmt.Println("[RESTAURANT]", err)
 	unique := isUniqueViolation(err)
 	if unique {
@@ -142,19 +140,22 @@ func (s *Service) CreateRestaurant(ctx context.Context, title, about, phone, loc
 	return nil
 }
 
-func (s *Service) GetRestaurantByID(ctx context.Context, id string) (Restaurant, error) {
-	_, ok := ctx.Value(KeyAuthUserID).(int64)
-	var r Restaurant
-	if !ok {
-		return r, ErrUnauthenticated
-	}
-
-	if !rxUUID.MatchString(id) {
-		return r, ErrInvalidRestaurantId
+// GetRestaurantByID returns the page of an active restaurant for customers,
+// in the context's locale.
+func (s *Service) GetRestaurantByID(ctx context.Context, rid string) (RestaurantProfile, error) {
+	var r RestaurantProfile
+	var lat, lng *float64
+	if !rxUUID.MatchString(rid) {
+		return r, ErrRestaurantNotFound
 	}
 
-	query := "SELECT title, about, rating FROM restaurant WHERE id = $1"
-	err := s.db.QueryRowContext(ctx, query, id).Scan(&r.Title, &r.About, &r.Rating)
+	query := `
+		SELECT r.id, r.title, COALESCE(NULLIF(t.about, ''), r.about, ''), COALESCE(r.avatar, ''), COALESCE(r.cover, ''),
+			r.location, r.city, r.area, r.country, r.phone, r.latitude, r.longitude, r.rating, r.review_count
+		FROM restaurant r LEFT JOIN restaurant_translation t ON r.id = t.restaurant_id AND t.locale = $2
+		WHERE r.id = $1 AND r.active = true`
+	err := s.db.QueryRowContext(ctx, query, rid, localeOf(ctx)).Scan(&r.Id, &r.Title, &r.About, &r.Avatar, &r.Cover,
+		&r.Location, &r.City, &r.Area, &r.Country, &r.Phone, &lat, &lng, &r.Rating, &r.Reviews)
 	if err == sql.ErrNoRows {
 		return r, ErrRestaurantNotFound
 	}
@@ -163,6 +164,45 @@ func (s *Service) GetRestaurantByID(ctx context.Context, id string) (Restaurant,
 		return r, fmt.Errorf("could not query restaurant: %v", err)
 	}
 
+	if lat != nil && lng != nil {
+		r.Position = &Coordinates{Latitude: *lat, Longitude: *lng}
+	}
+	if r.Avatar != "" {
+		r.Avatar = s.restaurantPictureURL(rid, r.Avatar)
+	}
+	if r.Cover != "" {
+		r.Cover = s.restaurantPictureURL(rid, r.Cover)
+	}
+
+	cc, err := restaurantCuisines(ctx, s.db, []string{rid})
+	if err != nil {
+		return r, err
+	}
+
+	r.Cuisines = cc[rid]
+	if r.Cuisines == nil {
+		r.Cuisines = make([]string, 0)
+	}
+
+	g, err := s.GetRestaurantGallery(ctx, rid)
+	if err != nil {
+		return r, err
+	}
+
+	r.Gallery = make([]string, 0, len(g.GalleryPictures))
+	for _, image := range g.GalleryPictures {
+		r.Gallery = append(r.Gallery, s.restaurantPictureURL(rid, path.Join("gallery", image)))
+	}
+
+	r.Offers = make([]string, 0, len(g.OfferPictures))
+	for _, image := range g.OfferPictures {
+		r.Offers = append(r.Offers, s.restaurantPictureURL(rid, path.Join("offers", image)))
+	}
+
+	if r.Hours, err = restaurantHours(ctx, s.db, rid); err != nil {
+		return r, err
+	}
+
 	return r, nil
 }
 
@@ -172,7 +212,7 @@ func (s *Service) GetRestaurantsByFp(ctx context.Context) ([]Restaurant, error)
 		return nil, ErrUnauthenticated
 	}
 
-	query := "SELECT id, title, about, rating FROM restaurant WHERE owner_id = $1"
+	query := "SELECT id, title, about, rating, review_count FROM restaurant WHERE owner_id = $1"
 	rows, err := s.db.QueryContext(ctx, query, uid)
 	if err == sql.ErrNoRows {
 		return nil, ErrRestaurantNotFound
@@ -183,7 +223,7 @@ func (s *Service) GetRestaurantsByFp(ctx context.Context) ([]Restaurant, error)
 	for rows.Next() {
 		var r Restaurant
 		//var rl int
-		if err = rows.Scan(&r.Id, &r.Title, &r.About, &r.Rating); err != nil {
+		if err = rows.Scan(&r.Id, &r.Title, &r.About, &r.Rating, &r.Reviews); err != nil {
 			fmt.Println(r)
 			return nil, fmt.Errorf("could not get title: %v", err)
 		}
@@ -202,13 +242,13 @@ func (s *Service) GetRestaurantsByFp(ctx context.Context) ([]Restaurant, error)
 func (s *Service) getRestaurantByIdForFp(ctx context.Context, id string) (RestaurantDetails, error) {
 	var r RestaurantDetails
 	query := `SELECT id, title, COALESCE(avatar, ''), COALESCE(cover, ''), owner_id, about, active, location, city,
-       area, country, phone, opening_time, closing_time, rating, close_status, created_at,
+       area, country, phone, rating, review_count, created_at,
        COALESCE(ambassador_code, ''), COALESCE(vat_reg_no, '')
 	   FROM restaurant
 	   WHERE id = $1`
 	err := s.db.QueryRowContext(ctx, query, id).Scan(&r.Id, &r.Title, &r.Avatar, &r.Cover, &r.OwnerId, &r.About,
-		&r.Active, &r.Location, &r.City, &r.Area, &r.Country, &r.Phone, &r.OpeningTime, &r.ClosingTime, &r.Rating,
-		&r.CloseStatus, &r.CreatedAt, &r.AmbassadorCode, &r.VatRegNo)
+		&r.Active, &r.Location, &r.City, &r.Area, &r.Country, &r.Phone, &r.Rating,
+		&r.Reviews, &r.CreatedAt, &r.AmbassadorCode, &r.VatRegNo)
 	if err == sql.ErrNoRows {
 		return r, ErrRestaurantNotFound
 	}
@@ -219,10 +259,17 @@ func (s *Service) getRestaurantByIdForFp(ctx context.Context, id string) (Restau
 		return r, fmt.Errorf("could not query restaurant: %v", err)
 	}
 
+	h, err := restaurantHours(ctx, s.db, id)
+	if err != nil {
+		return r, err
+	}
+
+	r.OpenNow = h.OpenNow
+	r.OrderingPaused = h.Paused
 	return r, nil
 }
 
-func (s *Service) CreateRestaurantByAmbassador(ctx context.Context, title, about, phone, location, city, area, country, openingTime, closingTime, referral string) error {
+func (s *Service) CreateRestaurantByAmbassador(ctx context.Context, title, about, phone, location, city, area, country, referral string) error {
 	uid, ok := ctx.Value(KeyAuthFoodProviderID).(int64)
 	if !ok {
 		return ErrUnauthenticated
@@ -233,9 +280,8 @@ func (s *Service) CreateRestaurantByAmbassador(ctx context.Context, title, about
 	city = strings.TrimSpace(city)
 	area = strings.TrimSpace(area)
 	location = strings.TrimSpace(location)
-	openingTime = strings.TrimSpace(openingTime)
-	closingTime = strings.TrimSpace(closingTime)
 	referral = strings.TrimSpace(referral)
+	phone = normalizePhone(phone)
 	if !rxPhone.MatchString(phone) {
 		return ErrInvalidPhone
 	}
@@ -247,13 +293,13 @@ func (s *Service) CreateRestaurantByAmbassador(ctx context.Context, title, about
 	defer func() { _ = tx.Rollback() }()
 
 	query, args, err := buildQuery(`
-		INSERT INTO restaurant (title, owner_id, about, location, city, area, country, phone, opening_time, closing_time
+		INSERT INTO restaurant (title, owner_id, about, location, city, area, country, phone
 		{{if .ambassador_code}}
 		, ambassador_code
 		{{end}})
-		VALUES (@1, @2, @3, @4, @5, @6, @7, @8, @9, @10
+		VALUES (@1, @2, @3, @4, @5, @6, @7, @8
 		{{if .ambassador_code}}
-		, @11
+		, @9
 		{{end}})
   		RETURNING id`, map[string]interface{}{
 		"1":               title,
@@ -264,17 +310,15 @@ func (s *Service) CreateRestaurantByAmbassador(ctx context.Context, title, about
 		"6":               area,
 		"7":               country,
 		"8":               phone,
-		"9":               openingTime,
-		"10":              closingTime,
-		"11":              referral,
+		"9":               referral,
 		"ambassador_code": referral,
 	})
 	if err != nil {
 		return fmt.Errorf("could not build sql query: %v", err)
 	}
 	// query = `
-	//	INSERT INTO restaurant (title, owner_id, about, location, city, area, country, phone, opening_time, closing_time, ambassador_code)
-	//	VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11)
+	//	INSERT INTO restaurant (title, owner_id, about, location, city, area, country, phone, ambassador_code)
+	//	VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)
 	//	RETURNING id`
 	var id string
 	err = tx.QueryRowContext(ctx, query, args...).Scan(&id)
@@ -310,8 +354,7 @@ func (s *Service) UpdateRestaurant(ctx context.Context, id, about, phone, locati
 	city = strings.TrimSpace(city)
 	area = strings.TrimSpace(area)
 	location = strings.TrimSpace(location)
-	phone = strings.TrimSpace(phone)
-
+	phone = normalizePhone(phone)
 	if !rxPhone.MatchString(phone) {
 		return ErrInvalidPhone
 	}
@@ -380,35 +423,7 @@ func (s *Service) UpdateRestaurant(ctx context.Context, id, about, phone, locati
 		return fmt.Errorf("failed to update restaurant: could not commit transaction: %v", err)
 	}
 
-	return nil
-}
-
-func (s *Service) UpdateRestaurantStatus(ctx context.Context, id string, closed bool) error {
-	_, auth := ctx.Value(KeyAuthFoodProviderID).(int64)
-	if !auth {
-		return ErrUnauthenticated
-	}
-
-	if !rxUUID.MatchString(id) {
-		return ErrInvalidRestaurantId
-	}
-
-	tx, err := s.db.BeginTx(ctx, nil)
-	if err != nil {
-		return fmt.Errorf("could not begin tx: %v", err)
-	}
-	defer func() { _ = tx.Rollback() }()
-
-	query := "UPDATE INTO restaurant SET closed = $1 WHERE id = $2"
-	_, err = tx.ExecContext(ctx, query, closed, id)
-	if err != nil {
-		return fmt.Errorf("failed to update restaurant: %v", err)
-	}
-
-	if err := tx.Commit(); err != nil {
-		return fmt.Errorf("failed to update restaurant: could not commit transaction: %v", err)
-	}
-
+	s.indexRestaurant(ctx, id)
 	return nil
 }
 
@@ -423,7 +438,7 @@ func (s *Service) UpdateRestaurantDisplayPicture(ctx context.Context, r io.Reade
 		return "", ErrInvalidRestaurantId
 	}
 
-	if _, err := s.checkPermission(ctx, Manager, uid, rid); err != nil {
+	if _, err := s.checkPermission(ctx, Owner, uid, rid); err != nil {
 		fmt.Println("Permission Failed!")
 		return "", ErrUnauthenticated
 	}
@@ -516,7 +531,7 @@ func (s *Service) UpdatePicture(ctx context.Context, r io.Reader, rid, dir, quer
 		return "", ErrUnsupportedImageFormat
 	}
 
-	if _, err := s.checkPermission(ctx, Manager, uid, rid); err != nil {
+	if _, err := s.checkPermission(ctx, Owner, uid, rid); err != nil {
 		fmt.Println("Permission Failed!")
 		return "", ErrUnauthenticated
 	}
@@ -584,7 +599,7 @@ func (s *Service) UpdateRestaurantCoverPicture(ctx context.Context, r io.Reader,
 		return "", ErrInvalidRestaurantId
 	}
 
-	if _, err := s.checkPermission(ctx, Manager, uid, rid); err != nil {
+	if _, err := s.checkPermission(ctx, Owner, uid, rid); err != nil {
 		fmt.Println("Permission Failed!")
 		return "", ErrUnauthenticated
 	}
@@ -665,7 +680,7 @@ func (s *Service) CreateRestaurantGalleryPicture(ctx context.Context, r io.Reade
 		return "", ErrInvalidRestaurantId
 	}
 
-	if _, err := s.checkPermission(ctx, Manager, uid, rid); err != nil {
+	if _, err := s.checkPermission(ctx, Owner, uid, rid); err != nil {
 		fmt.Println("Permission Failed!")
 		return "", ErrUnauthenticated
 	}
@@ -739,7 +754,7 @@ func (s *Service) DeleteRestaurantGalleryPicture(ctx context.Context, rid, image
 		return ErrInvalidRestaurantId
 	}
 
-	if _, err := s.checkPermission(ctx, Manager, uid, rid); err != nil {
+	if _, err := s.checkPermission(ctx, Owner, uid, rid); err != nil {
 		fmt.Println("Permission Failed!")
 		return ErrUnauthenticated
 	}
@@ -809,7 +824,7 @@ func (s *Service) CreateRestaurantOffersPicture(ctx context.Context, r io.Reader
 		return "", ErrInvalidRestaurantId
 	}
 
-	if _, err := s.checkPermission(ctx, Manager, uid, rid); err != nil {
+	if _, err := s.checkPermission(ctx, Owner, uid, rid); err != nil {
 		fmt.Println("Permission Failed!")
 		return "", ErrUnauthenticated
 	}
@@ -884,7 +899,7 @@ func (s *Service) DeleteRestaurantOffersPicture(ctx context.Context, rid, image
 		return ErrInvalidRestaurantId
 	}
 
-	if _, err := s.checkPermission(ctx, Manager, uid, rid); err != nil {
+	if _, err := s.checkPermission(ctx, Owner, uid, rid); err != nil {
 		fmt.Println("Permission Failed!")
 		return ErrUnauthenticated
 	}
@@ -24,17 +24,15 @@ func TestCreateRestaurant(t *testing.T) {
 		City      string
 		Area      string
 		Country   string
-		OTime     string
-		CTime     string
 		Referral  string
 	}
 
 	var tt = []testdata {
 		// success condition
-		{Label: "Test should create Restaurant successfully", Condition: "success", Want: nil, Title: "White Canary", About: "WE sale yummy pancakes!", Phone: "01967584756", Location: "House #50, Road #89", City: "Dhaka", Area: "Gulshan", Country: "Bangladesh", OTime: "8AM", CTime: "11PM", Referral: ""},
+		{Label: "Test should create Restaurant successfully", Condition: "success", Want: nil, Title: "White Canary", About: "WE sale yummy pancakes!", Phone: "01967584756", Location: "House #50, Road #89", City: "Dhaka", Area: "Gulshan", Country: "Bangladesh", Referral: ""},
 		// error condition
-		{Label: "Test should fail with title taken", Condition: "fail", Want: ErrTitleTaken, Title: "White Canary", About: "WE sale yummy pancakes!", Phone: "01967584756", Location: "House #50, Road #89", City: "Dhaka", Area: "Gulshan", Country: "Bangladesh", OTime: "8AM", CTime: "11PM", Referral: ""},
-		{Label: "Test should fail with invalid phone number", Condition: "fail", Want: ErrInvalidPhone, Title: "Taste Bud", About: "WE sale yummy desserts!", Phone: "019675847560", Location: "House #50, Road #89", City: "Dhaka", Area: "Gulshan", Country: "Bangladesh", OTime: "8AM", CTime: "11PM", Referral: ""},
+		{Label: "Test should fail with title taken", Condition: "fail", Want: ErrTitleTaken, Title: "White Canary", About: "WE sale yummy pancakes!", Phone: "01967584756", Location: "House #50, Road #89", City: "Dhaka", Area: "Gulshan", Country: "Bangladesh", Referral: ""},
+		{Label: "Test should fail with invalid phone number", Condition: "fail", Want: ErrInvalidPhone, Title: "Taste Bud", About: "WE sale yummy desserts!", Phone: "019675847560", Location: "House #50, Road #89", City: "Dhaka", Area: "Gulshan", Country: "Bangladesh", Referral: ""},
 	}
 
 	tearDown := SetupTest()
@@ -65,10 +63,10 @@ func TestCreateRestaurant(t *testing.T) {
 	user, _ := s.FoodProviderLogin(ctx, "johndoe@gmail.com", "ilovegolang")
 	ctx = context.WithValue(ctx, KeyAuthFoodProviderID, user.AuthUser.ID)
 
-	_ = s.CreateUser(ctx, "johndoe@gmail.com", "John Snow", "ilovegolang")
+	_ = s.CreateUser(ctx, "johndoe@gmail.com", "01867584576", "John Snow", "ilovegolang")
 	user2, _ := s.UserLogin(ctx, "johndoe@gmail.com", "ilovegolang")
 	ctx2 := context.WithValue(ctx, KeyAuthUserID, user2.AuthUser.ID)
-	tt = append(tt, testdata{Label: "Test should fail to create a restaurant by a non food provider account", Condition: "fail", Want: ErrUnauthenticated, Context:ctx2, Title: "Fake Out 2.0", About: "WE sale yummy pancakes!", Phone: "01967584756", Location: "House #50, Road #89", City: "Dhaka", Area: "Gulshan", Country: "Bangladesh", OTime: "8AM", CTime: "11PM", Referral: ""})
+	tt = append(tt, testdata{Label: "Test should fail to create a restaurant by a non food provider account", Condition: "fail", Want: ErrUnauthenticated, Context:ctx2, Title: "Fake Out 2.0", About: "WE sale yummy pancakes!", Phone: "01967584756", Location: "House #50, Road #89", City: "Dhaka", Area: "Gulshan", Country: "Bangladesh", Referral: ""})
 	//_ = s.CreateFoodProvider(ctx, "evil@gmail.com", "John Snow Evil", "01807584576", "ilovebeingevil")
 	//user2, _ := s.FoodProviderLogin(ctx, "johndoe@gmail.com", "ilovebeingevil")
 	//ctx2 := context.WithValue(ctx, KeyAuthFoodProviderID, user2.AuthUser.ID)
@@ -78,7 +76,7 @@ func TestCreateRestaurant(t *testing.T) {
 			if test.Label ==  "Test should fail to create a restaurant by a non food provider account" {
 				ctx = ctx2
 			}
-			Got := s.CreateRestaurant(ctx2, test.Title, test.About, test.Phone, test.Location, test.City, test.Area, test.Country, test.OTime, test.CTime)
+			Got := s.CreateRestaurant(ctx2, test.Title, test.About, test.Phone, test.Location, test.City, test.Area, test.Country)
 			if test.Condition == "success" {
 				if Got != nil {
 					t.Error("Got:", Got, "| Want:", test.Want)
@@ -0,0 +1,487 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	// maxReviewPictures a customer can attach to a single review.
+	maxReviewPictures = 3
+	// reviewFlagThreshold is the number of flags after which a review is hidden.
+	reviewFlagThreshold = 3
+	maxReviewLength     = 1000
+)
+
+var (
+	// ErrReviewNotFound denotes a not found review.
+	ErrReviewNotFound = errors.New("review not found")
+	// ErrReviewExists denotes the order was already reviewed.
+	ErrReviewExists = errors.New("order already reviewed")
+	// ErrOrderNotCompleted denotes a review for an order that is not completed yet.
+	ErrOrderNotCompleted = errors.New("order not completed")
+	// ErrInvalidStars denotes a rating outside of 1 to 5 stars.
+	ErrInvalidStars = errors.New("invalid stars")
+	// ErrInvalidReview denotes a review or reply text that is too long.
+	ErrInvalidReview = errors.New("invalid review")
+	// ErrTooManyPictures denotes a review already has the maximum pictures.
+	ErrTooManyPictures = errors.New("too many pictures")
+	// ErrAlreadyFlagged denotes the reporter already flagged the review.
+	ErrAlreadyFlagged = errors.New("review already flagged")
+	// ErrNotCustomer denotes a user flagging a review of a restaurant they never completed an order from.
+	ErrNotCustomer = errors.New("not a customer of the restaurant")
+)
+
+type Review struct {
+	Id        int64      `json:"id"`
+	OrderId   int64      `json:"order_id"`
+	UserId    int64      `json:"user_id"`
+	Fullname  string     `json:"fullname"`
+	Stars     int        `json:"stars"`
+	Body      string     `json:"body"`
+	Pictures  []string   `json:"pictures"`
+	Reply     *string    `json:"reply,omitempty"`
+	RepliedAt *time.Time `json:"replied_at,omitempty"`
+	Hidden    bool       `json:"hidden,omitempty"`
+	Flags     int        `json:"flags,omitempty"`
+	CreatedAt time.Time  `json:"created_at"`
+}
+
+// CreateReview for one of the authenticated user's completed orders.
+func (s *Service) CreateReview(ctx context.Context, oid int64, stars int, body string) (int64, error) {
+	uid, auth := ctx.Value(KeyAuthUserID).(int64)
+	if !auth {
+		return 0, ErrUnauthenticated
+	}
+
+	if stars < 1 || stars > 5 {
+		return 0, ErrInvalidStars
+	}
+
+	body = strings.TrimSpace(body)
+	if len(body) > maxReviewLength {
+		return 0, ErrInvalidReview
+	}
+
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return 0, fmt.Errorf("could not begin tx: %v", err)
+	}
+	defer func() { _ = tx.Rollback() }()
+
+	var rid string
+	var status int64
+	query := "SELECT restaurant_id, status FROM orders WHERE id = $1 AND cust_id = $2"
+	err = tx.QueryRowContext(ctx, query, oid, uid).Scan(&rid, &status)
+	if err == sql.ErrNoRows {
+		return 0, ErrOrderNotFound
+	}
+
+	if err != nil {
+		return 0, fmt.Errorf("could not query order: %v", err)
+	}
+
+	if status != OrderCompleted {
+		return 0, ErrOrderNotCompleted
+	}
+
+	var id int64
+	query = "INSERT INTO review (order_id, restaurant_id, user_id, stars, body) VALUES ($1, $2, $3, $4, $5) RETURNING id"
+	err = tx.QueryRowContext(ctx, query, oid, rid, uid, stars, body).Scan(&id)
+	if isUniqueViolation(err) {
+		return 0, ErrReviewExists
+	}
+
+	if err != nil {
+		return 0, fmt.Errorf("could not create review: %v", err)
+	}
+
+	if err = updateRestaurantRating(ctx, tx, rid); err != nil {
+		return 0, err
+	}
+
+	if err = tx.Commit(); err != nil {
+		return 0, fmt.Errorf("could not create review: could not commit transaction: %v", err)
+	}
+
+	return id, nil
+}
+
+// CreateReviewPicture attaches a picture to a review of the authenticated user.
+func (s *Service) CreateReviewPicture(ctx context.Context, r io.Reader, id int64) (string, error) {
+	uid, auth := ctx.Value(KeyAuthUserID).(int64)
+	if !auth {
+		return "", ErrUnauthenticated
+	}
+
+	var rid string
+	var count int
+	query := `
+		SELECT r.restaurant_id, (SELECT count(*) FROM review_picture WHERE review_id = r.id)
+		FROM review r
+		WHERE r.id = $1 AND r.user_id = $2`
+	err := s.db.QueryRowContext(ctx, query, id, uid).Scan(&rid, &count)
+	if err == sql.ErrNoRows {
+		return "", ErrReviewNotFound
+	}
+
+	if err != nil {
+		return "", fmt.Errorf("could not query review: %v", err)
+	}
+
+	if count >= maxReviewPictures {
+		return "", ErrTooManyPictures
+	}
+
+	dir := path.Join(restaurantDir, rid, "reviews")
+	imageName, err := saveImage(r, dir, 600, 600)
+	if err != nil {
+		return "", err
+	}
+
+	// The count is checked again as the picture goes in, in case another
+	// upload got in since.
+	query = `
+		INSERT INTO review_picture (review_id, image)
+		SELECT $1, $2 WHERE (SELECT count(*) FROM review_picture WHERE review_id = $1) < $3`
+	res, err := s.db.ExecContext(ctx, query, id, imageName, maxReviewPictures)
+	if err != nil {
+		defer os.Remove(path.Join(dir, imageName))
+		return "", fmt.Errorf("could not save review picture: %v", err)
+	}
+
+	if n, _ := res.RowsAffected(); n == 0 {
+		defer os.Remove(path.Join(dir, imageName))
+		return "", ErrTooManyPictures
+	}
+
+	return s.reviewPictureURL(rid, imageName), nil
+}
+
+// GetReviews of a restaurant, newest first. Hidden reviews are left out.
+// Pass the id of the last review received as before to get the next page.
+func (s *Service) GetReviews(ctx context.Context, rid string, last int64, first int) ([]Review, error) {
+	if !rxUUID.MatchString(rid) {
+		return nil, ErrInvalidRestaurantId
+	}
+
+	return s.reviews(ctx, rid, false, last, first)
+}
+
+// GetReviewsForFp lists every review of the restaurant including the hidden
+// ones and how many times they were flagged.
+func (s *Service) GetReviewsForFp(ctx context.Context, rid string, last int64, first int) ([]Review, error) {
+	uid, auth := ctx.Value(KeyAuthFoodProviderID).(int64)
+	if !auth {
+		return nil, ErrUnauthenticated
+	}
+
+	if !rxUUID.MatchString(rid) {
+		return nil, ErrInvalidRestaurantId
+	}
+
+	if _, err := s.checkPermission(ctx, Supervisor, uid, rid); err != nil {
+		return nil, err
+	}
+
+	return s.reviews(ctx, rid, true, last, first)
+}
+
+func (s *Service) reviews(ctx context.Context, rid string, all bool, last int64, first int) ([]Review, error) {
+	first = normalizePageSize(first)
+	query, args, err := buildQuery(`
+		SELECT r.id, r.order_id, r.user_id, u.fullname, r.stars, r.body, r.reply, r.replied_at, r.hidden,
+			(SELECT count(*) FROM review_flag WHERE review_id = r.id), r.created_at
+		FROM review r INNER JOIN users u ON r.user_id = u.id
+		WHERE r.restaurant_id = @rid
+		{{if not .all}}
+		AND r.hidden = false
+		{{end}}
+		{{if .last}}
+		AND r.id < @last
+		{{end}}
+		ORDER BY r.id DESC
+		LIMIT @first`, map[string]interface{}{
+		"rid":   rid,
+		"all":   all,
+		"last":  last,
+		"first": first,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("could not build reviews sql query: %v", err)
+	}
+
+	rows, err := s.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("could not query reviews: %v", err)
+	}
+
+	defer rows.Close()
+	rr := make([]Review, 0, first)
+	ids := make([]string, 0, first)
+	for rows.Next() {
+		var r Review
+		var reply sql.NullString
+		var repliedAt *time.Time
+		if err = rows.Scan(&r.Id, &r.OrderId, &r.UserId, &r.Fullname, &r.Stars, &r.Body, &reply, &repliedAt,
+			&r.Hidden, &r.Flags, &r.CreatedAt); err != nil {
+			return nil, fmt.Errorf("could not scan review: %v", err)
+		}
+
+		if reply.Valid {
+			r.Reply = &reply.String
+			r.RepliedAt = repliedAt
+		}
+		if !all {
+			r.Flags = 0
+		}
+		r.Pictures = make([]string, 0)
+		rr = append(rr, r)
+		ids = append(ids, strconv.FormatInt(r.Id, 10))
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("could not iterate reviews: %v", err)
+	}
+
+	if len(rr) == 0 {
+		return rr, nil
+	}
+
+	query = "SELECT review_id, image FROM review_picture WHERE review_id IN (" + strings.Join(ids, ", ") + ") ORDER BY id"
+	pictures, err := s.db.QueryContext(ctx, query)
+	if err != nil {
+		return nil, fmt.Errorf("could not query review pictures: %v", err)
+	}
+
+	defer pictures.Close()
+	for pictures.Next() {
+		var id int64
+		var image string
+		if err = pictures.Scan(&id, &image); err != nil {
+			return nil, fmt.Errorf("could not scan review picture: %v", err)
+		}
+
+		for i := range rr {
+			if rr[i].Id == id {
+				rr[i].Pictures = append(rr[i].Pictures, s.reviewPictureURL(rid, image))
+			}
+		}
+	}
+
+	if err = pictures.Err(); err != nil {
+		return nil, fmt.Errorf("could not iterate review pictures: %v", err)
+	}
+
+	return rr, nil
+}
+
+// ReplyToReview lets the owner of the restaurant publicly answer a review.
+// Replying again replaces the previous reply.
+func (s *Service) ReplyToReview(ctx context.Context, rid string, id int64, reply string) error {
+	uid, auth := ctx.Value(KeyAuthFoodProviderID).(int64)
+	if !auth {
+		return ErrUnauthenticated
+	}
+
+	if !rxUUID.MatchString(rid) {
+		return ErrInvalidRestaurantId
+	}
+
+	reply = strings.TrimSpace(reply)
+	if reply == "" {
+		return ErrEmptyValue
+	}
+
+	if len(reply) > maxReviewLength {
+		return ErrInvalidReview
+	}
+
+	if _, err := s.checkPermission(ctx, Owner, uid, rid); err != nil {
+		return err
+	}
+
+	query := "UPDATE review SET reply = $1, replied_at = now() WHERE id = $2 AND restaurant_id = $3"
+	res, err := s.db.ExecContext(ctx, query, reply, id, rid)
+	if err != nil {
+		return fmt.Errorf("could not reply to review: %v", err)
+	}
+
+	if n, _ := res.RowsAffected(); n == 0 {
+		return ErrReviewNotFound
+	}
+
+	return nil
+}
+
+// FlagReview reports a review as inappropriate. Either a user who completed
+// an order from the restaurant or its staff can flag, once each. Once a
+// review gathers enough flags from customers it is hidden and no longer
+// counts toward the rating. Staff flags are kept for moderation only, so a
+// restaurant can't hide the reviews it dislikes.
+func (s *Service) FlagReview(ctx context.Context, rid string, id int64, reason string) error {
+	var reporter string
+	customer, isCustomer := ctx.Value(KeyAuthUserID).(int64)
+	if isCustomer {
+		reporter = "user:" + strconv.FormatInt(customer, 10)
+	} else if uid, auth := ctx.Value(KeyAuthFoodProviderID).(int64); auth {
+		if !rxUUID.MatchString(rid) {
+			return ErrInvalidRestaurantId
+		}
+
+		if _, err := s.checkPermission(ctx, Owner, uid, rid); err != nil {
+			return err
+		}
+		reporter = "fp:" + strconv.FormatInt(uid, 10)
+	} else {
+		return ErrUnauthenticated
+	}
+
+	reason = strings.TrimSpace(reason)
+	if reason == "" {
+		return ErrEmptyValue
+	}
+
+	if len(reason) > 255 {
+		return ErrInvalidReview
+	}
+
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("could not begin tx: %v", err)
+	}
+	defer func() { _ = tx.Rollback() }()
+
+	var restaurant string
+	query := "SELECT restaurant_id FROM review WHERE id = $1"
+	err = tx.QueryRowContext(ctx, query, id).Scan(&restaurant)
+	if err == sql.ErrNoRows || (err == nil && rid != "" && restaurant != rid) {
+		return ErrReviewNotFound
+	}
+
+	if err != nil {
+		return fmt.Errorf("could not query review: %v", err)
+	}
+
+	// Customers can only flag reviews of restaurants they ordered from, so
+	// a few throwaway accounts can't hide any review.
+	if isCustomer {
+		var ordered bool
+		query = "SELECT EXISTS (SELECT 1 FROM orders WHERE cust_id = $1 AND restaurant_id = $2 AND status = $3)"
+		if err = tx.QueryRowContext(ctx, query, customer, restaurant, OrderCompleted).Scan(&ordered); err != nil {
+			return fmt.Errorf("could not query orders: %v", err)
+		}
+
+		if !ordered {
+			return ErrNotCustomer
+		}
+	}
+
+	query = "INSERT INTO review_flag (review_id, reporter, reason) VALUES ($1, $2, $3)"
+	_, err = tx.ExecContext(ctx, query, id, reporter, reason)
+	if isUniqueViolation(err) {
+		return ErrAlreadyFlagged
+	}
+
+	if err != nil {
+		return fmt.Errorf("could not flag review: %v", err)
+	}
+
+	// Only flags raised since the review was last unhidden count, so the
+	// flags a moderator dismissed don't hide it again.
+	query = `
+		UPDATE review SET hidden = true
+		WHERE id = $1 AND hidden = false AND (
+			SELECT count(*) FROM review_flag f, review r
+			WHERE r.id = $1 AND f.review_id = $1 AND f.reporter NOT LIKE 'fp:%'
+				AND (r.unhidden_at IS NULL OR f.created_at > r.unhidden_at)
+		) >= $2`
+	res, err := tx.ExecContext(ctx, query, id, reviewFlagThreshold)
+	if err != nil {
+		return fmt.Errorf("could not hide review: %v", err)
+	}
+
+	if n, _ := res.RowsAffected(); n > 0 {
+		if err = updateRestaurantRating(ctx, tx, restaurant); err != nil {
+			return err
+		}
+	}
+
+	if err = tx.Commit(); err != nil {
+		return fmt.Errorf("could not flag review: could not commit transaction: %v", err)
+	}
+
+	return nil
+}
+
+// UnhideReview shows a review hidden by flags again, dismissing the flags it
+// gathered so far. It counts toward the rating again.
+func (s *Service) UnhideReview(ctx context.Context, rid string, id int64) error {
+	uid, auth := ctx.Value(KeyAuthFoodProviderID).(int64)
+	if !auth {
+		return ErrUnauthenticated
+	}
+
+	if !rxUUID.MatchString(rid) {
+		return ErrInvalidRestaurantId
+	}
+
+	if _, err := s.checkPermission(ctx, Owner, uid, rid); err != nil {
+		return err
+	}
+
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("could not begin tx: %v", err)
+	}
+	defer func() { _ = tx.Rollback() }()
+
+	query := "UPDATE review SET hidden = false, unhidden_at = now() WHERE id = $1 AND restaurant_id = $2 AND hidden = true"
+	res, err := tx.ExecContext(ctx, query, id, rid)
+	if err != nil {
+		return fmt.Errorf("could not unhide review: %v", err)
+	}
+
+	if n, _ := res.RowsAffected(); n == 0 {
+		return ErrReviewNotFound
+	}
+
+	if err = updateRestaurantRating(ctx, tx, rid); err != nil {
+		return err
+	}
+
+	if err = tx.Commit(); err != nil {
+		return fmt.Errorf("could not unhide review: could not commit transaction: %v", err)
+	}
+
+	return nil
+}
+
+// updateRestaurantRating recalculates the aggregate rating and review count
+// of the restaurant from its visible reviews.
+func updateRestaurantRating(ctx context.Context, tx *sql.Tx, rid string) error {
+	query := `
+		UPDATE restaurant SET
+			rating = (SELECT COALESCE(ROUND(AVG(stars), 1), 0) FROM review WHERE restaurant_id = $1 AND hidden = false),
+			review_count = (SELECT count(*) FROM review WHERE restaurant_id = $1 AND hidden = false)
+		WHERE id = $1`
+	if _, err := tx.ExecContext(ctx, query, rid); err != nil {
+		return fmt.Errorf("could not update restaurant rating: %v", err)
+	}
+
+	return nil
+}
+
+func (s *Service) reviewPictureURL(rid, image string) string {
+	u := s.origin
+	u.Path = "/img/restaurant/" + rid + "/reviews/" + image
+	return u.String()
+}
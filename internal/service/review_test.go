@@ -0,0 +1,232 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/hako/branca"
+	"github.com/jackc/pgx"
+	"github.com/jackc/pgx/stdlib"
+)
+
+// setupReviews starts a database with a restaurant and returns the service,
+// the database, a context of its owner and the restaurant id.
+func setupReviews() (*Service, *sql.DB, context.Context, string, func()) {
+	tearDown := SetupTest()
+
+	ctx := context.TODO()
+
+	codec := branca.NewBranca("supersecretkeyyoushouldnotcommit")
+	codec.SetTTL(uint32(TokenLifeSpan.Seconds()))
+
+	fpCodec := branca.NewBranca("supersecretkeyyoushouldcommitnot")
+	fpCodec.SetTTL(uint32(TokenLifeSpan.Seconds()))
+
+	c, err := pgx.ParseURI(pgURL.String())
+	if err != nil {
+		log.Fatalf(err.Error())
+	}
+
+	db := stdlib.OpenDB(c)
+
+	if err := ValidateSchema(db); err != nil {
+		log.Fatalf("failed to validate schema: %v\n", err)
+	}
+
+	s := New(db, codec, fpCodec, nil, url.URL{})
+
+	_ = s.CreateFoodProvider(ctx, "johndoe@gmail.com", "John Snow", "01867584576", "ilovegolang")
+	user, _ := s.FoodProviderLogin(ctx, "01867584576", "ilovegolang")
+	ctx = context.WithValue(ctx, KeyAuthFoodProviderID, user.AuthUser.ID)
+	_ = s.CreateRestaurant(ctx, "test.Title", "test.About", "01616534596", "test.Location", "test.City", "test.Area", "test.Country")
+	user, _ = s.FoodProviderLogin(ctx, "01867584576", "ilovegolang")
+	rid := (*user.Restaurants)[0].Id
+
+	return s, db, ctx, rid, tearDown
+}
+
+// seedCustomer adds a customer with an order in the status and returns the
+// customer's context and the order id.
+func seedCustomer(t *testing.T, db *sql.DB, rid, email string, status int64) (context.Context, int64) {
+	var uid, oid int64
+	query := "INSERT INTO users (email, fullname) VALUES ($1, 'Jane Doe') RETURNING id"
+	if err := db.QueryRow(query, email).Scan(&uid); err != nil {
+		t.Fatal("could not seed customer:", err)
+	}
+
+	query = "INSERT INTO orders (cust_id, restaurant_id, status) VALUES ($1, $2, $3) RETURNING id"
+	if err := db.QueryRow(query, uid, rid, status).Scan(&oid); err != nil {
+		t.Fatal("could not seed order:", err)
+	}
+
+	return context.WithValue(context.TODO(), KeyAuthUserID, uid), oid
+}
+
+func TestCreateReview(t *testing.T) {
+	s, db, _, rid, tearDown := setupReviews()
+	defer tearDown()
+
+	customer, completed := seedCustomer(t, db, rid, "jane@gmail.com", OrderCompleted)
+	_, placed := seedCustomer(t, db, rid, "june@gmail.com", OrderPlaced)
+
+	var tt = []struct {
+		Label string
+		Order int64
+		Stars int
+		Body  string
+		Want  error
+	}{
+		{Label: "Test should review a completed order", Order: completed, Stars: 4, Body: "Good biryani", Want: nil},
+		{Label: "Test should not review an order twice", Order: completed, Stars: 5, Want: ErrReviewExists},
+		{Label: "Test should not review another customer's order", Order: placed, Stars: 4, Want: ErrOrderNotFound},
+		{Label: "Test should not review with no stars", Order: completed, Stars: 0, Want: ErrInvalidStars},
+		{Label: "Test should not review with more than five stars", Order: completed, Stars: 6, Want: ErrInvalidStars},
+		{Label: "Test should not review with a body that is too long", Order: completed, Stars: 4, Body: strings.Repeat("a", maxReviewLength+1), Want: ErrInvalidReview},
+	}
+
+	for _, test := range tt {
+		t.Run(test.Label, func(t *testing.T) {
+			_, got := s.CreateReview(customer, test.Order, test.Stars, test.Body)
+			if got != test.Want {
+				t.Error("Got:", got, "| Want:", test.Want)
+			}
+		})
+	}
+
+	t.Run("Test should not review an order that is not completed", func(t *testing.T) {
+		other, oid := seedCustomer(t, db, rid, "joan@gmail.com", OrderReady)
+		if _, got := s.CreateReview(other, oid, 4, ""); got != ErrOrderNotCompleted {
+			t.Error("Got:", got, "| Want:", ErrOrderNotCompleted)
+		}
+	})
+}
+
+func TestFlagReview(t *testing.T) {
+	s, db, owner, rid, tearDown := setupReviews()
+	defer tearDown()
+
+	author, oid := seedCustomer(t, db, rid, "jane@gmail.com", OrderCompleted)
+	id, err := s.CreateReview(author, oid, 1, "Cold food")
+	if err != nil {
+		t.Fatal("Got:", err, "| Want: the review created")
+	}
+
+	first, _ := seedCustomer(t, db, rid, "june@gmail.com", OrderCompleted)
+	second, _ := seedCustomer(t, db, rid, "joan@gmail.com", OrderCompleted)
+	third, _ := seedCustomer(t, db, rid, "jill@gmail.com", OrderCompleted)
+	stranger, _ := seedCustomer(t, db, rid, "jack@gmail.com", OrderPlaced)
+
+	var tt = []struct {
+		Label  string
+		Ctx    context.Context
+		Rid    string
+		Reason string
+		Want   error
+		Hidden bool
+	}{
+		{Label: "Test should store a staff flag", Ctx: owner, Rid: rid, Reason: "Fake", Want: nil, Hidden: false},
+		{Label: "Test should not flag twice", Ctx: owner, Rid: rid, Reason: "Fake", Want: ErrAlreadyFlagged, Hidden: false},
+		{Label: "Test should not flag without a reason", Ctx: first, Reason: " ", Want: ErrEmptyValue, Hidden: false},
+		{Label: "Test should not flag as someone who never ordered", Ctx: stranger, Reason: "Spam", Want: ErrNotCustomer, Hidden: false},
+		{Label: "Test should flag as a customer", Ctx: first, Reason: "Spam", Want: nil, Hidden: false},
+		{Label: "Test should not hide with a staff flag counted", Ctx: second, Reason: "Spam", Want: nil, Hidden: false},
+		{Label: "Test should hide at the threshold of customer flags", Ctx: third, Reason: "Spam", Want: nil, Hidden: true},
+	}
+
+	for _, test := range tt {
+		t.Run(test.Label, func(t *testing.T) {
+			if got := s.FlagReview(test.Ctx, test.Rid, id, test.Reason); got != test.Want {
+				t.Error("Got:", got, "| Want:", test.Want)
+			}
+
+			var hidden bool
+			if err := db.QueryRow("SELECT hidden FROM review WHERE id = $1", id).Scan(&hidden); err != nil {
+				t.Fatal(err)
+			}
+
+			if hidden != test.Hidden {
+				t.Error("Got:", hidden, "| Want:", test.Hidden)
+			}
+		})
+	}
+
+	t.Run("Test should unhide and not hide again on dismissed flags", func(t *testing.T) {
+		if got := s.UnhideReview(owner, rid, id); got != nil {
+			t.Error("Got:", got, "| Want:", nil)
+		}
+
+		fourth, _ := seedCustomer(t, db, rid, "joe@gmail.com", OrderCompleted)
+		if got := s.FlagReview(fourth, "", id, "Spam"); got != nil {
+			t.Error("Got:", got, "| Want:", nil)
+		}
+
+		var hidden bool
+		if err := db.QueryRow("SELECT hidden FROM review WHERE id = $1", id).Scan(&hidden); err != nil {
+			t.Fatal(err)
+		}
+
+		if hidden {
+			t.Error("Got:", hidden, "| Want:", false)
+		}
+	})
+
+	t.Run("Test should not unhide a visible review", func(t *testing.T) {
+		if got := s.UnhideReview(owner, rid, id); got != ErrReviewNotFound {
+			t.Error("Got:", got, "| Want:", ErrReviewNotFound)
+		}
+	})
+}
+
+func TestUpdateRestaurantRating(t *testing.T) {
+	s, db, _, rid, tearDown := setupReviews()
+	defer tearDown()
+
+	var tt = []struct {
+		Label  string
+		Stars  int
+		Hidden bool
+		Rating float64
+		Count  int
+	}{
+		{Label: "Test should rate a single review", Stars: 5, Rating: 5, Count: 1},
+		{Label: "Test should average the reviews", Stars: 4, Rating: 4.5, Count: 2},
+		{Label: "Test should round to one decimal", Stars: 4, Rating: 4.3, Count: 3},
+		{Label: "Test should leave out hidden reviews", Stars: 1, Hidden: true, Rating: 4.3, Count: 3},
+	}
+
+	for i, test := range tt {
+		t.Run(test.Label, func(t *testing.T) {
+			_, oid := seedCustomer(t, db, rid, "jane"+string(rune('a'+i))+"@gmail.com", OrderCompleted)
+			query := "INSERT INTO review (order_id, restaurant_id, user_id, stars, hidden) SELECT id, restaurant_id, cust_id, $2, $3 FROM orders WHERE id = $1"
+			if _, err := db.Exec(query, oid, test.Stars, test.Hidden); err != nil {
+				t.Fatal(err)
+			}
+
+			tx, err := s.db.BeginTx(context.TODO(), nil)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if err = updateRestaurantRating(context.TODO(), tx, rid); err != nil {
+				t.Fatal(err)
+			}
+
+			if err = tx.Commit(); err != nil {
+				t.Fatal(err)
+			}
+
+			var rating float64
+			var count int
+			if err = db.QueryRow("SELECT rating, review_count FROM restaurant WHERE id = $1", rid).Scan(&rating, &count); err != nil {
+				t.Fatal(err)
+			}
+
+			if rating != test.Rating || count != test.Count {
+				t.Error("Got:", rating, count, "| Want:", test.Rating, test.Count)
+			}
+		})
+	}
+}
@@ -0,0 +1,348 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const scheduleDateLayout = "2006-01-02"
+
+// ErrInvalidSchedule denotes a schedule with unknown days or malformed or
+// empty time and date ranges.
+var ErrInvalidSchedule = errors.New("invalid schedule")
+
+// Schedule is a window in which a category or item can be ordered, in the
+// restaurant's time zone. Empty fields don't restrict: no days means every
+// day, no times the whole day and no dates forever. An end time before the
+// start time runs past midnight.
+type Schedule struct {
+	Days  []time.Weekday `json:"days"`
+	Start string         `json:"start"`
+	End   string         `json:"end"`
+	From  string         `json:"from"`
+	To    string         `json:"to"`
+}
+
+// clockMinutes parses an H:MM or HH:MM time of day into minutes since
+// midnight. 24:00 is the end of the day.
+func clockMinutes(hm string) (int, bool) {
+	i := strings.IndexByte(hm, ':')
+	if i < 1 || i > 2 || len(hm) != i+3 {
+		return 0, false
+	}
+
+	h, err := strconv.Atoi(hm[:i])
+	if err != nil || h < 0 || h > 24 {
+		return 0, false
+	}
+
+	m, err := strconv.Atoi(hm[i+1:])
+	if err != nil || m < 0 || m > 59 || (h == 24 && m != 0) {
+		return 0, false
+	}
+
+	return h*60 + m, true
+}
+
+// minutesOf the day at t.
+func minutesOf(t time.Time) int {
+	return t.Hour()*60 + t.Minute()
+}
+
+func (s Schedule) validate() error {
+	for _, d := range s.Days {
+		if d < time.Sunday || d > time.Saturday {
+			return ErrInvalidSchedule
+		}
+	}
+
+	if (s.Start == "") != (s.End == "") {
+		return ErrInvalidSchedule
+	}
+
+	if s.Start != "" {
+		start, ok := clockMinutes(s.Start)
+		end, endOk := clockMinutes(s.End)
+		if !ok || !endOk || start == 24*60 || start == end {
+			return ErrInvalidSchedule
+		}
+	}
+
+	for _, d := range []string{s.From, s.To} {
+		if _, err := time.Parse(scheduleDateLayout, d); d != "" && err != nil {
+			return ErrInvalidSchedule
+		}
+	}
+
+	if s.From != "" && s.To != "" && s.To < s.From {
+		return ErrInvalidSchedule
+	}
+
+	return nil
+}
+
+func (s Schedule) onDay(d time.Weekday) bool {
+	if len(s.Days) == 0 {
+		return true
+	}
+
+	for _, day := range s.Days {
+		if day == d {
+			return true
+		}
+	}
+
+	return false
+}
+
+// inDates reports whether the date of t is within the schedule's dates.
+func (s Schedule) inDates(t time.Time) bool {
+	date := t.Format(scheduleDateLayout)
+	return (s.From == "" || date >= s.From) && (s.To == "" || date <= s.To)
+}
+
+// activeAt reports whether t, in the restaurant's time zone, falls within
+// the schedule.
+func (s Schedule) activeAt(t time.Time) bool {
+	if s.Start == "" {
+		return s.inDates(t) && s.onDay(t.Weekday())
+	}
+
+	m := minutesOf(t)
+	start, _ := clockMinutes(s.Start)
+	end, _ := clockMinutes(s.End)
+	if start < end {
+		return s.inDates(t) && s.onDay(t.Weekday()) && m >= start && m < end
+	}
+
+	// Overnight, e.g. 22:00 to 02:00 belongs to the day it started on, for
+	// its days and dates alike.
+	started := t.AddDate(0, 0, -1)
+	return (s.inDates(t) && s.onDay(t.Weekday()) && m >= start) ||
+		(s.inDates(started) && s.onDay(started.Weekday()) && m < end)
+}
+
+func daysMask(dd []time.Weekday) int {
+	mask := 0
+	for _, d := range dd {
+		mask |= 1 << uint(d)
+	}
+
+	return mask
+}
+
+func maskDays(mask int) []time.Weekday {
+	dd := make([]time.Weekday, 0)
+	for d := time.Sunday; d <= time.Saturday; d++ {
+		if mask&(1<<uint(d)) != 0 {
+			dd = append(dd, d)
+		}
+	}
+
+	return dd
+}
+
+// restaurantNow is the current time in the restaurant's time zone.
+func restaurantNow(ctx context.Context, q queryer, rid string) (time.Time, error) {
+	var tz string
+	err := q.QueryRowContext(ctx, "SELECT timezone FROM restaurant WHERE id = $1", rid).Scan(&tz)
+	if err == sql.ErrNoRows {
+		return time.Time{}, ErrRestaurantNotFound
+	}
+
+	if err != nil {
+		return time.Time{}, fmt.Errorf("could not query restaurant time zone: %v", err)
+	}
+
+	loc, err := time.LoadLocation(tz)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("could not load restaurant time zone: %v", err)
+	}
+
+	return time.Now().In(loc), nil
+}
+
+// offSchedule finds the restaurant's categories and items that have
+// schedules none of which is active now.
+func offSchedule(ctx context.Context, q queryer, rid string) (map[int64]bool, map[int64]bool, error) {
+	now, err := restaurantNow(ctx, q, rid)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	query := `
+		SELECT COALESCE(category_id, 0), COALESCE(item_id, 0), days, start_time, end_time, from_date, to_date
+		FROM schedule
+		WHERE restaurant_id = $1`
+	rows, err := q.QueryContext(ctx, query, rid)
+	if err != nil {
+		return nil, nil, fmt.Errorf("could not query schedules: %v", err)
+	}
+
+	defer rows.Close()
+	categories := make(map[int64]bool)
+	items := make(map[int64]bool)
+	for rows.Next() {
+		var cid, iid int64
+		var days int
+		var s Schedule
+		if err = rows.Scan(&cid, &iid, &days, &s.Start, &s.End, &s.From, &s.To); err != nil {
+			return nil, nil, fmt.Errorf("could not scan schedule: %v", err)
+		}
+
+		s.Days = maskDays(days)
+		off, id := items, iid
+		if cid != 0 {
+			off, id = categories, cid
+		}
+
+		// Off until any of its schedules is active.
+		if _, seen := off[id]; !seen {
+			off[id] = true
+		}
+
+		if s.activeAt(now) {
+			off[id] = false
+		}
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, nil, fmt.Errorf("could not iterate schedules: %v", err)
+	}
+
+	return categories, items, nil
+}
+
+func (s *Service) getSchedules(ctx context.Context, rid, column string, id int64) ([]Schedule, error) {
+	uid, auth := ctx.Value(KeyAuthFoodProviderID).(int64)
+	if !auth {
+		return nil, ErrUnauthenticated
+	}
+
+	if !rxUUID.MatchString(rid) {
+		return nil, ErrRestaurantNotFound
+	}
+
+	if _, err := s.checkPermission(ctx, Waiter, uid, rid); err != nil {
+		return nil, err
+	}
+
+	query := `
+		SELECT days, start_time, end_time, from_date, to_date
+		FROM schedule
+		WHERE restaurant_id = $1 AND ` + column + ` = $2
+		ORDER BY id`
+	rows, err := s.db.QueryContext(ctx, query, rid, id)
+	if err != nil {
+		return nil, fmt.Errorf("could not query schedules: %v", err)
+	}
+
+	defer rows.Close()
+	ss := make([]Schedule, 0)
+	for rows.Next() {
+		var days int
+		var sc Schedule
+		if err = rows.Scan(&days, &sc.Start, &sc.End, &sc.From, &sc.To); err != nil {
+			return nil, fmt.Errorf("could not scan schedule: %v", err)
+		}
+
+		sc.Days = maskDays(days)
+		ss = append(ss, sc)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("could not iterate schedules: %v", err)
+	}
+
+	return ss, nil
+}
+
+func (s *Service) setSchedules(ctx context.Context, rid, column string, id int64, ss []Schedule) error {
+	uid, auth := ctx.Value(KeyAuthFoodProviderID).(int64)
+	if !auth {
+		return ErrUnauthenticated
+	}
+
+	if !rxUUID.MatchString(rid) {
+		return ErrRestaurantNotFound
+	}
+
+	for _, sc := range ss {
+		if err := sc.validate(); err != nil {
+			return err
+		}
+	}
+
+	if _, err := s.checkPermission(ctx, Manager, uid, rid); err != nil {
+		return err
+	}
+
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("could not begin tx: %v", err)
+	}
+	defer func() { _ = tx.Rollback() }()
+
+	var exists bool
+	query := "SELECT EXISTS (SELECT 1 FROM item WHERE id = $1 AND restaurant_id = $2 AND archived = false)"
+	notFound := ErrItemNotFound
+	if column == "category_id" {
+		query = "SELECT EXISTS (SELECT 1 FROM category WHERE id = $1 AND restaurant = $2 AND archived = false)"
+		notFound = ErrCategoryNotFound
+	}
+
+	if err = tx.QueryRowContext(ctx, query, id, rid).Scan(&exists); err != nil {
+		return fmt.Errorf("could not query schedule owner: %v", err)
+	}
+
+	if !exists {
+		return notFound
+	}
+
+	query = "DELETE FROM schedule WHERE restaurant_id = $1 AND " + column + " = $2"
+	if _, err = tx.ExecContext(ctx, query, rid, id); err != nil {
+		return fmt.Errorf("could not clear schedules: %v", err)
+	}
+
+	query = "INSERT INTO schedule (restaurant_id, " + column + `, days, start_time, end_time, from_date, to_date)
+		VALUES ($1, $2, $3, $4, $5, $6, $7)`
+	for _, sc := range ss {
+		_, err = tx.ExecContext(ctx, query, rid, id, daysMask(sc.Days), sc.Start, sc.End, sc.From, sc.To)
+		if err != nil {
+			return fmt.Errorf("could not create schedule: %v", err)
+		}
+	}
+
+	if err = tx.Commit(); err != nil {
+		return fmt.Errorf("could not set schedules: could not commit transaction: %v", err)
+	}
+
+	return nil
+}
+
+// GetCategorySchedules of a menu category.
+func (s *Service) GetCategorySchedules(ctx context.Context, rid string, cid int64) ([]Schedule, error) {
+	return s.getSchedules(ctx, rid, "category_id", cid)
+}
+
+// SetCategorySchedules replaces the schedules of a menu category. Without
+// schedules the category follows its availability flag only.
+func (s *Service) SetCategorySchedules(ctx context.Context, rid string, cid int64, ss []Schedule) error {
+	return s.setSchedules(ctx, rid, "category_id", cid, ss)
+}
+
+// GetItemSchedules of a menu item.
+func (s *Service) GetItemSchedules(ctx context.Context, rid string, iid int64) ([]Schedule, error) {
+	return s.getSchedules(ctx, rid, "item_id", iid)
+}
+
+// SetItemSchedules replaces the schedules of a menu item. Without schedules
+// the item follows its availability flag only.
+func (s *Service) SetItemSchedules(ctx context.Context, rid string, iid int64, ss []Schedule) error {
+	return s.setSchedules(ctx, rid, "item_id", iid, ss)
+}
@@ -0,0 +1,81 @@
+package service
+
+import (
+	"testing"
+	"time"
+)
+
+func TestScheduleActiveAt(t *testing.T) {
+	at := func(s string) time.Time {
+		tm, err := time.Parse("2006-01-02 15:04", s)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return tm
+	}
+
+	breakfast := Schedule{Start: "07:00", End: "11:00"}
+	weekendNights := Schedule{Days: []time.Weekday{time.Friday, time.Saturday}, Start: "22:00", End: "02:00"}
+	ramadan := Schedule{From: "2026-02-18", To: "2026-03-19"}
+	unpadded := Schedule{Start: "9:00", End: "17:00"}
+	allDay := Schedule{Start: "00:00", End: "24:00"}
+	iftarNights := Schedule{Start: "22:00", End: "02:00", From: "2026-02-18", To: "2026-03-19"}
+
+	var tt = []struct {
+		Label    string
+		Schedule Schedule
+		Time     time.Time
+		Active   bool
+	}{
+		{Label: "Test should be active within the time range", Schedule: breakfast, Time: at("2026-10-19 08:30"), Active: true},
+		{Label: "Test should end at the end time", Schedule: breakfast, Time: at("2026-10-19 11:00"), Active: false},
+		{Label: "Test should be inactive before the start time", Schedule: breakfast, Time: at("2026-10-19 06:59"), Active: false},
+		{Label: "Test should be active on a listed day", Schedule: weekendNights, Time: at("2026-10-23 23:00"), Active: true},
+		{Label: "Test should run past midnight into the next day", Schedule: weekendNights, Time: at("2026-10-25 01:30"), Active: true},
+		{Label: "Test should be inactive after midnight of an unlisted day", Schedule: weekendNights, Time: at("2026-10-23 01:30"), Active: false},
+		{Label: "Test should be inactive on an unlisted day", Schedule: weekendNights, Time: at("2026-10-19 23:00"), Active: false},
+		{Label: "Test should be active within the date range", Schedule: ramadan, Time: at("2026-03-19 20:00"), Active: true},
+		{Label: "Test should be inactive outside the date range", Schedule: ramadan, Time: at("2026-03-20 20:00"), Active: false},
+		{Label: "Test should be active within unpadded times", Schedule: unpadded, Time: at("2026-10-19 10:00"), Active: true},
+		{Label: "Test should be inactive after unpadded times", Schedule: unpadded, Time: at("2026-10-19 18:00"), Active: false},
+		{Label: "Test should be active all day until midnight", Schedule: allDay, Time: at("2026-10-19 23:59"), Active: true},
+		{Label: "Test should be active past midnight of the last date", Schedule: iftarNights, Time: at("2026-03-20 01:00"), Active: true},
+		{Label: "Test should be inactive past midnight before the first date", Schedule: iftarNights, Time: at("2026-02-18 01:00"), Active: false},
+		{Label: "Test should be active on the first night", Schedule: iftarNights, Time: at("2026-02-18 23:00"), Active: true},
+	}
+
+	for _, test := range tt {
+		t.Run(test.Label, func(t *testing.T) {
+			if active := test.Schedule.activeAt(test.Time); active != test.Active {
+				t.Error("Got:", active, "| Want:", test.Active)
+			}
+		})
+	}
+}
+
+func TestScheduleValidate(t *testing.T) {
+	var tt = []struct {
+		Label    string
+		Schedule Schedule
+		Err      error
+	}{
+		{Label: "Test should accept an empty schedule", Schedule: Schedule{}},
+		{Label: "Test should reject a start without an end", Schedule: Schedule{Start: "07:00"}, Err: ErrInvalidSchedule},
+		{Label: "Test should reject a malformed time", Schedule: Schedule{Start: "7am", End: "11:00"}, Err: ErrInvalidSchedule},
+		{Label: "Test should reject an unknown day", Schedule: Schedule{Days: []time.Weekday{7}}, Err: ErrInvalidSchedule},
+		{Label: "Test should accept an unpadded time", Schedule: Schedule{Start: "9:00", End: "17:00"}},
+		{Label: "Test should accept a day ending at 24:00", Schedule: Schedule{Start: "00:00", End: "24:00"}},
+		{Label: "Test should reject a start at 24:00", Schedule: Schedule{Start: "24:00", End: "02:00"}, Err: ErrInvalidSchedule},
+		{Label: "Test should reject minutes past the hour", Schedule: Schedule{Start: "07:60", End: "11:00"}, Err: ErrInvalidSchedule},
+		{Label: "Test should reject an empty range", Schedule: Schedule{Start: "7:00", End: "07:00"}, Err: ErrInvalidSchedule},
+		{Label: "Test should reject a reversed date range", Schedule: Schedule{From: "2026-03-19", To: "2026-02-18"}, Err: ErrInvalidSchedule},
+	}
+
+	for _, test := range tt {
+		t.Run(test.Label, func(t *testing.T) {
+			if err := test.Schedule.validate(); err != test.Err {
+				t.Error("Got:", err, "| Want:", test.Err)
+			}
+		})
+	}
+}
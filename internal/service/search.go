@@ -0,0 +1,437 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+	"unicode"
+)
+
+// Kinds of search results.
+const (
+	SearchRestaurant = "restaurant"
+	SearchItem       = "item"
+)
+
+// Weights of the indexed fields.
+const (
+	weightItemName        = 3
+	weightCategory        = 1.5
+	weightDescription     = 1
+	weightRestaurantTitle = 3
+	weightAbout           = 1
+	weightItemRestaurant  = 0.5
+)
+
+// SearchQuery of GET /api/search. Zero prices don't restrict; with a price
+// range only items are found.
+type SearchQuery struct {
+	Text     string
+	City     string
+	Area     string
+	MinPrice float64
+	MaxPrice float64
+	Limit    int
+}
+
+// SearchResult is a restaurant or a menu item matching a search.
+type SearchResult struct {
+	Kind            string  `json:"kind"`
+	RestaurantId    string  `json:"restaurant_id"`
+	RestaurantTitle string  `json:"restaurant_title"`
+	ItemId          int64   `json:"item_id,omitempty"`
+	Name            string  `json:"name"`
+	Description     string  `json:"description,omitempty"`
+	Category        string  `json:"category,omitempty"`
+	Price           float64 `json:"price,omitempty"`
+	City            string  `json:"city"`
+	Area            string  `json:"area"`
+	Score           float64 `json:"score"`
+}
+
+type searchDoc struct {
+	key    string
+	result SearchResult
+	terms  map[string]float64
+}
+
+// searchIndex is an in-process inverted index of the public menus of active
+// restaurants. It's replaced a restaurant at a time as menus change.
+type searchIndex struct {
+	mu          sync.RWMutex
+	docs        map[string]*searchDoc
+	restaurants map[string][]string
+	postings    map[string]map[string]float64
+}
+
+func newSearchIndex() *searchIndex {
+	return &searchIndex{
+		docs:        make(map[string]*searchDoc),
+		restaurants: make(map[string][]string),
+		postings:    make(map[string]map[string]float64),
+	}
+}
+
+// tokenize lower cases the text and splits it into words. Marks are kept so
+// Bangla words stay whole.
+func tokenize(text string) []string {
+	return strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r) && !unicode.IsMark(r)
+	})
+}
+
+// addTerms of the text to the doc with the field's weight.
+func (d *searchDoc) addTerms(text string, weight float64) {
+	if d.terms == nil {
+		d.terms = make(map[string]float64)
+	}
+
+	for _, t := range tokenize(text) {
+		d.terms[t] += weight
+	}
+}
+
+// editDistance between two words, in runes.
+func editDistance(a, b string) int {
+	ra, rb := []rune(a), []rune(b)
+	prev := make([]int, len(rb)+1)
+	cur := make([]int, len(rb)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+
+	for i := 1; i <= len(ra); i++ {
+		cur[0] = i
+		for j := 1; j <= len(rb); j++ {
+			cost := 1
+			if ra[i-1] == rb[j-1] {
+				cost = 0
+			}
+
+			cur[j] = min3(prev[j]+1, cur[j-1]+1, prev[j-1]+cost)
+		}
+		prev, cur = cur, prev
+	}
+
+	return prev[len(rb)]
+}
+
+func min3(a, b, c int) int {
+	if b < a {
+		a = b
+	}
+	if c < a {
+		a = c
+	}
+	return a
+}
+
+// termMatch weighs how well an indexed term matches a query word: exactly,
+// as a prefix, or within the typos the word's length allows.
+func termMatch(word, term string) float64 {
+	if word == term {
+		return 1
+	}
+
+	n := len([]rune(word))
+	if n >= 3 && strings.HasPrefix(term, word) {
+		return 0.8
+	}
+
+	typos := 0
+	switch {
+	case n >= 8:
+		typos = 2
+	case n >= 4:
+		typos = 1
+	}
+
+	if typos == 0 {
+		return 0
+	}
+
+	if d := len([]rune(term)) - n; d > typos || -d > typos {
+		return 0
+	}
+
+	switch editDistance(word, term) {
+	case 0:
+		return 1
+	case 1:
+		return 0.7
+	case 2:
+		if typos == 2 {
+			return 0.5
+		}
+	}
+
+	return 0
+}
+
+// replace the docs of the restaurant.
+func (x *searchIndex) replace(rid string, docs []searchDoc) {
+	x.mu.Lock()
+	defer x.mu.Unlock()
+
+	for _, key := range x.restaurants[rid] {
+		for t := range x.docs[key].terms {
+			delete(x.postings[t], key)
+			if len(x.postings[t]) == 0 {
+				delete(x.postings, t)
+			}
+		}
+		delete(x.docs, key)
+	}
+
+	keys := make([]string, 0, len(docs))
+	for i := range docs {
+		d := &docs[i]
+		x.docs[d.key] = d
+		keys = append(keys, d.key)
+		for t, w := range d.terms {
+			if _, ok := x.postings[t]; !ok {
+				x.postings[t] = make(map[string]float64)
+			}
+			x.postings[t][d.key] = w
+		}
+	}
+
+	if len(keys) == 0 {
+		delete(x.restaurants, rid)
+		return
+	}
+
+	x.restaurants[rid] = keys
+}
+
+// search the index. Every word of the query must match, ranked by the
+// weight of the fields they match in.
+func (x *searchIndex) search(q SearchQuery) []SearchResult {
+	rr := make([]SearchResult, 0)
+	words := tokenize(q.Text)
+	if len(words) == 0 {
+		return rr
+	}
+
+	x.mu.RLock()
+	defer x.mu.RUnlock()
+
+	var scores map[string]float64
+	for i, w := range words {
+		best := make(map[string]float64)
+		for term, docs := range x.postings {
+			m := termMatch(w, term)
+			if m == 0 {
+				continue
+			}
+
+			for key, weight := range docs {
+				if s := m * weight; s > best[key] {
+					best[key] = s
+				}
+			}
+		}
+
+		if i == 0 {
+			scores = best
+			continue
+		}
+
+		for key := range scores {
+			if s, ok := best[key]; ok {
+				scores[key] += s
+			} else {
+				delete(scores, key)
+			}
+		}
+	}
+
+	priced := q.MinPrice > 0 || q.MaxPrice > 0
+	for key, score := range scores {
+		r := x.docs[key].result
+		if q.City != "" && !strings.EqualFold(r.City, q.City) {
+			continue
+		}
+
+		if q.Area != "" && !strings.EqualFold(r.Area, q.Area) {
+			continue
+		}
+
+		if priced && (r.Kind != SearchItem || r.Price < q.MinPrice || (q.MaxPrice > 0 && r.Price > q.MaxPrice)) {
+			continue
+		}
+
+		r.Score = score
+		rr = append(rr, r)
+	}
+
+	sort.Slice(rr, func(i, j int) bool {
+		if rr[i].Score != rr[j].Score {
+			return rr[i].Score > rr[j].Score
+		}
+		if rr[i].Kind != rr[j].Kind {
+			return rr[i].Kind == SearchRestaurant
+		}
+		return rr[i].Name < rr[j].Name
+	})
+
+	if limit := normalizePageSize(q.Limit); len(rr) > limit {
+		rr = rr[:limit]
+	}
+
+	return rr
+}
+
+// searchDocs of a restaurant: the restaurant itself and its available items.
+// Inactive restaurants have none. Items are indexed at their effective price,
+// with any due scheduled change that isn't applied yet taken into account.
+func searchDocs(ctx context.Context, q queryer, rid string) ([]searchDoc, error) {
+	docs := make([]searchDoc, 0)
+	var r SearchResult
+	var about string
+	query := "SELECT id, title, about, city, area FROM restaurant WHERE id = $1 AND active = true"
+	err := q.QueryRowContext(ctx, query, rid).Scan(&r.RestaurantId, &r.RestaurantTitle, &about, &r.City, &r.Area)
+	if err == sql.ErrNoRows {
+		return docs, nil
+	}
+
+	if err != nil {
+		return nil, fmt.Errorf("could not query restaurant: %v", err)
+	}
+
+	d := searchDoc{key: rid, result: r}
+	d.result.Kind = SearchRestaurant
+	d.result.Name = r.RestaurantTitle
+	d.result.Description = about
+	d.addTerms(r.RestaurantTitle, weightRestaurantTitle)
+	d.addTerms(about, weightAbout)
+	docs = append(docs, d)
+
+	query = `
+		SELECT i.id, i.name, i.description, COALESCE((
+				SELECT p.price FROM item_price p
+				WHERE p.item_id = i.id AND p.applied = false AND p.effective_at <= now()
+				ORDER BY p.effective_at DESC, p.id DESC LIMIT 1
+			), i.price), c.label
+		FROM item i INNER JOIN category c ON i.category_id = c.id
+		WHERE i.restaurant_id = $1 AND i.archived = false AND i.availability = true AND c.availability = true`
+	rows, err := q.QueryContext(ctx, query, rid)
+	if err != nil {
+		return nil, fmt.Errorf("could not query items: %v", err)
+	}
+
+	defer rows.Close()
+	for rows.Next() {
+		i := searchDoc{result: r}
+		i.result.Kind = SearchItem
+		if err = rows.Scan(&i.result.ItemId, &i.result.Name, &i.result.Description, &i.result.Price,
+			&i.result.Category); err != nil {
+			return nil, fmt.Errorf("could not scan item: %v", err)
+		}
+
+		i.key = rid + "/" + strconv.FormatInt(i.result.ItemId, 10)
+		i.addTerms(i.result.Name, weightItemName)
+		i.addTerms(i.result.Category, weightCategory)
+		i.addTerms(i.result.Description, weightDescription)
+		i.addTerms(r.RestaurantTitle, weightItemRestaurant)
+		docs = append(docs, i)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("could not iterate items: %v", err)
+	}
+
+	return docs, nil
+}
+
+// indexRestaurant replaces the restaurant's docs in the search index after a
+// change to its menu or profile. The change is already stored, so failures
+// are only logged; the next refresh catches up.
+func (s *Service) indexRestaurant(ctx context.Context, rid string) {
+	docs, err := searchDocs(ctx, s.db, rid)
+	if err != nil {
+		s.logger.WithError(err).WithField("restaurant", rid).Error("could not index restaurant")
+		return
+	}
+
+	s.search.replace(rid, docs)
+}
+
+// IndexSearch builds the search index from every active restaurant. Texts
+// are indexed in the default locale.
+func (s *Service) IndexSearch(ctx context.Context) error {
+	rows, err := s.db.QueryContext(ctx, "SELECT id FROM restaurant WHERE active = true")
+	if err != nil {
+		return fmt.Errorf("could not query restaurants: %v", err)
+	}
+
+	defer rows.Close()
+	ids := make([]string, 0)
+	for rows.Next() {
+		var id string
+		if err = rows.Scan(&id); err != nil {
+			return fmt.Errorf("could not scan restaurant: %v", err)
+		}
+
+		ids = append(ids, id)
+	}
+
+	if err = rows.Err(); err != nil {
+		return fmt.Errorf("could not iterate restaurants: %v", err)
+	}
+
+	// Restaurants no longer active drop out too.
+	s.search.mu.RLock()
+	for rid := range s.search.restaurants {
+		if !contains(ids, rid) {
+			ids = append(ids, rid)
+		}
+	}
+	s.search.mu.RUnlock()
+
+	for _, rid := range ids {
+		docs, err := searchDocs(ctx, s.db, rid)
+		if err != nil {
+			return err
+		}
+
+		s.search.replace(rid, docs)
+	}
+
+	return nil
+}
+
+// RefreshSearchIndex rebuilds the search index periodically, catching up
+// with changes made outside the service, like restaurant activation.
+func (s *Service) RefreshSearchIndex(ctx context.Context, every time.Duration) {
+	t := time.NewTicker(every)
+	defer t.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-t.C:
+			if err := s.IndexSearch(ctx); err != nil {
+				s.logger.WithError(err).Error("could not refresh search index")
+			}
+		}
+	}
+}
+
+// Search restaurants and menu items of active restaurants.
+func (s *Service) Search(ctx context.Context, q SearchQuery) ([]SearchResult, error) {
+	if strings.TrimSpace(q.Text) == "" {
+		return nil, ErrEmptyValue
+	}
+
+	if q.MinPrice < 0 || q.MaxPrice < 0 || (q.MaxPrice > 0 && q.MaxPrice < q.MinPrice) {
+		return nil, ErrInvalidPrice
+	}
+
+	return s.search.search(q), nil
+}
@@ -0,0 +1,85 @@
+package service
+
+import "testing"
+
+func TestTermMatch(t *testing.T) {
+	var tt = []struct {
+		Label string
+		Word  string
+		Term  string
+		Want  float64
+	}{
+		{Label: "Test should match exactly", Word: "kacchi", Term: "kacchi", Want: 1},
+		{Label: "Test should match a prefix", Word: "kac", Term: "kacchi", Want: 0.8},
+		{Label: "Test should not match short prefixes", Word: "ka", Term: "kacchi", Want: 0},
+		{Label: "Test should tolerate a typo", Word: "kachi", Term: "kacchi", Want: 0.7},
+		{Label: "Test should not tolerate typos in short words", Word: "dal", Term: "dol", Want: 0},
+		{Label: "Test should tolerate two typos in long words", Word: "biriyanni", Term: "biryani", Want: 0.5},
+		{Label: "Test should not tolerate two typos in shorter words", Word: "kachhe", Term: "kacchi", Want: 0},
+		{Label: "Test should match bangla words", Word: "কাচ্চি", Term: "কাচ্চি", Want: 1},
+	}
+
+	for _, test := range tt {
+		t.Run(test.Label, func(t *testing.T) {
+			if got := termMatch(test.Word, test.Term); got != test.Want {
+				t.Error("Got:", got, "| Want:", test.Want)
+			}
+		})
+	}
+}
+
+func TestSearchIndex(t *testing.T) {
+	x := newSearchIndex()
+	doc := func(key, kind, name, category string, price float64, city string) searchDoc {
+		d := searchDoc{key: key, result: SearchResult{Kind: kind, RestaurantId: "r", Name: name, Price: price, City: city}}
+		d.addTerms(name, weightItemName)
+		d.addTerms(category, weightCategory)
+		return d
+	}
+
+	x.replace("r1", []searchDoc{
+		doc("r1", SearchRestaurant, "Kacchi Bhai", "", 0, "Dhaka"),
+		doc("r1/1", SearchItem, "Mutton Kacchi", "Biryani", 450, "Dhaka"),
+		doc("r1/2", SearchItem, "Borhani", "Drinks", 80, "Dhaka"),
+	})
+	x.replace("r2", []searchDoc{
+		doc("r2/3", SearchItem, "Chicken Kacchi", "Biryani", 300, "Chittagong"),
+	})
+
+	var tt = []struct {
+		Label string
+		Query SearchQuery
+		Want  []string
+	}{
+		{Label: "Test should rank and find across restaurants", Query: SearchQuery{Text: "kacchi"},
+			Want: []string{"Kacchi Bhai", "Chicken Kacchi", "Mutton Kacchi"}},
+		{Label: "Test should require every word", Query: SearchQuery{Text: "mutton kacchi"}, Want: []string{"Mutton Kacchi"}},
+		{Label: "Test should tolerate typos", Query: SearchQuery{Text: "borhany"}, Want: []string{"Borhani"}},
+		{Label: "Test should filter by city", Query: SearchQuery{Text: "kacchi", City: "chittagong"}, Want: []string{"Chicken Kacchi"}},
+		{Label: "Test should filter by price", Query: SearchQuery{Text: "biryani", MaxPrice: 400}, Want: []string{"Chicken Kacchi"}},
+		{Label: "Test should find nothing without words", Query: SearchQuery{Text: " - "}, Want: []string{}},
+	}
+
+	for _, test := range tt {
+		t.Run(test.Label, func(t *testing.T) {
+			rr := x.search(test.Query)
+			names := make([]string, 0)
+			for _, r := range rr {
+				names = append(names, r.Name)
+			}
+			if len(names) != len(test.Want) {
+				t.Fatal("Got:", names, "| Want:", test.Want)
+			}
+			for i := range names {
+				if names[i] != test.Want[i] {
+					t.Error("Got:", names, "| Want:", test.Want)
+				}
+			}
+		})
+	}
+
+	x.replace("r1", nil)
+	if rr := x.search(SearchQuery{Text: "kacchi"}); len(rr) != 1 {
+		t.Error("Got:", len(rr), "| Want:", 1)
+	}
+}
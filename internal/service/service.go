@@ -6,6 +6,7 @@ import (
 	"sync"
 
 	"github.com/hako/branca"
+	"github.com/sirupsen/logrus"
 )
 
 // contains the core logic. You can use it to back a REST, GraphQL or RPC API :D
@@ -16,8 +17,25 @@ type Service struct {
 	aCodec       *branca.Branca
 	origin       url.URL
 	orderClients sync.Map
+	search       *searchIndex
+	linkKey      []byte
+	fonts        menuFonts
+	logger       logrus.FieldLogger
 }
 
 func New(db *sql.DB, userCodec, foodProviderCodec, ambassadorCodec *branca.Branca, origin url.URL) *Service {
-	return &Service{db:db, uCodec: userCodec, fpCodec:foodProviderCodec, aCodec:ambassadorCodec, origin:origin}
+	return &Service{db:db, uCodec: userCodec, fpCodec:foodProviderCodec, aCodec:ambassadorCodec, origin:origin,
+		search: newSearchIndex(), fonts: defaultMenuFonts,
+		logger: logrus.StandardLogger()}
+}
+
+// SetLogger sets where failures of background work, like keeping the
+// search index up to date, are logged.
+func (s *Service) SetLogger(logger logrus.FieldLogger) {
+	s.logger = logger
+}
+
+// Logger is where failures after a response has started are logged.
+func (s *Service) Logger() logrus.FieldLogger {
+	return s.logger
 }
@@ -0,0 +1,444 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"math"
+	"strings"
+	"time"
+)
+
+// Kinds of cash drawer entries recorded during a shift.
+const (
+	EntryPayment  = "payment"
+	EntryPayout   = "payout"
+	EntryDiscount = "discount"
+	EntryVoid     = "void"
+)
+
+var paymentMethods = map[string]bool{
+	"cash":   true,
+	"card":   true,
+	"bkash":  true,
+	"rocket": true,
+}
+
+var (
+	// ErrShiftNotFound denotes a not found shift.
+	ErrShiftNotFound = errors.New("shift not found")
+	// ErrShiftAlreadyOpen denotes the restaurant already has an open shift.
+	ErrShiftAlreadyOpen = errors.New("shift already open")
+	// ErrShiftClosed denotes an entry recorded on a closed shift.
+	ErrShiftClosed = errors.New("shift closed")
+	// ErrShiftNotClosed denotes a sign off of a shift that is still open.
+	ErrShiftNotClosed = errors.New("shift not closed")
+	// ErrShiftSignedOff denotes a change to a signed off shift.
+	ErrShiftSignedOff = errors.New("shift signed off")
+	// ErrInvalidAmount denotes a negative or zero amount of money.
+	ErrInvalidAmount = errors.New("invalid amount")
+	// ErrInvalidEntry denotes an unknown entry kind or payment method.
+	ErrInvalidEntry = errors.New("invalid entry")
+)
+
+type queryer interface {
+	QueryContext(ctx context.Context, query string, args ...interface{}) (*sql.Rows, error)
+	QueryRowContext(ctx context.Context, query string, args ...interface{}) *sql.Row
+}
+
+// ZReport sums up a shift. While the shift is open it is a running total,
+// once closed it compares the expected cash in the drawer with the counted
+// cash, and once signed off it can no longer change.
+type ZReport struct {
+	ShiftId      int64              `json:"shift_id"`
+	OpenedBy     int64              `json:"opened_by"`
+	OpenedAt     time.Time          `json:"opened_at"`
+	ClosedBy     *int64             `json:"closed_by,omitempty"`
+	ClosedAt     *time.Time         `json:"closed_at,omitempty"`
+	SignedOffBy  *int64             `json:"signed_off_by,omitempty"`
+	SignedOffAt  *time.Time         `json:"signed_off_at,omitempty"`
+	OpeningFloat float64            `json:"opening_float"`
+	Payments     map[string]float64 `json:"payments"`
+	Sales        float64            `json:"sales"`
+	VAT          float64            `json:"vat"`
+	Discounts    float64            `json:"discounts"`
+	Voids        float64            `json:"voids"`
+	VoidCount    int                `json:"void_count"`
+	Payouts      float64            `json:"payouts"`
+	ExpectedCash float64            `json:"expected_cash"`
+	CountedCash  *float64           `json:"counted_cash,omitempty"`
+	Difference   *float64           `json:"difference,omitempty"`
+}
+
+func (z *ZReport) add(kind, method string, amount, vat float64) {
+	switch kind {
+	case EntryPayment:
+		z.Payments[method] = roundMoney(z.Payments[method] + amount)
+		z.Sales = roundMoney(z.Sales + amount)
+		z.VAT = roundMoney(z.VAT + vat)
+	case EntryPayout:
+		z.Payouts = roundMoney(z.Payouts + amount)
+	case EntryDiscount:
+		z.Discounts = roundMoney(z.Discounts + amount)
+	case EntryVoid:
+		z.Voids = roundMoney(z.Voids + amount)
+		z.VoidCount++
+	}
+}
+
+// settle calculates the cash expected in the drawer and how far off the
+// counted cash is.
+func (z *ZReport) settle() {
+	z.ExpectedCash = roundMoney(z.OpeningFloat + z.Payments["cash"] - z.Payouts)
+	if z.CountedCash != nil {
+		d := roundMoney(*z.CountedCash - z.ExpectedCash)
+		z.Difference = &d
+	}
+}
+
+func roundMoney(f float64) float64 {
+	return math.Round(f*100) / 100
+}
+
+// OpenShift starts a new shift with the given float in the cash drawer.
+// A restaurant can only have one open shift at a time.
+func (s *Service) OpenShift(ctx context.Context, rid string, float float64) (*ZReport, error) {
+	uid, auth := ctx.Value(KeyAuthFoodProviderID).(int64)
+	if !auth {
+		return nil, ErrUnauthenticated
+	}
+
+	if !rxUUID.MatchString(rid) {
+		return nil, ErrInvalidRestaurantId
+	}
+
+	if float < 0 {
+		return nil, ErrInvalidAmount
+	}
+
+	if _, err := s.checkPermission(ctx, Waiter, uid, rid); err != nil {
+		return nil, err
+	}
+
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, fmt.Errorf("could not begin tx: %v", err)
+	}
+	defer func() { _ = tx.Rollback() }()
+
+	var open bool
+	query := "SELECT EXISTS (SELECT 1 FROM shift WHERE restaurant_id = $1 AND closed_at IS NULL)"
+	if err = tx.QueryRowContext(ctx, query, rid).Scan(&open); err != nil {
+		return nil, fmt.Errorf("could not query open shift: %v", err)
+	}
+
+	if open {
+		return nil, ErrShiftAlreadyOpen
+	}
+
+	// The check above is for a clear error; the partial unique index keeps
+	// two shifts opened at once from both getting in.
+	var sid int64
+	query = "INSERT INTO shift (restaurant_id, opened_by, opening_float) VALUES ($1, $2, $3) RETURNING id"
+	err = tx.QueryRowContext(ctx, query, rid, uid, float).Scan(&sid)
+	if isUniqueViolation(err) {
+		return nil, ErrShiftAlreadyOpen
+	}
+
+	if err != nil {
+		return nil, fmt.Errorf("could not open shift: %v", err)
+	}
+
+	z, err := zReport(ctx, tx, rid, sid)
+	if err != nil {
+		return nil, err
+	}
+
+	if err = tx.Commit(); err != nil {
+		return nil, fmt.Errorf("could not open shift: could not commit transaction: %v", err)
+	}
+
+	return z, nil
+}
+
+// GetCurrentShift returns the running report of the restaurant's open shift.
+func (s *Service) GetCurrentShift(ctx context.Context, rid string) (*ZReport, error) {
+	uid, auth := ctx.Value(KeyAuthFoodProviderID).(int64)
+	if !auth {
+		return nil, ErrUnauthenticated
+	}
+
+	if !rxUUID.MatchString(rid) {
+		return nil, ErrInvalidRestaurantId
+	}
+
+	if _, err := s.checkPermission(ctx, Waiter, uid, rid); err != nil {
+		return nil, err
+	}
+
+	var sid int64
+	query := "SELECT id FROM shift WHERE restaurant_id = $1 AND closed_at IS NULL"
+	err := s.db.QueryRowContext(ctx, query, rid).Scan(&sid)
+	if err == sql.ErrNoRows {
+		return nil, ErrShiftNotFound
+	}
+
+	if err != nil {
+		return nil, fmt.Errorf("could not query open shift: %v", err)
+	}
+
+	return zReport(ctx, s.db, rid, sid)
+}
+
+// GetZReport of any shift of the restaurant.
+func (s *Service) GetZReport(ctx context.Context, rid string, sid int64) (*ZReport, error) {
+	uid, auth := ctx.Value(KeyAuthFoodProviderID).(int64)
+	if !auth {
+		return nil, ErrUnauthenticated
+	}
+
+	if !rxUUID.MatchString(rid) {
+		return nil, ErrInvalidRestaurantId
+	}
+
+	if _, err := s.checkPermission(ctx, Waiter, uid, rid); err != nil {
+		return nil, err
+	}
+
+	return zReport(ctx, s.db, rid, sid)
+}
+
+// RecordShiftEntry adds a payment, payout, discount or void to an open
+// shift. Payouts always come out of the cash drawer.
+func (s *Service) RecordShiftEntry(ctx context.Context, rid string, sid int64, kind, method string, amount, vat float64, oid int64, note string) error {
+	uid, auth := ctx.Value(KeyAuthFoodProviderID).(int64)
+	if !auth {
+		return ErrUnauthenticated
+	}
+
+	if !rxUUID.MatchString(rid) {
+		return ErrInvalidRestaurantId
+	}
+
+	kind = strings.ToLower(strings.TrimSpace(kind))
+	method = strings.ToLower(strings.TrimSpace(method))
+	note = strings.TrimSpace(note)
+	if method == "" || kind == EntryPayout {
+		method = "cash"
+	}
+
+	if kind != EntryPayment && kind != EntryPayout && kind != EntryDiscount && kind != EntryVoid {
+		return ErrInvalidEntry
+	}
+
+	if !paymentMethods[method] || len(note) > 255 {
+		return ErrInvalidEntry
+	}
+
+	if amount <= 0 || vat < 0 || vat > amount {
+		return ErrInvalidAmount
+	}
+
+	if _, err := s.checkPermission(ctx, Waiter, uid, rid); err != nil {
+		return err
+	}
+
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("could not begin tx: %v", err)
+	}
+	defer func() { _ = tx.Rollback() }()
+
+	var closed bool
+	query := "SELECT closed_at IS NOT NULL FROM shift WHERE id = $1 AND restaurant_id = $2"
+	err = tx.QueryRowContext(ctx, query, sid, rid).Scan(&closed)
+	if err == sql.ErrNoRows {
+		return ErrShiftNotFound
+	}
+
+	if err != nil {
+		return fmt.Errorf("could not query shift: %v", err)
+	}
+
+	if closed {
+		return ErrShiftClosed
+	}
+
+	order := sql.NullInt64{Int64: oid, Valid: oid > 0}
+	if order.Valid {
+		var exists bool
+		query = "SELECT EXISTS (SELECT 1 FROM orders WHERE id = $1 AND restaurant_id = $2)"
+		if err = tx.QueryRowContext(ctx, query, oid, rid).Scan(&exists); err != nil {
+			return fmt.Errorf("could not query order: %v", err)
+		}
+
+		if !exists {
+			return ErrOrderNotFound
+		}
+	}
+
+	query = `
+		INSERT INTO shift_entry (shift_id, kind, method, order_id, amount, vat, note, created_by)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8)`
+	if _, err = tx.ExecContext(ctx, query, sid, kind, method, order, amount, vat, note, uid); err != nil {
+		return fmt.Errorf("could not record shift entry: %v", err)
+	}
+
+	if err = tx.Commit(); err != nil {
+		return fmt.Errorf("could not record shift entry: could not commit transaction: %v", err)
+	}
+
+	return nil
+}
+
+// CloseShift with the cash counted in the drawer. A closed shift can be
+// recounted until it is signed off.
+func (s *Service) CloseShift(ctx context.Context, rid string, sid int64, counted float64) (*ZReport, error) {
+	uid, auth := ctx.Value(KeyAuthFoodProviderID).(int64)
+	if !auth {
+		return nil, ErrUnauthenticated
+	}
+
+	if !rxUUID.MatchString(rid) {
+		return nil, ErrInvalidRestaurantId
+	}
+
+	if counted < 0 {
+		return nil, ErrInvalidAmount
+	}
+
+	if _, err := s.checkPermission(ctx, Waiter, uid, rid); err != nil {
+		return nil, err
+	}
+
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, fmt.Errorf("could not begin tx: %v", err)
+	}
+	defer func() { _ = tx.Rollback() }()
+
+	z, err := zReport(ctx, tx, rid, sid)
+	if err != nil {
+		return nil, err
+	}
+
+	if z.SignedOffAt != nil {
+		return nil, ErrShiftSignedOff
+	}
+
+	query := `
+		UPDATE shift SET counted_cash = $1, closed_by = $2, closed_at = COALESCE(closed_at, now())
+		WHERE id = $3`
+	if _, err = tx.ExecContext(ctx, query, counted, uid, sid); err != nil {
+		return nil, fmt.Errorf("could not close shift: %v", err)
+	}
+
+	if z, err = zReport(ctx, tx, rid, sid); err != nil {
+		return nil, err
+	}
+
+	if err = tx.Commit(); err != nil {
+		return nil, fmt.Errorf("could not close shift: could not commit transaction: %v", err)
+	}
+
+	return z, nil
+}
+
+// SignOffShift makes the Z-report of a closed shift final. Only a
+// Supervisor or above can sign off.
+func (s *Service) SignOffShift(ctx context.Context, rid string, sid int64) (*ZReport, error) {
+	uid, auth := ctx.Value(KeyAuthFoodProviderID).(int64)
+	if !auth {
+		return nil, ErrUnauthenticated
+	}
+
+	if !rxUUID.MatchString(rid) {
+		return nil, ErrInvalidRestaurantId
+	}
+
+	if _, err := s.checkPermission(ctx, Supervisor, uid, rid); err != nil {
+		return nil, err
+	}
+
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, fmt.Errorf("could not begin tx: %v", err)
+	}
+	defer func() { _ = tx.Rollback() }()
+
+	z, err := zReport(ctx, tx, rid, sid)
+	if err != nil {
+		return nil, err
+	}
+
+	if z.SignedOffAt != nil {
+		return nil, ErrShiftSignedOff
+	}
+
+	if z.ClosedAt == nil {
+		return nil, ErrShiftNotClosed
+	}
+
+	query := "UPDATE shift SET signed_off_by = $1, signed_off_at = now() WHERE id = $2"
+	if _, err = tx.ExecContext(ctx, query, uid, sid); err != nil {
+		return nil, fmt.Errorf("could not sign off shift: %v", err)
+	}
+
+	if z, err = zReport(ctx, tx, rid, sid); err != nil {
+		return nil, err
+	}
+
+	if err = tx.Commit(); err != nil {
+		return nil, fmt.Errorf("could not sign off shift: could not commit transaction: %v", err)
+	}
+
+	return z, nil
+}
+
+func zReport(ctx context.Context, q queryer, rid string, sid int64) (*ZReport, error) {
+	z := ZReport{ShiftId: sid, Payments: make(map[string]float64)}
+	var counted sql.NullFloat64
+	query := `
+		SELECT opened_by, created_at, opening_float, counted_cash, closed_by, closed_at, signed_off_by, signed_off_at
+		FROM shift
+		WHERE id = $1 AND restaurant_id = $2`
+	err := q.QueryRowContext(ctx, query, sid, rid).Scan(&z.OpenedBy, &z.OpenedAt, &z.OpeningFloat, &counted,
+		&z.ClosedBy, &z.ClosedAt, &z.SignedOffBy, &z.SignedOffAt)
+	if err == sql.ErrNoRows {
+		return nil, ErrShiftNotFound
+	}
+
+	if err != nil {
+		return nil, fmt.Errorf("could not query shift: %v", err)
+	}
+
+	if counted.Valid {
+		z.CountedCash = &counted.Float64
+	}
+
+	query = "SELECT kind, method, amount, vat FROM shift_entry WHERE shift_id = $1"
+	rows, err := q.QueryContext(ctx, query, sid)
+	if err != nil {
+		return nil, fmt.Errorf("could not query shift entries: %v", err)
+	}
+
+	defer rows.Close()
+	for rows.Next() {
+		var kind, method string
+		var amount, vat float64
+		if err = rows.Scan(&kind, &method, &amount, &vat); err != nil {
+			return nil, fmt.Errorf("could not scan shift entry: %v", err)
+		}
+
+		z.add(kind, method, amount, vat)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("could not iterate shift entries: %v", err)
+	}
+
+	z.settle()
+
+	return &z, nil
+}
@@ -0,0 +1,60 @@
+package service
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestZReport(t *testing.T) {
+	type entry struct {
+		Kind   string
+		Method string
+		Amount float64
+		VAT    float64
+	}
+
+	var tt = []struct {
+		Label      string
+		Float      float64
+		Counted    float64
+		Entries    []entry
+		Expected   float64
+		Difference float64
+		Sales      float64
+	}{
+		{Label: "Test should expect only the float without entries", Float: 500, Counted: 500, Expected: 500, Difference: 0, Sales: 0},
+		{Label: "Test should add cash payments and take out payouts", Float: 500, Counted: 1100, Entries: []entry{
+			{Kind: EntryPayment, Method: "cash", Amount: 750.50, VAT: 97.89},
+			{Kind: EntryPayment, Method: "bkash", Amount: 300},
+			{Kind: EntryPayout, Method: "cash", Amount: 150.50},
+			{Kind: EntryDiscount, Method: "cash", Amount: 50},
+			{Kind: EntryVoid, Method: "cash", Amount: 120},
+		}, Expected: 1100, Difference: 0, Sales: 1050.50},
+		{Label: "Test should report a short drawer", Float: 0, Counted: 90, Entries: []entry{
+			{Kind: EntryPayment, Method: "cash", Amount: 100.10},
+		}, Expected: 100.10, Difference: -10.10, Sales: 100.10},
+	}
+
+	for _, test := range tt {
+		t.Run(test.Label, func(t *testing.T) {
+			z := ZReport{OpeningFloat: test.Float, Payments: make(map[string]float64)}
+			for _, e := range test.Entries {
+				z.add(e.Kind, e.Method, e.Amount, e.VAT)
+			}
+			counted := test.Counted
+			z.CountedCash = &counted
+			z.settle()
+
+			if !cmp.Equal(z.ExpectedCash, test.Expected) {
+				t.Error("Got:", z.ExpectedCash, "| Want:", test.Expected)
+			}
+			if !cmp.Equal(*z.Difference, test.Difference) {
+				t.Error("Got:", *z.Difference, "| Want:", test.Difference)
+			}
+			if !cmp.Equal(z.Sales, test.Sales) {
+				t.Error("Got:", z.Sales, "| Want:", test.Sales)
+			}
+		})
+	}
+}
@@ -0,0 +1,316 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// KeyLocale is the context key of the locale content is read in.
+const KeyLocale key = "locale"
+
+// DefaultLocale is the locale of the menu and restaurant text itself.
+// Translations fall back to it field by field.
+const DefaultLocale = "en"
+
+// Locales content can be translated to.
+var Locales = []string{"en", "bn"}
+
+var (
+	// ErrUnsupportedLocale denotes a locale outside Locales.
+	ErrUnsupportedLocale = errors.New("unsupported locale")
+	// ErrInvalidTranslation denotes a translated text longer than the text it translates may be.
+	ErrInvalidTranslation = errors.New("invalid translation")
+)
+
+// ItemTranslation of a menu item's name and description. Empty fields fall
+// back to the item's own.
+type ItemTranslation struct {
+	Name        string `json:"name"`
+	Description string `json:"description"`
+}
+
+// Translations of a restaurant's content, keyed by locale.
+type Translations struct {
+	About      map[string]string                    `json:"about"`
+	Categories map[int64]map[string]string          `json:"categories"`
+	Items      map[int64]map[string]ItemTranslation `json:"items"`
+}
+
+// ParseAcceptLanguage picks the supported locale an Accept-Language header
+// prefers, e.g. "bn-BD,bn;q=0.9,en;q=0.8" is "bn". Regions are ignored and
+// without a supported locale the default one is used.
+func ParseAcceptLanguage(header string) string {
+	locale, best := DefaultLocale, 0.0
+	for _, part := range strings.Split(header, ",") {
+		fields := strings.Split(part, ";")
+		tag := strings.ToLower(strings.TrimSpace(fields[0]))
+		if i := strings.IndexAny(tag, "-_"); i != -1 {
+			tag = tag[:i]
+		}
+
+		q := 1.0
+		for _, f := range fields[1:] {
+			f = strings.TrimSpace(f)
+			if strings.HasPrefix(f, "q=") {
+				var err error
+				if q, err = strconv.ParseFloat(f[2:], 64); err != nil {
+					q = 0
+				}
+			}
+		}
+
+		if q > best && contains(Locales, tag) {
+			locale, best = tag, q
+		}
+	}
+
+	return locale
+}
+
+// localeOf the request, set from its Accept-Language header.
+func localeOf(ctx context.Context) string {
+	if l, ok := ctx.Value(KeyLocale).(string); ok && contains(Locales, l) {
+		return l
+	}
+
+	return DefaultLocale
+}
+
+// GetTranslations of the restaurant's about text, categories and items.
+func (s *Service) GetTranslations(ctx context.Context, rid string) (Translations, error) {
+	t := Translations{
+		About:      make(map[string]string),
+		Categories: make(map[int64]map[string]string),
+		Items:      make(map[int64]map[string]ItemTranslation),
+	}
+
+	uid, auth := ctx.Value(KeyAuthFoodProviderID).(int64)
+	if !auth {
+		return t, ErrUnauthenticated
+	}
+
+	if !rxUUID.MatchString(rid) {
+		return t, ErrRestaurantNotFound
+	}
+
+	if _, err := s.checkPermission(ctx, Waiter, uid, rid); err != nil {
+		return t, err
+	}
+
+	query := "SELECT locale, about FROM restaurant_translation WHERE restaurant_id = $1"
+	rows, err := s.db.QueryContext(ctx, query, rid)
+	if err != nil {
+		return t, fmt.Errorf("could not query restaurant translations: %v", err)
+	}
+
+	defer rows.Close()
+	for rows.Next() {
+		var locale, about string
+		if err = rows.Scan(&locale, &about); err != nil {
+			return t, fmt.Errorf("could not scan restaurant translation: %v", err)
+		}
+
+		t.About[locale] = about
+	}
+
+	if err = rows.Err(); err != nil {
+		return t, fmt.Errorf("could not iterate restaurant translations: %v", err)
+	}
+
+	query = `
+		SELECT t.category_id, t.locale, t.label
+		FROM category_translation t INNER JOIN category c ON t.category_id = c.id
+		WHERE c.restaurant = $1 AND c.archived = false`
+	rows, err = s.db.QueryContext(ctx, query, rid)
+	if err != nil {
+		return t, fmt.Errorf("could not query category translations: %v", err)
+	}
+
+	defer rows.Close()
+	for rows.Next() {
+		var cid int64
+		var locale, label string
+		if err = rows.Scan(&cid, &locale, &label); err != nil {
+			return t, fmt.Errorf("could not scan category translation: %v", err)
+		}
+
+		if _, ok := t.Categories[cid]; !ok {
+			t.Categories[cid] = make(map[string]string)
+		}
+		t.Categories[cid][locale] = label
+	}
+
+	if err = rows.Err(); err != nil {
+		return t, fmt.Errorf("could not iterate category translations: %v", err)
+	}
+
+	query = `
+		SELECT t.item_id, t.locale, t.name, t.description
+		FROM item_translation t INNER JOIN item i ON t.item_id = i.id
+		WHERE i.restaurant_id = $1 AND i.archived = false`
+	rows, err = s.db.QueryContext(ctx, query, rid)
+	if err != nil {
+		return t, fmt.Errorf("could not query item translations: %v", err)
+	}
+
+	defer rows.Close()
+	for rows.Next() {
+		var iid int64
+		var locale string
+		var it ItemTranslation
+		if err = rows.Scan(&iid, &locale, &it.Name, &it.Description); err != nil {
+			return t, fmt.Errorf("could not scan item translation: %v", err)
+		}
+
+		if _, ok := t.Items[iid]; !ok {
+			t.Items[iid] = make(map[string]ItemTranslation)
+		}
+		t.Items[iid][locale] = it
+	}
+
+	if err = rows.Err(); err != nil {
+		return t, fmt.Errorf("could not iterate item translations: %v", err)
+	}
+
+	return t, nil
+}
+
+// checkTranslator checks the authenticated food provider can translate the
+// restaurant's content to the locale.
+func (s *Service) checkTranslator(ctx context.Context, rid, locale string) error {
+	uid, auth := ctx.Value(KeyAuthFoodProviderID).(int64)
+	if !auth {
+		return ErrUnauthenticated
+	}
+
+	if !rxUUID.MatchString(rid) {
+		return ErrRestaurantNotFound
+	}
+
+	if !contains(Locales, locale) {
+		return ErrUnsupportedLocale
+	}
+
+	_, err := s.checkPermission(ctx, Manager, uid, rid)
+	return err
+}
+
+// SetRestaurantTranslation of the restaurant's about text. An empty text
+// removes the translation.
+func (s *Service) SetRestaurantTranslation(ctx context.Context, rid, locale, about string) error {
+	about = strings.TrimSpace(about)
+	if err := s.checkTranslator(ctx, rid, locale); err != nil {
+		return err
+	}
+
+	var exists bool
+	query := "SELECT EXISTS (SELECT 1 FROM restaurant WHERE id = $1)"
+	if err := s.db.QueryRowContext(ctx, query, rid).Scan(&exists); err != nil {
+		return fmt.Errorf("could not query restaurant: %v", err)
+	}
+
+	if !exists {
+		return ErrRestaurantNotFound
+	}
+
+	if about == "" {
+		query = "DELETE FROM restaurant_translation WHERE restaurant_id = $1 AND locale = $2"
+		if _, err := s.db.ExecContext(ctx, query, rid, locale); err != nil {
+			return fmt.Errorf("could not delete restaurant translation: %v", err)
+		}
+		return nil
+	}
+
+	query = `
+		INSERT INTO restaurant_translation (restaurant_id, locale, about) VALUES ($1, $2, $3)
+		ON CONFLICT (restaurant_id, locale) DO UPDATE SET about = excluded.about`
+	if _, err := s.db.ExecContext(ctx, query, rid, locale, about); err != nil {
+		return fmt.Errorf("could not set restaurant translation: %v", err)
+	}
+
+	return nil
+}
+
+// SetCategoryTranslation of a menu category's label. An empty label removes
+// the translation.
+func (s *Service) SetCategoryTranslation(ctx context.Context, rid string, cid int64, locale, label string) error {
+	label = strings.TrimSpace(label)
+	if len([]rune(label)) > maxMenuNameLength {
+		return ErrInvalidTranslation
+	}
+
+	if err := s.checkTranslator(ctx, rid, locale); err != nil {
+		return err
+	}
+
+	var exists bool
+	query := "SELECT EXISTS (SELECT 1 FROM category WHERE id = $1 AND restaurant = $2 AND archived = false)"
+	if err := s.db.QueryRowContext(ctx, query, cid, rid).Scan(&exists); err != nil {
+		return fmt.Errorf("could not query category: %v", err)
+	}
+
+	if !exists {
+		return ErrCategoryNotFound
+	}
+
+	if label == "" {
+		query = "DELETE FROM category_translation WHERE category_id = $1 AND locale = $2"
+		if _, err := s.db.ExecContext(ctx, query, cid, locale); err != nil {
+			return fmt.Errorf("could not delete category translation: %v", err)
+		}
+		return nil
+	}
+
+	query = `
+		INSERT INTO category_translation (category_id, locale, label) VALUES ($1, $2, $3)
+		ON CONFLICT (category_id, locale) DO UPDATE SET label = excluded.label`
+	if _, err := s.db.ExecContext(ctx, query, cid, locale, label); err != nil {
+		return fmt.Errorf("could not set category translation: %v", err)
+	}
+
+	return nil
+}
+
+// SetItemTranslation of a menu item's name and description. Empty fields
+// fall back to the item's own; with both empty the translation is removed.
+func (s *Service) SetItemTranslation(ctx context.Context, rid string, iid int64, locale string, t ItemTranslation) error {
+	t.Name = strings.TrimSpace(t.Name)
+	t.Description = strings.TrimSpace(t.Description)
+	if len([]rune(t.Name)) > maxMenuNameLength || len([]rune(t.Description)) > 255 {
+		return ErrInvalidTranslation
+	}
+
+	if err := s.checkTranslator(ctx, rid, locale); err != nil {
+		return err
+	}
+
+	var exists bool
+	query := "SELECT EXISTS (SELECT 1 FROM item WHERE id = $1 AND restaurant_id = $2 AND archived = false)"
+	if err := s.db.QueryRowContext(ctx, query, iid, rid).Scan(&exists); err != nil {
+		return fmt.Errorf("could not query item: %v", err)
+	}
+
+	if !exists {
+		return ErrItemNotFound
+	}
+
+	if t.Name == "" && t.Description == "" {
+		query = "DELETE FROM item_translation WHERE item_id = $1 AND locale = $2"
+		if _, err := s.db.ExecContext(ctx, query, iid, locale); err != nil {
+			return fmt.Errorf("could not delete item translation: %v", err)
+		}
+		return nil
+	}
+
+	query = `
+		INSERT INTO item_translation (item_id, locale, name, description) VALUES ($1, $2, $3, $4)
+		ON CONFLICT (item_id, locale) DO UPDATE SET name = excluded.name, description = excluded.description`
+	if _, err := s.db.ExecContext(ctx, query, iid, locale, t.Name, t.Description); err != nil {
+		return fmt.Errorf("could not set item translation: %v", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,90 @@
+package service
+
+import "testing"
+
+func TestParseAcceptLanguage(t *testing.T) {
+	var tt = []struct {
+		Label  string
+		Header string
+		Want   string
+	}{
+		{Label: "Test should default without a header", Header: "", Want: "en"},
+		{Label: "Test should ignore the region", Header: "bn-BD", Want: "bn"},
+		{Label: "Test should prefer the highest quality", Header: "en;q=0.5, bn;q=0.8", Want: "bn"},
+		{Label: "Test should keep the first of equal qualities", Header: "en, bn", Want: "en"},
+		{Label: "Test should skip unsupported locales", Header: "fr-FR, bn;q=0.1", Want: "bn"},
+		{Label: "Test should default for unsupported locales", Header: "fr, de;q=0.9", Want: "en"},
+		{Label: "Test should skip refused locales", Header: "bn;q=0, en;q=0.2", Want: "en"},
+	}
+
+	for _, test := range tt {
+		t.Run(test.Label, func(t *testing.T) {
+			if got := ParseAcceptLanguage(test.Header); got != test.Want {
+				t.Error("Got:", got, "| Want:", test.Want)
+			}
+		})
+	}
+}
+
+func TestRxFullname(t *testing.T) {
+	var tt = []struct {
+		Label    string
+		Fullname string
+		Want     bool
+	}{
+		{Label: "Test should accept a latin name", Fullname: "John Snow", Want: true},
+		{Label: "Test should accept a bangla name", Fullname: "তৌফিক রহমান", Want: true},
+		{Label: "Test should reject digits", Fullname: "007John Snow", Want: false},
+		{Label: "Test should reject bangla digits", Fullname: "রহমান ১২", Want: false},
+		{Label: "Test should count characters, not bytes", Fullname: "আআআআআআআআআআআআআআআআআআআআআ", Want: false},
+	}
+
+	for _, test := range tt {
+		t.Run(test.Label, func(t *testing.T) {
+			if got := rxFullname.MatchString(test.Fullname); got != test.Want {
+				t.Error("Got:", got, "| Want:", test.Want)
+			}
+		})
+	}
+}
+
+func TestNormalizePhone(t *testing.T) {
+	var tt = []struct {
+		Label string
+		Phone string
+		Want  string
+	}{
+		{Label: "Test should keep ascii digits", Phone: " 01712345678 ", Want: "01712345678"},
+		{Label: "Test should write bangla digits in ascii", Phone: "+৮৮০১৭১২৩৪৫৬৭৮", Want: "+8801712345678"},
+		{Label: "Test should write mixed digits in ascii", Phone: "০১7১২345678", Want: "01712345678"},
+	}
+
+	for _, test := range tt {
+		t.Run(test.Label, func(t *testing.T) {
+			got := normalizePhone(test.Phone)
+			if got != test.Want || !rxPhone.MatchString(got) {
+				t.Error("Got:", got, "| Want:", test.Want)
+			}
+		})
+	}
+}
+
+func TestRxEmail(t *testing.T) {
+	var tt = []struct {
+		Label string
+		Email string
+		Want  bool
+	}{
+		{Label: "Test should accept an email", Email: "john@example.com", Want: true},
+		{Label: "Test should accept a bangla email", Email: "তৌফিক@উদাহরণ.বাংলা", Want: true},
+		{Label: "Test should reject a no-break space", Email: "john\u00a0snow@example.com", Want: false},
+	}
+
+	for _, test := range tt {
+		t.Run(test.Label, func(t *testing.T) {
+			if got := rxEmail.MatchString(test.Email); got != test.Want {
+				t.Error("Got:", got, "| Want:", test.Want)
+			}
+		})
+	}
+}
@@ -13,6 +13,7 @@ import (
 	"path"
 	"regexp"
 	"strings"
+	"unicode"
 
 	"github.com/disintegration/imaging"
 	gonanoid "github.com/matoous/go-nanoid"
@@ -23,8 +24,8 @@ import (
 const MaxImageBytes = 5 << 20 // 5MB
 
 var (
-	rxEmail    = regexp.MustCompile(`^[^\s@]+@[^\s@]+\.[^\s@]+$`)
-	rxFullname = regexp.MustCompile(`^[a-zA-Z ]{0,20}$`)
+	rxEmail    = regexp.MustCompile(`^[^\s\p{Z}@]+@[^\s\p{Z}@]+\.[^\s\p{Z}@]+$`)
+	rxFullname = regexp.MustCompile(`^[\p{L}\p{M} ]{0,20}$`)
 	rxPhone    = regexp.MustCompile(`(^([+]{1}[8]{2}|0088)?(01){1}[3-9]{1}\d{8})$`)
 	rxUUID     = regexp.MustCompile("^[0-9a-f]{8}-[0-9a-f]{4}-[0-9a-f]{4}-[0-9a-f]{4}-[0-9a-f]{12}$")
 
@@ -65,6 +66,23 @@ var (
 	ErrPhoneNumberTaken = errors.New("phone number taken")
 )
 
+// normalizePhone trims the phone number and writes its digits in ASCII, so
+// numbers typed in Bangla digits match rxPhone and are stored alike.
+func normalizePhone(phone string) string {
+	return strings.Map(func(r rune) rune {
+		if r <= unicode.MaxASCII || !unicode.IsDigit(r) {
+			return r
+		}
+
+		// Decimal digits come in runs of ten from zero.
+		start := r
+		for unicode.IsDigit(start - 1) {
+			start--
+		}
+		return '0' + (r-start)%10
+	}, strings.TrimSpace(phone))
+}
+
 // User model.
 type User struct {
 	ID       int64  `json:"id,omitempty"`
@@ -95,7 +113,7 @@ func (s *Service) CreateUser(ctx context.Context, email, phone, fullname, passwo
 		return ErrInvalidFullname
 	}
 
-	phone = strings.TrimSpace(phone)
+	phone = normalizePhone(phone)
 	if !rxPhone.MatchString(phone) {
 		return ErrInvalidPhone
 	}
@@ -158,7 +176,7 @@ func (s *Service) UpdateUser(ctx context.Context, address, phone string) error {
 		_, err = tx.ExecContext(ctx, query, address, uid)
 	}
 
-	if phone != "" {
+	if phone = normalizePhone(phone); phone != "" {
 		if !rxPhone.MatchString(phone) {
 			return ErrInvalidPhone
 		}
@@ -51,11 +51,11 @@ func TestService_CreateUser(t *testing.T) {
 		log.Fatalf("failed to validate schema: %v\n", err)
 	}
 
-	s := New(db, nil,  codec, fpCodec, nil, url.URL{})
+	s := New(db, codec, fpCodec, nil, url.URL{})
 
 	for _, test := range tt {
 		t.Run(test.Label, func(t *testing.T) {
-			Got := s.CreateUser(ctx, test.Email, test.Fullname, test.Password)
+			Got := s.CreateUser(ctx, test.Email, "01867584576", test.Fullname, test.Password)
 			if test.Condition == "success" {
 				if Got != nil {
 					t.Error("Got:", Got, "| Want:", test.Want)
@@ -106,11 +106,11 @@ func TestService_UpdateUser(t *testing.T) {
 		log.Fatalf("failed to validate schema: %v\n", err)
 	}
 
-	s := New(db, nil, codec, nil,nil, url.URL{})
+	s := New(db, codec, nil, nil, url.URL{})
 
 	for _, test := range tt {
 		t.Run(test.Label, func(t *testing.T) {
-			_ = s.CreateUser(ctx, test.Email, test.Fullname, test.Password)
+			_ = s.CreateUser(ctx, test.Email, "01867584576", test.Fullname, test.Password)
 			user, _ := s.UserLogin(ctx, test.Email, test.Password)
 			ctx = context.WithValue(ctx, KeyAuthUserID, user.AuthUser.ID)
 			Got := s.UpdateUser(ctx, test.Address, test.Phone)
@@ -4,6 +4,7 @@ import (
 	"context"
 	"database/sql"
 	"fmt"
+	"io/ioutil"
 	"log"
 	"net/http"
 	"net/url"
@@ -34,6 +35,9 @@ func run() error {
 		userTokenKey = env("TOKEN_KEY", "supersecretkeyyoushouldnotcommit")
 		fpTokenKey = env("TOKEN_KEY", "supersecretkeyyoushouldcommitook")
 		ambassadorTokenKey = env("TOKEN_KEY", "supersecretkeyyoushouldcommit111")
+		linkKey = env("LINK_KEY", "supersecretlinkkeyyoushouldnotcommit")
+		menuFont = env("MENU_FONT", "")
+		menuBoldFont = env("MENU_BOLD_FONT", "")
 	)
 
 	log := logrus.New()
@@ -46,6 +50,10 @@ func run() error {
 	}
 	log.SetReportCaller(true)
 
+	if _, ok := os.LookupEnv("LINK_KEY"); !ok {
+		log.Warn("LINK_KEY is not set, table menu links are signed with the development key")
+	}
+
 	origin, err := url.Parse(originStr)
 	if err != nil || !origin.IsAbs() {
 		log.WithError(err).Fatal("invalid origin url:")
@@ -83,6 +91,29 @@ func run() error {
 	aCodec.SetTTL(uint32(service.TokenLifeSpan.Seconds()))
 
 	s := service.New(db, codec, fpCodec, aCodec, *origin)
+	s.SetLogger(log)
+	s.SetLinkKey([]byte(linkKey))
+	if menuFont != "" {
+		regular, err := ioutil.ReadFile(menuFont)
+		if err != nil {
+			return fmt.Errorf("could not read menu font: %v", err)
+		}
+
+		var bold []byte
+		if menuBoldFont != "" {
+			if bold, err = ioutil.ReadFile(menuBoldFont); err != nil {
+				return fmt.Errorf("could not read menu bold font: %v", err)
+			}
+		}
+		s.SetMenuFonts(regular, bold)
+	}
+
+	if err = s.IndexSearch(context.Background()); err != nil {
+		log.Printf("could not build search index: %v\n", err)
+	}
+
+	go s.RefreshSearchIndex(context.Background(), time.Minute*10)
+	go s.ApplyScheduledPrices(context.Background(), time.Minute)
 
 	//fixtures.PopulateFoodProvider(s)
 
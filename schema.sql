@@ -65,14 +65,19 @@ CREATE TABLE IF NOT EXISTS restaurant
     area            VARCHAR NOT NULL,
     country         VARCHAR NOT NULL,
     phone           VARCHAR NOT NULL,
-    opening_time    VARCHAR NOT NULL,
-    closing_time    VARCHAR NOT NULL,
+    latitude        DECIMAL(9,6) CHECK (latitude >= -90 AND latitude <= 90),
+    longitude       DECIMAL(9,6) CHECK (longitude >= -180 AND longitude <= 180),
     ambassador_code VARCHAR,
     vat_reg_no      VARCHAR,
-    rating          DECIMAL(1,1) DEFAULT 0.0 CHECK (rating >= 0),
+    rating          DECIMAL(2,1) NOT NULL DEFAULT 0.0 CHECK (rating >= 0 AND rating <= 5),
+    review_count    INT NOT NULL DEFAULT 0,
     active          BOOLEAN NOT NULL DEFAULT true,
-    close_status    BOOLEAN NOT NULL DEFAULT true,
-    created_at      TIMESTAMPTZ NOT NULL DEFAULT now()
+    ordering_paused BOOLEAN NOT NULL DEFAULT false,
+    paused_until    TIMESTAMPTZ,
+    timezone        VARCHAR NOT NULL DEFAULT 'Asia/Dhaka',
+    created_at      TIMESTAMPTZ NOT NULL DEFAULT now(),
+
+    INDEX (latitude, longitude)
 );
 
 CREATE TABLE IF NOT EXISTS permission
@@ -100,9 +105,11 @@ CREATE TABLE IF NOT EXISTS category
     restaurant      UUID NOT NULL,
     label           VARCHAR(25) NOT NULL,
     availability    BOOLEAN NOT NULL DEFAULT true,
+    archived        BOOLEAN NOT NULL DEFAULT false,
+    position        INT NOT NULL DEFAULT 0,
     created_at      TIMESTAMPTZ NOT NULL DEFAULT now(),
 
-    UNIQUE (restaurant, label)
+    UNIQUE INDEX (restaurant, label) WHERE archived = false
 );
 
 CREATE TABLE IF NOT EXISTS item
@@ -114,21 +121,47 @@ CREATE TABLE IF NOT EXISTS item
     description     VARCHAR(255) NOT NULL,
     price           DECIMAL(12,2) NOT NULL CHECK (price > 0),
     availability    BOOLEAN NOT NULL DEFAULT true,
+    sold_out        BOOLEAN NOT NULL DEFAULT false,
+    archived        BOOLEAN NOT NULL DEFAULT false,
+    position        INT NOT NULL DEFAULT 0,
+    spice_level     INT NOT NULL DEFAULT 0 CHECK (spice_level >= 0 AND spice_level <= 3),
     created_at      TIMESTAMPTZ NOT NULL DEFAULT now(),
 
-    UNIQUE (restaurant_id, name)
+    UNIQUE INDEX (restaurant_id, name) WHERE archived = false
 );
 
-CREATE TABLE IF NOT EXISTS item_gallery
+CREATE TABLE IF NOT EXISTS item_price
 (
-    id              SERIAL NOT NULL,
+    id              SERIAL NOT NULL PRIMARY KEY,
     item_id         INT NOT NULL REFERENCES item,
-    image1          VARCHAR NOT NULL,
-    image2          VARCHAR NOT NULL,
-    image3          VARCHAR NOT NULL,
+    price           DECIMAL(12,2) NOT NULL CHECK (price > 0),
+    previous_price  DECIMAL(12,2),
+    changed_by      INT NOT NULL REFERENCES foodprovider,
+    effective_at    TIMESTAMPTZ NOT NULL DEFAULT now(),
+    applied         BOOLEAN NOT NULL DEFAULT false,
+    created_at      TIMESTAMPTZ NOT NULL DEFAULT now(),
+
+    INDEX (item_id, effective_at)
+);
+
+CREATE TABLE IF NOT EXISTS item_gallery
+(
+    item_id         INT NOT NULL PRIMARY KEY REFERENCES item,
+    image1          VARCHAR,
+    image2          VARCHAR,
+    image3          VARCHAR,
+    created_at      TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+
+CREATE TABLE IF NOT EXISTS dining_table
+(
+    id              SERIAL NOT NULL PRIMARY KEY,
+    restaurant_id   UUID NOT NULL REFERENCES restaurant,
+    label           VARCHAR(20) NOT NULL,
+    archived        BOOLEAN NOT NULL DEFAULT false,
     created_at      TIMESTAMPTZ NOT NULL DEFAULT now(),
 
-    PRIMARY KEY (id, item_id)
+    UNIQUE INDEX (restaurant_id, label) WHERE archived = false
 );
 
 CREATE TABLE IF NOT EXISTS orders
@@ -136,20 +169,118 @@ CREATE TABLE IF NOT EXISTS orders
     id              SERIAL NOT NULL PRIMARY KEY,
     cust_id         INT NOT NULL REFERENCES users,
     restaurant_id   UUID NOT NULL REFERENCES restaurant,
+    table_id        INT REFERENCES dining_table,
     status          INT NOT NULL,
     created_at      TIMESTAMPTZ NOT NULL DEFAULT now(),
 
     INDEX (restaurant_id)
 );
 
+CREATE TABLE IF NOT EXISTS item_tag
+(
+    item_id         INT NOT NULL REFERENCES item,
+    kind            VARCHAR(10) NOT NULL,
+    tag             VARCHAR(20) NOT NULL,
+
+    PRIMARY KEY (item_id, kind, tag)
+);
+
+CREATE TABLE IF NOT EXISTS item_variant
+(
+    id              SERIAL NOT NULL PRIMARY KEY,
+    item_id         INT NOT NULL REFERENCES item,
+    name            VARCHAR(25) NOT NULL,
+    price           DECIMAL(12,2) NOT NULL CHECK (price > 0),
+    archived        BOOLEAN NOT NULL DEFAULT false,
+    created_at      TIMESTAMPTZ NOT NULL DEFAULT now(),
+
+    UNIQUE (item_id, name)
+);
+
+CREATE TABLE IF NOT EXISTS modifier_group
+(
+    id              SERIAL NOT NULL PRIMARY KEY,
+    item_id         INT NOT NULL REFERENCES item,
+    name            VARCHAR(25) NOT NULL,
+    min_select      INT NOT NULL DEFAULT 0 CHECK (min_select >= 0),
+    max_select      INT NOT NULL DEFAULT 1 CHECK (max_select >= 1),
+    archived        BOOLEAN NOT NULL DEFAULT false,
+    created_at      TIMESTAMPTZ NOT NULL DEFAULT now(),
+
+    CHECK (min_select <= max_select),
+    UNIQUE (item_id, name)
+);
+
+CREATE TABLE IF NOT EXISTS modifier_option
+(
+    id              SERIAL NOT NULL PRIMARY KEY,
+    group_id        INT NOT NULL REFERENCES modifier_group,
+    name            VARCHAR(25) NOT NULL,
+    price           DECIMAL(12,2) NOT NULL DEFAULT 0 CHECK (price >= 0),
+    created_at      TIMESTAMPTZ NOT NULL DEFAULT now(),
+
+    UNIQUE (group_id, name)
+);
+
+CREATE TABLE IF NOT EXISTS modifier_option_allergen
+(
+    option_id       INT NOT NULL REFERENCES modifier_option,
+    allergen        VARCHAR NOT NULL,
+
+    PRIMARY KEY (option_id, allergen)
+);
+
+CREATE TABLE IF NOT EXISTS combo_slot
+(
+    id              SERIAL NOT NULL PRIMARY KEY,
+    item_id         INT NOT NULL REFERENCES item,
+    name            VARCHAR(25) NOT NULL,
+    quantity        INT NOT NULL DEFAULT 1 CHECK (quantity > 0),
+    created_at      TIMESTAMPTZ NOT NULL DEFAULT now(),
+
+    INDEX (item_id)
+);
+
+CREATE TABLE IF NOT EXISTS combo_choice
+(
+    slot_id         INT NOT NULL REFERENCES combo_slot,
+    item_id         INT NOT NULL REFERENCES item,
+
+    PRIMARY KEY (slot_id, item_id)
+);
+
 CREATE TABLE IF NOT EXISTS order_item
 (
+    id              SERIAL NOT NULL PRIMARY KEY,
     order_id        INT NOT NULL REFERENCES orders,
     item_id         INT NOT NULL REFERENCES item,
+    variant_id      INT REFERENCES item_variant,
     quantity        INT NOT NULL DEFAULT 1 CHECK (quantity > 0),
+    price           DECIMAL(12,2) NOT NULL DEFAULT 0,
     created_at      TIMESTAMPTZ NOT NULL DEFAULT now(),
 
-    PRIMARY KEY (order_id, item_id)
+    INDEX (order_id)
+);
+
+CREATE TABLE IF NOT EXISTS order_item_option
+(
+    order_item_id   INT NOT NULL REFERENCES order_item,
+    option_id       INT NOT NULL REFERENCES modifier_option,
+    name            VARCHAR(25) NOT NULL,
+    price           DECIMAL(12,2) NOT NULL DEFAULT 0,
+
+    PRIMARY KEY (order_item_id, option_id)
+);
+
+CREATE TABLE IF NOT EXISTS order_item_component
+(
+    order_item_id   INT NOT NULL REFERENCES order_item,
+    position        INT NOT NULL,
+    item_id         INT NOT NULL REFERENCES item,
+    slot            VARCHAR(25) NOT NULL,
+    quantity        INT NOT NULL DEFAULT 1 CHECK (quantity > 0),
+
+    PRIMARY KEY (order_item_id, position)
 );
 -- INSERT INTO users (id, email, fullname)
 -- VALUES (1, 'jon@example.org', 'jon snow'),
@@ -162,4 +293,237 @@ CREATE TABLE IF NOT EXISTS restaurant_offers
     image           VARCHAR NOT NULL,
     created_at      TIMESTAMPTZ NOT NULL DEFAULT now(),
     PRIMARY KEY (id, restaurant_id)
-);
\ No newline at end of file
+);
+
+CREATE TABLE IF NOT EXISTS review
+(
+    id              SERIAL NOT NULL PRIMARY KEY,
+    order_id        INT NOT NULL UNIQUE REFERENCES orders,
+    restaurant_id   UUID NOT NULL REFERENCES restaurant,
+    user_id         INT NOT NULL REFERENCES users,
+    stars           INT NOT NULL CHECK (stars >= 1 AND stars <= 5),
+    body            VARCHAR(1000) NOT NULL DEFAULT '',
+    reply           VARCHAR(1000),
+    replied_at      TIMESTAMPTZ,
+    hidden          BOOLEAN NOT NULL DEFAULT false,
+    unhidden_at     TIMESTAMPTZ,
+    created_at      TIMESTAMPTZ NOT NULL DEFAULT now(),
+
+    INDEX (restaurant_id)
+);
+
+CREATE TABLE IF NOT EXISTS review_picture
+(
+    id              SERIAL,
+    review_id       INT NOT NULL REFERENCES review,
+    image           VARCHAR NOT NULL,
+    created_at      TIMESTAMPTZ NOT NULL DEFAULT now(),
+
+    PRIMARY KEY (id, review_id)
+);
+
+CREATE TABLE IF NOT EXISTS review_flag
+(
+    review_id       INT NOT NULL REFERENCES review,
+    reporter        VARCHAR NOT NULL,
+    reason          VARCHAR(255) NOT NULL,
+    created_at      TIMESTAMPTZ NOT NULL DEFAULT now(),
+
+    PRIMARY KEY (review_id, reporter)
+);
+
+CREATE TABLE IF NOT EXISTS shift
+(
+    id              SERIAL NOT NULL PRIMARY KEY,
+    restaurant_id   UUID NOT NULL REFERENCES restaurant,
+    opened_by       INT NOT NULL REFERENCES foodprovider,
+    opening_float   DECIMAL(12,2) NOT NULL CHECK (opening_float >= 0),
+    counted_cash    DECIMAL(12,2),
+    closed_by       INT REFERENCES foodprovider,
+    closed_at       TIMESTAMPTZ,
+    signed_off_by   INT REFERENCES foodprovider,
+    signed_off_at   TIMESTAMPTZ,
+    created_at      TIMESTAMPTZ NOT NULL DEFAULT now(),
+
+    INDEX (restaurant_id),
+    UNIQUE INDEX (restaurant_id) WHERE closed_at IS NULL
+);
+
+CREATE TABLE IF NOT EXISTS shift_entry
+(
+    id              SERIAL NOT NULL PRIMARY KEY,
+    shift_id        INT NOT NULL REFERENCES shift,
+    kind            VARCHAR NOT NULL,
+    method          VARCHAR NOT NULL DEFAULT 'cash',
+    order_id        INT REFERENCES orders,
+    amount          DECIMAL(12,2) NOT NULL CHECK (amount > 0),
+    vat             DECIMAL(12,2) NOT NULL DEFAULT 0 CHECK (vat >= 0),
+    note            VARCHAR(255) NOT NULL DEFAULT '',
+    created_by      INT NOT NULL REFERENCES foodprovider,
+    created_at      TIMESTAMPTZ NOT NULL DEFAULT now(),
+
+    INDEX (shift_id)
+);
+
+CREATE TABLE IF NOT EXISTS ingredient
+(
+    id              SERIAL NOT NULL PRIMARY KEY,
+    restaurant_id   UUID NOT NULL REFERENCES restaurant,
+    name            VARCHAR(50) NOT NULL,
+    unit            VARCHAR(10) NOT NULL,
+    stock           DECIMAL(12,3) NOT NULL DEFAULT 0,
+    low_stock       DECIMAL(12,3) NOT NULL DEFAULT 0 CHECK (low_stock >= 0),
+    created_at      TIMESTAMPTZ NOT NULL DEFAULT now(),
+
+    UNIQUE (restaurant_id, name)
+);
+
+CREATE TABLE IF NOT EXISTS recipe
+(
+    item_id         INT NOT NULL REFERENCES item,
+    ingredient_id   INT NOT NULL REFERENCES ingredient,
+    quantity        DECIMAL(12,3) NOT NULL CHECK (quantity > 0),
+
+    PRIMARY KEY (item_id, ingredient_id)
+);
+
+CREATE TABLE IF NOT EXISTS schedule
+(
+    id              SERIAL NOT NULL PRIMARY KEY,
+    restaurant_id   UUID NOT NULL REFERENCES restaurant,
+    category_id     INT REFERENCES category,
+    item_id         INT REFERENCES item,
+    days            INT NOT NULL DEFAULT 0,
+    start_time      VARCHAR(5) NOT NULL DEFAULT '',
+    end_time        VARCHAR(5) NOT NULL DEFAULT '',
+    from_date       VARCHAR(10) NOT NULL DEFAULT '',
+    to_date         VARCHAR(10) NOT NULL DEFAULT '',
+    created_at      TIMESTAMPTZ NOT NULL DEFAULT now(),
+
+    CHECK ((category_id IS NULL) != (item_id IS NULL)),
+    INDEX (restaurant_id)
+);
+
+CREATE TABLE IF NOT EXISTS restaurant_translation
+(
+    restaurant_id   UUID NOT NULL REFERENCES restaurant,
+    locale          VARCHAR(5) NOT NULL,
+    about           VARCHAR NOT NULL DEFAULT '',
+
+    PRIMARY KEY (restaurant_id, locale)
+);
+
+CREATE TABLE IF NOT EXISTS category_translation
+(
+    category_id     INT NOT NULL REFERENCES category,
+    locale          VARCHAR(5) NOT NULL,
+    label           VARCHAR(25) NOT NULL DEFAULT '',
+
+    PRIMARY KEY (category_id, locale)
+);
+
+CREATE TABLE IF NOT EXISTS item_translation
+(
+    item_id         INT NOT NULL REFERENCES item,
+    locale          VARCHAR(5) NOT NULL,
+    name            VARCHAR(25) NOT NULL DEFAULT '',
+    description     VARCHAR(255) NOT NULL DEFAULT '',
+
+    PRIMARY KEY (item_id, locale)
+);
+
+CREATE TABLE IF NOT EXISTS pricing_rule
+(
+    id              SERIAL NOT NULL PRIMARY KEY,
+    restaurant_id   UUID NOT NULL REFERENCES restaurant,
+    category_id     INT REFERENCES category,
+    item_id         INT REFERENCES item,
+    name            VARCHAR(50) NOT NULL,
+    kind            VARCHAR(10) NOT NULL,
+    amount          DECIMAL(12,2) NOT NULL CHECK (amount > 0),
+    days            INT NOT NULL DEFAULT 0,
+    start_time      VARCHAR(5) NOT NULL DEFAULT '',
+    end_time        VARCHAR(5) NOT NULL DEFAULT '',
+    from_date       VARCHAR(10) NOT NULL DEFAULT '',
+    to_date         VARCHAR(10) NOT NULL DEFAULT '',
+    created_at      TIMESTAMPTZ NOT NULL DEFAULT now(),
+
+    CHECK ((category_id IS NULL) != (item_id IS NULL)),
+    INDEX (restaurant_id)
+);
+
+CREATE TABLE IF NOT EXISTS menu_draft
+(
+    restaurant_id   UUID NOT NULL PRIMARY KEY REFERENCES restaurant,
+    base_version    INT NOT NULL DEFAULT 0,
+    base_digest     VARCHAR(64) NOT NULL DEFAULT '',
+    menu            JSONB NOT NULL,
+    updated_by      INT NOT NULL REFERENCES foodprovider,
+    updated_at      TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+
+CREATE TABLE IF NOT EXISTS menu_version
+(
+    id              SERIAL NOT NULL PRIMARY KEY,
+    restaurant_id   UUID NOT NULL REFERENCES restaurant,
+    number          INT NOT NULL,
+    menu            JSONB NOT NULL,
+    rolled_back_from INT NOT NULL DEFAULT 0,
+    published_by    INT NOT NULL REFERENCES foodprovider,
+    published_at    TIMESTAMPTZ NOT NULL DEFAULT now(),
+
+    UNIQUE (restaurant_id, number)
+);
+
+CREATE TABLE IF NOT EXISTS menu_link
+(
+    branch_id       UUID NOT NULL PRIMARY KEY REFERENCES restaurant,
+    master_id       UUID NOT NULL REFERENCES restaurant,
+    linked_by       INT NOT NULL REFERENCES foodprovider,
+    synced_at       TIMESTAMPTZ,
+    sync_error      VARCHAR NOT NULL DEFAULT '',
+    created_at      TIMESTAMPTZ NOT NULL DEFAULT now(),
+
+    INDEX (master_id)
+);
+
+CREATE TABLE IF NOT EXISTS menu_override
+(
+    branch_id       UUID NOT NULL REFERENCES restaurant,
+    item_id         INT NOT NULL REFERENCES item,
+    price           DECIMAL(12,2) CHECK (price > 0),
+    availability    BOOLEAN,
+
+    PRIMARY KEY (branch_id, item_id)
+);
+
+CREATE TABLE IF NOT EXISTS opening_hours
+(
+    id              SERIAL NOT NULL PRIMARY KEY,
+    restaurant_id   UUID NOT NULL REFERENCES restaurant,
+    day             INT NOT NULL CHECK (day >= 0 AND day <= 6),
+    open_time       VARCHAR(5) NOT NULL,
+    close_time      VARCHAR(5) NOT NULL,
+
+    INDEX (restaurant_id)
+);
+
+CREATE TABLE IF NOT EXISTS hours_exception
+(
+    restaurant_id   UUID NOT NULL REFERENCES restaurant,
+    date            VARCHAR(10) NOT NULL,
+    open_time       VARCHAR(5) NOT NULL DEFAULT '',
+    close_time      VARCHAR(5) NOT NULL DEFAULT '',
+    note            VARCHAR(50) NOT NULL DEFAULT '',
+
+    PRIMARY KEY (restaurant_id, date)
+);
+
+CREATE TABLE IF NOT EXISTS restaurant_cuisine
+(
+    restaurant_id   UUID NOT NULL REFERENCES restaurant,
+    cuisine         VARCHAR(20) NOT NULL,
+
+    PRIMARY KEY (restaurant_id, cuisine),
+    INDEX (cuisine)
+);